@@ -0,0 +1,303 @@
+package envcfg
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sethpollack/envcfg/internal/loader"
+	"github.com/sethpollack/envcfg/internal/walker"
+	"github.com/sethpollack/envcfg/sources"
+)
+
+// Description is the effective configuration of a set of options.
+type Description struct {
+	// Tags maps each tag role to the struct tag name used for it,
+	// e.g. "default" to "default".
+	Tags map[string]string
+	// InitMode is the default init mode: "vars", "any", "always" or "never".
+	InitMode  string
+	Delimiter string
+	// SubDelimiter separates the elements of nested slices.
+	SubDelimiter string
+	Separator    string
+	// KeySeparator joins the names of nested fields.
+	KeySeparator string
+	// SliceGaps is how missing slice indexes are handled: "stop", "skip"
+	// or "error", MaxSliceIndex the highest index looked up, 0 if unlimited.
+	SliceGaps     string
+	MaxSliceIndex int
+	// MaxDepth is the deepest field path that is walked, 0 if unlimited.
+	MaxDepth int
+	// MapMode is how maps with entries are set: "merge" or "replace".
+	MapMode string
+
+	Required                 bool
+	NotEmpty                 bool
+	NotEmptyTrimSpace        bool
+	TrimSpace                bool
+	Expand                   bool
+	DecodeUnset              bool
+	KeepNonZeroDefaults      bool
+	NoOverride               bool
+	DisableFallback          bool
+	DisableFieldNameFallback bool
+	DisableTagFallback       bool
+	FallbackTags             []string
+	SquashEmbedded           bool
+	BracketIndex             bool
+	EmptyAsUnset             bool
+	UnsetToken               string
+	RequireExplicitTags      bool
+	Profile                  string
+	ConfigVar                string
+	MaxErrors                int
+	CollectErrors            bool
+	DisableValidate          bool
+	Strict                   bool
+	FileBaseDir              string
+
+	// TypeParsers, KindParsers and Decoders list the registered parser
+	// types, parser kinds and decoder interfaces, sorted by name.
+	TypeParsers []string
+	KindParsers []string
+	Decoders    []string
+	// Validators lists the names of the custom validation rules.
+	Validators []string
+	// DefaultFuncs lists the names of the default functions, sorted.
+	DefaultFuncs []string
+	// Implementations lists the registered implementations of interface
+	// fields as "interface name", sorted.
+	Implementations []string
+	// ExpandLookups lists where expanded values look up variables, in order.
+	ExpandLookups []string
+
+	// Sources are the configured sources in load order.
+	Sources []SourceDescription
+	// Overrides are the sources that take precedence over all others.
+	Overrides []SourceDescription
+
+	// MatcherWrappers, ParserWrappers and WalkerWrappers count the
+	// registered WithMatcher, WithParser and WithWalker wrappers.
+	MatcherWrappers int
+	ParserWrappers  int
+	WalkerWrappers  int
+}
+
+// SourceDescription describes a configured source.
+type SourceDescription struct {
+	// Type is the Go type of the source, e.g. "*dotenv.source".
+	Type string
+	// Default reports whether the source is only used because
+	// its loader has no other sources.
+	Default bool
+	// Priority is the priority of the source.
+	Priority int
+	// Optional reports whether the source is skipped when it fails to load.
+	Optional bool
+	// Prefix is the prefix added to the keys of the source.
+	Prefix string
+	// Filters and Transforms count the filters and transforms of a loader
+	// or a configured source.
+	Filters    int
+	Transforms int
+	// Sources are the sources of a loader.
+	Sources []SourceDescription
+}
+
+// Describe returns the effective configuration of the options,
+// without loading any sources.
+func Describe(opts ...Option) Description {
+	return newOptions(opts...).Describe()
+}
+
+// Describe returns the effective configuration of the options.
+func (o *Options) Describe() Description {
+	d := Description{
+		Tags: map[string]string{
+			"env":           o.Matcher.TagName,
+			"default":       o.Matcher.DefaultTag,
+			"defaultFile":   o.Matcher.DefaultFileTag,
+			"required":      o.Matcher.RequiredTag,
+			"notempty":      o.Matcher.NotEmptyTag,
+			"expand":        o.Matcher.ExpandTag,
+			"file":          o.Matcher.FileTag,
+			"desc":          o.Matcher.DescTag,
+			"example":       o.Matcher.ExampleTag,
+			"sensitive":     o.Matcher.SensitiveTag,
+			"renamedFrom":   o.Matcher.RenamedFromTag,
+			"defaultEnv":    o.Matcher.DefaultEnvTag,
+			"delim":         o.Walker.DelimTag,
+			"subdelim":      o.Walker.SubDelimTag,
+			"sep":           o.Walker.SepTag,
+			"init":          o.Walker.InitTag,
+			"mapmode":       o.Walker.MapModeTag,
+			"keep":          o.Walker.KeepTag,
+			"encoding":      o.Walker.EncodingTag,
+			"base64":        o.Walker.Base64Tag,
+			"ignore":        o.Walker.IgnoreTag,
+			"decodeunset":   o.Walker.DecodeUnsetTag,
+			"skipUnless":    o.Walker.SkipUnlessTag,
+			"remain":        o.Walker.RemainTag,
+			"group":         o.Walker.GroupTag,
+			"grouprequired": o.Walker.GroupRequiredTag,
+			"required_if":   o.Walker.RequiredIfTag,
+			"required_with": o.Walker.RequiredWithTag,
+			"validate":      o.Walker.ValidateTag,
+			"envPrefix":     o.Matcher.PrefixTag,
+			"squash":        o.Matcher.SquashTag,
+			"alias":         o.Matcher.AliasTag,
+		},
+		InitMode:     initModeName(o.Walker.InitMode),
+		Delimiter:    o.Walker.DefaultDelim,
+		SubDelimiter: o.Walker.DefaultSubDelim,
+		Separator:    o.Walker.DefaultSep,
+		KeySeparator: o.Matcher.KeySeparator,
+
+		SliceGaps:     sliceGapsName(o.Walker.SliceGaps),
+		MaxSliceIndex: o.Walker.MaxSliceIndex,
+		MaxDepth:      o.Walker.MaxDepth,
+		MapMode:       mapModeName(o.Walker.MapMode),
+
+		Required:                 o.Matcher.Required,
+		NotEmpty:                 o.Matcher.NotEmpty,
+		NotEmptyTrimSpace:        o.Matcher.NotEmptyTrimSpace,
+		TrimSpace:                o.Walker.TrimSpace,
+		Expand:                   o.Matcher.Expand,
+		DecodeUnset:              o.Walker.DecodeUnset,
+		KeepNonZeroDefaults:      o.Walker.KeepNonZeroDefaults,
+		NoOverride:               o.Walker.NoOverride,
+		DisableFallback:          o.Matcher.DisableFallback,
+		DisableFieldNameFallback: o.Matcher.DisableFieldNameFallback,
+		DisableTagFallback:       o.Matcher.DisableTagFallback,
+		FallbackTags:             o.Matcher.FallbackTags,
+		SquashEmbedded:           o.Matcher.SquashEmbedded,
+		BracketIndex:             o.Matcher.BracketIndex,
+		EmptyAsUnset:             o.Matcher.EmptyAsUnset,
+		UnsetToken:               o.Matcher.UnsetToken,
+		RequireExplicitTags:      o.Walker.RequireExplicitTags,
+		Profile:                  o.Matcher.Profile,
+		ConfigVar:                o.configVar,
+		MaxErrors:                o.Walker.MaxErrors,
+		CollectErrors:            o.Walker.CollectErrors,
+		DisableValidate:          o.Walker.DisableValidate,
+		Strict:                   o.strict,
+		FileBaseDir:              o.Matcher.FileBaseDir,
+
+		MatcherWrappers: len(o.matcherWrappers),
+		ParserWrappers:  len(o.parserWrappers),
+		WalkerWrappers:  len(o.walkerWrappers),
+	}
+
+	for t := range o.Parser.TypeParsers {
+		d.TypeParsers = append(d.TypeParsers, t.String())
+	}
+	sort.Strings(d.TypeParsers)
+
+	for k := range o.Parser.KindParsers {
+		d.KindParsers = append(d.KindParsers, k.String())
+	}
+	sort.Strings(d.KindParsers)
+
+	for iface := range o.Decoder.Decoders {
+		d.Decoders = append(d.Decoders, fmt.Sprintf("%T", iface))
+	}
+	sort.Strings(d.Decoders)
+
+	for name := range o.Walker.Validators {
+		d.Validators = append(d.Validators, name)
+	}
+	sort.Strings(d.Validators)
+
+	for name := range o.Matcher.DefaultFuncs {
+		d.DefaultFuncs = append(d.DefaultFuncs, name)
+	}
+	sort.Strings(d.DefaultFuncs)
+
+	for iface, impls := range o.Walker.Implementations {
+		for name := range impls {
+			d.Implementations = append(d.Implementations, fmt.Sprintf("%s %s", iface, name))
+		}
+	}
+	sort.Strings(d.Implementations)
+
+	for _, l := range o.expandLookups {
+		d.ExpandLookups = append(d.ExpandLookups, l.String())
+	}
+
+	root := describeSource(o.Loader, false)
+	d.Sources = root.Sources
+
+	for _, s := range o.Loader.Overrides {
+		d.Overrides = append(d.Overrides, describeSource(s, false))
+	}
+
+	return d
+}
+
+func describeSource(s sources.Source, isDefault bool) SourceDescription {
+	if c, ok := s.(*loader.Configured); ok {
+		d := describeSource(c.Source, isDefault)
+		d.Priority = c.Priority
+		d.Prefix = c.Prefix
+		d.Optional = c.Optional
+		d.Filters = len(c.Filters)
+		d.Transforms = len(c.Transforms)
+
+		return d
+	}
+
+	d := SourceDescription{
+		Type:    fmt.Sprintf("%T", s),
+		Default: isDefault,
+	}
+
+	l, ok := s.(*loader.Loader)
+	if !ok {
+		return d
+	}
+
+	d.Filters = len(l.Filters)
+	d.Transforms = len(l.Transforms)
+
+	for _, sub := range l.Sources {
+		d.Sources = append(d.Sources, describeSource(sub, false))
+	}
+
+	if len(l.Sources) == 0 && l.DefaultSource != nil {
+		d.Sources = append(d.Sources, describeSource(l.DefaultSource, true))
+	}
+
+	return d
+}
+
+func sliceGapsName(gaps walker.SliceGaps) string {
+	switch gaps {
+	case walker.SliceGapsSkip:
+		return "skip"
+	case walker.SliceGapsError:
+		return "error"
+	default:
+		return "stop"
+	}
+}
+
+func mapModeName(mode walker.MapMode) string {
+	if mode == walker.MapReplace {
+		return "replace"
+	}
+
+	return "merge"
+}
+
+func initModeName(mode walker.InitMode) string {
+	switch mode {
+	case walker.InitAny:
+		return "any"
+	case walker.InitAlways:
+		return "always"
+	case walker.InitNever:
+		return "never"
+	default:
+		return "vars"
+	}
+}
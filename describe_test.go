@@ -0,0 +1,73 @@
+package envcfg_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sethpollack/envcfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		d := envcfg.Describe()
+
+		assert.Equal(t, "env", d.Tags["env"])
+		assert.Equal(t, "default", d.Tags["default"])
+		assert.Equal(t, "vars", d.InitMode)
+		assert.Equal(t, ",", d.Delimiter)
+		assert.Equal(t, ";", d.SubDelimiter)
+		assert.Equal(t, "merge", d.MapMode)
+		assert.Equal(t, ":", d.Separator)
+		assert.Contains(t, d.KindParsers, "int")
+		assert.Contains(t, d.TypeParsers, "time.Duration")
+		assert.Empty(t, d.Decoders)
+		assert.Equal(t, []envcfg.SourceDescription{{Type: "*osenv.source", Default: true}}, d.Sources)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		type decoder interface{ Decode(string) error }
+
+		d := envcfg.Describe(
+			envcfg.WithTagName("cfg"),
+			envcfg.WithInitNever(),
+			envcfg.WithDelimiter("|"),
+			envcfg.WithMaxDepth(8),
+			envcfg.WithRequired(),
+			envcfg.WithProfile("prod"),
+			envcfg.WithTypeParser(reflect.TypeOf(complex64(0)), func(string) (any, error) { return nil, nil }),
+			envcfg.WithDecoder((*decoder)(nil), func(any, string) error { return nil }),
+			envcfg.WithValidator("region", func(any) error { return nil }),
+			envcfg.WithMatcher(func(m envcfg.Matcher) envcfg.Matcher { return m }),
+			envcfg.WithLoader(
+				envcfg.WithMapEnvSource(map[string]string{}),
+				envcfg.WithPrefix("APP_"),
+			),
+			envcfg.WithLoader(),
+		)
+
+		assert.Equal(t, "cfg", d.Tags["env"])
+		assert.Equal(t, "never", d.InitMode)
+		assert.Equal(t, "|", d.Delimiter)
+		assert.Equal(t, 8, d.MaxDepth)
+		assert.True(t, d.Required)
+		assert.Equal(t, "prod", d.Profile)
+		assert.Contains(t, d.TypeParsers, "complex64")
+		assert.Equal(t, []string{"*envcfg_test.decoder"}, d.Decoders)
+		assert.Equal(t, []string{"region"}, d.Validators)
+		assert.Equal(t, 1, d.MatcherWrappers)
+
+		assert.Equal(t, []envcfg.SourceDescription{
+			{
+				Type:       "*loader.Loader",
+				Filters:    1,
+				Transforms: 1,
+				Sources:    []envcfg.SourceDescription{{Type: "*mapenv.source"}},
+			},
+			{
+				Type:    "*loader.Loader",
+				Sources: []envcfg.SourceDescription{{Type: "*osenv.source", Default: true}},
+			},
+		}, d.Sources)
+	})
+}
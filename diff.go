@@ -0,0 +1,59 @@
+package envcfg
+
+import (
+	"fmt"
+	"reflect"
+
+	errs "github.com/sethpollack/envcfg/errors"
+)
+
+// Change is a field whose value differs between two configurations.
+// The values of sensitive fields are masked.
+type Change struct {
+	// Field is the dotted path of the field, e.g. "Redis.Host".
+	Field string
+	// Key is the environment variable of the field.
+	Key string
+	Old any
+	New any
+}
+
+// Diff returns the fields whose values differ between old and new, two
+// configurations of the same struct type, e.g. to log what a reload changed.
+// The values of sensitive fields, or fields of sensitive structs, are masked.
+// No sources are loaded.
+func Diff(old, new any, opts ...Option) ([]Change, error) {
+	if reflect.TypeOf(old) != reflect.TypeOf(new) {
+		return nil, fmt.Errorf("%w: %T and %T", errs.ErrDiffTypes, old, new)
+	}
+
+	o := newOptions(opts...)
+
+	fields, err := o.Walker.Fields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	ov := reflect.Indirect(reflect.ValueOf(old))
+	nv := reflect.Indirect(reflect.ValueOf(new))
+
+	var changes []Change
+
+	for _, path := range fields {
+		before, after := exportValue(ov, path, false), exportValue(nv, path, false)
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+
+		spec := o.Matcher.Spec(path)
+
+		changes = append(changes, Change{
+			Field: fieldName(path),
+			Key:   spec.Key,
+			Old:   exportValue(ov, path, spec.Sensitive),
+			New:   exportValue(nv, path, spec.Sensitive),
+		})
+	}
+
+	return changes, nil
+}
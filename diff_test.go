@@ -0,0 +1,42 @@
+package envcfg_test
+
+import (
+	"testing"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	type Config struct {
+		Port     int
+		Password string `sensitive:"true"`
+		Redis    *struct {
+			Host string
+		}
+		Tags []string
+		Name string
+	}
+
+	old := Config{Port: 8080, Password: "old", Tags: []string{"a"}, Name: "app"}
+	new := Config{Port: 9090, Password: "new", Tags: []string{"a", "b"}, Name: "app"}
+	new.Redis = &struct{ Host string }{Host: "localhost"}
+
+	changes, err := envcfg.Diff(&old, &new)
+	require.NoError(t, err)
+	assert.Equal(t, []envcfg.Change{
+		{Field: "Port", Key: "PORT", Old: 8080, New: 9090},
+		{Field: "Password", Key: "PASSWORD", Old: envcfg.Mask, New: envcfg.Mask},
+		{Field: "Redis.Host", Key: "REDIS_HOST", Old: nil, New: "localhost"},
+		{Field: "Tags", Key: "TAGS", Old: []string{"a"}, New: []string{"a", "b"}},
+	}, changes)
+
+	changes, err = envcfg.Diff(&old, &old)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = envcfg.Diff(&old, new)
+	assert.ErrorIs(t, err, errs.ErrDiffTypes)
+}
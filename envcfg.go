@@ -1,18 +1,32 @@
 package envcfg
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
+	errs "github.com/sethpollack/envcfg/errors"
 	"github.com/sethpollack/envcfg/internal/decoder"
 	"github.com/sethpollack/envcfg/internal/loader"
 	"github.com/sethpollack/envcfg/internal/matcher"
 	"github.com/sethpollack/envcfg/internal/parser"
 	"github.com/sethpollack/envcfg/internal/walker"
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/sethpollack/envcfg/sources/cache"
 	"github.com/sethpollack/envcfg/sources/dotenv"
+	"github.com/sethpollack/envcfg/sources/envrc"
+	"github.com/sethpollack/envcfg/sources/fsenv"
 	"github.com/sethpollack/envcfg/sources/mapenv"
 	"github.com/sethpollack/envcfg/sources/osenv"
+	"github.com/sethpollack/envcfg/sources/systemd"
+	"gopkg.in/yaml.v3"
 )
 
 type Option func(*Options)
@@ -23,13 +37,90 @@ type Options struct {
 	Decoder *decoder.Decoder
 	Parser  *parser.Parser
 	Matcher *matcher.Matcher
+
+	configVar       string
+	strict          bool
+	expandLookups   []ExpandLookup
+	fieldProvenance *Provenance
+	sources         map[string]SourceInfo
+
+	matcherWrappers []func(Matcher) Matcher
+	parserWrappers  []func(Parser) Parser
+	walkerWrappers  []func(Walker) Walker
+}
+
+// Matcher resolves the environment variable values of struct field paths.
+// Implement it to customize how fields are matched to environment variables.
+type Matcher = walker.Matcher
+
+// RemainMatcher is implemented by Matchers that support remain fields.
+type RemainMatcher = walker.RemainMatcher
+
+// IndexMatcher is implemented by Matchers that list the indexes of slice
+// elements, required to skip or report missing indexes.
+type IndexMatcher = walker.IndexMatcher
+
+// Parser converts environment variable values to Go values.
+type Parser = walker.Parser
+
+// Walker populates a configuration value.
+type Walker interface {
+	Walk(v any) error
+}
+
+func build(ctx context.Context, opts ...Option) (*Options, error) {
+	o := newOptions(opts...)
+
+	if err := o.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// load loads the sources into the matcher.
+func (o *Options) load(ctx context.Context) error {
+	loaded, origins, err := o.Loader.LoadOrigins(ctx)
+	if err != nil {
+		return err
+	}
+
+	o.Matcher.SetEnvVars(loaded)
+
+	o.sources = make(map[string]SourceInfo, len(origins))
+	for key, origin := range origins {
+		o.sources[key] = sourceInfo(origin)
+	}
+
+	return nil
+}
+
+// selectKeys limits sources implementing sources.KeySource to the
+// variables that may populate cfg, when they can be listed up front.
+func (o *Options) selectKeys(cfg any) {
+	// strict mode needs every variable to report the unused ones.
+	if o.strict {
+		return
+	}
+
+	keys, ok := o.Walker.Keys(cfg)
+	if !ok {
+		return
+	}
+
+	if o.configVar != "" {
+		keys = append(keys, o.configVar)
+	}
+
+	o.Loader.Keys = keys
 }
 
-func build(opts ...Option) (*Options, error) {
+// newOptions applies the options without loading any sources.
+func newOptions(opts ...Option) *Options {
 	o := &Options{
 		Walker:  walker.New(),
 		Decoder: decoder.New(),
-		Loader:  &loader.Loader{},
+		Loader:  &loader.Loader{DefaultSource: osenv.New()},
 		Matcher: matcher.New(),
 		Parser:  parser.New(),
 	}
@@ -38,21 +129,94 @@ func build(opts ...Option) (*Options, error) {
 		opt(o)
 	}
 
-	if len(o.Loader.Sources) == 0 {
-		o.Loader.Sources = []loader.Source{osenv.New()}
+	o.Walker.Matcher = o.Matcher
+	o.Walker.Decoder = o.Decoder
+	o.Walker.Parser = o.Parser
+
+	if o.Loader.OnSkip == nil {
+		o.Loader.OnSkip = o.logSkipped
+	}
+
+	for _, wrap := range o.matcherWrappers {
+		o.Walker.Matcher = wrap(o.Walker.Matcher)
+	}
+
+	for _, wrap := range o.parserWrappers {
+		o.Walker.Parser = wrap(o.Walker.Parser)
+	}
+
+	return o
+}
+
+// logSkipped warns about an optional source that failed to load.
+func (o *Options) logSkipped(source sources.Source, err error) {
+	logger := o.Matcher.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.Warn("optional source failed to load", "source", fmt.Sprintf("%T", source), "error", err)
+}
+
+func (o *Options) decodeConfigVar(cfg any) error {
+	if o.configVar == "" {
+		return nil
+	}
+
+	value, ok := o.Matcher.EnvVars[o.configVar]
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		err = json.Unmarshal([]byte(value), cfg)
+	} else {
+		err = yaml.Unmarshal([]byte(value), cfg)
 	}
 
-	loaded, err := o.Loader.Load()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("%w: %s: %w", errs.ErrConfigVar, o.configVar, err)
 	}
 
-	o.Matcher.EnvVars = loaded
-	o.Walker.Matcher = o.Matcher
-	o.Walker.Decoder = o.Decoder
-	o.Walker.Parser = o.Parser
+	return nil
+}
 
-	return o, nil
+// checkUnused fails strict parsing when loaded variables were not used
+// by any field, reporting them by their name in the source.
+func (o *Options) checkUnused() error {
+	if !o.strict {
+		return nil
+	}
+
+	var unused []string
+	for _, key := range o.Matcher.Unused() {
+		if key == o.configVar {
+			continue
+		}
+
+		if info, ok := o.sources[key]; ok && info.Key != "" {
+			key = info.Key
+		}
+
+		unused = append(unused, key)
+	}
+
+	if len(unused) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errs.ErrUnusedEnvVar, strings.Join(unused, ", "))
+}
+
+func (o *Options) walker() Walker {
+	var w Walker = o.Walker
+
+	for _, wrap := range o.walkerWrappers {
+		w = wrap(w)
+	}
+
+	return w
 }
 
 // WithTagName sets a custom struct tag name to override the default "env" tag.
@@ -79,6 +243,23 @@ func WithDelimiter(delim string) Option {
 	}
 }
 
+// WithSubDelimiterTag sets the struct tag name used for the delimiter of
+// nested slice elements. The default tag name is "subdelim".
+func WithSubDelimiterTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.SubDelimTag = tag
+	}
+}
+
+// WithSubDelimiter sets the delimiter used to separate the elements of
+// nested slices in environment variable values, e.g. 1;2,3 is [[1 2] [3]].
+// The default sub-delimiter is ";".
+func WithSubDelimiter(delim string) Option {
+	return func(o *Options) {
+		o.Walker.DefaultSubDelim = delim
+	}
+}
+
 // WithSeparatorTag sets the struct tag name used for the separator.
 // The default tag name is "sep".
 func WithSeparatorTag(tag string) Option {
@@ -119,6 +300,24 @@ func WithInitTag(tag string) Option {
 	}
 }
 
+// WithMapModeTag sets the struct tag name used for the map mode,
+// "merge" or "replace". The default tag name is "mapmode".
+func WithMapModeTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.MapModeTag = tag
+	}
+}
+
+// WithMapReplace replaces maps that already have entries with the entries
+// of the environment variables. By default the entries are merged, the
+// variables override matching keys and the other entries are kept.
+// Use mapmode:"merge" to merge a single map.
+func WithMapReplace() Option {
+	return func(o *Options) {
+		o.Walker.MapMode = walker.MapReplace
+	}
+}
+
 // WithInitAny enables automatic initialization nil pointers
 // if environment variables are found or if default values are provided.
 // By default they are initialized only when a matching
@@ -140,6 +339,7 @@ func WithInitNever() Option {
 
 // WithInitAlways enables automatic initialization of nil pointers
 // regardless of whether matching environment variables are found.
+// Nil slices and maps are initialized to empty ones as well.
 // By default they are initialized only when a matching
 // environment variable is found.
 func WithInitAlways() Option {
@@ -148,6 +348,88 @@ func WithInitAlways() Option {
 	}
 }
 
+// WithSkipUnlessTag sets the struct tag name used for conditionally skipping fields.
+// The default tag name is "skipUnless".
+func WithSkipUnlessTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.SkipUnlessTag = tag
+	}
+}
+
+// WithRemainTag sets the struct tag name used for fields collecting unmatched variables.
+// The default tag name is "remain".
+func WithRemainTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.RemainTag = tag
+	}
+}
+
+// WithGroupTag sets the struct tag name used for field groups.
+// The default tag name is "group".
+func WithGroupTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.GroupTag = tag
+		o.Matcher.GroupTag = tag
+	}
+}
+
+// WithGroupRequiredTag sets the struct tag name used for required groups.
+// The default tag name is "grouprequired".
+func WithGroupRequiredTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.GroupRequiredTag = tag
+		o.Matcher.GroupRequiredTag = tag
+	}
+}
+
+// WithRequiredIfTag sets the struct tag name used for fields required
+// when a sibling field has a value.
+func WithRequiredIfTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.RequiredIfTag = tag
+		o.Matcher.RequiredIfTag = tag
+	}
+}
+
+// WithRequiredWithTag sets the struct tag name used for fields required
+// when any of the sibling fields is set.
+func WithRequiredWithTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.RequiredWithTag = tag
+		o.Matcher.RequiredWithTag = tag
+	}
+}
+
+// WithPrefixTag sets the struct tag name used for the prefix of nested fields.
+func WithPrefixTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.PrefixTag = tag
+	}
+}
+
+// WithAliasTag sets the struct tag name used for alternative variable names.
+func WithAliasTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.AliasTag = tag
+	}
+}
+
+// WithSquashTag sets the struct tag name used for struct fields whose
+// nested fields are not prefixed with their name.
+func WithSquashTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.SquashTag = tag
+	}
+}
+
+// WithValidateTag sets the struct tag name used for validation rules.
+func WithValidateTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.ValidateTag = tag
+		o.Matcher.ValidateTag = tag
+	}
+}
+
 // WithDefaultTag sets the struct tag name used for default values.
 // The default tag name is "default".
 func WithDefaultTag(tag string) Option {
@@ -156,6 +438,111 @@ func WithDefaultTag(tag string) Option {
 	}
 }
 
+// WithDescriptionTag sets the struct tag name used for field descriptions.
+// The default tag name is "desc".
+func WithDescriptionTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.DescTag = tag
+	}
+}
+
+// WithExampleTag sets the struct tag name used for example values.
+// The default tag name is "example".
+func WithExampleTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.ExampleTag = tag
+	}
+}
+
+// WithDefaultFunc registers a function computing default values at parse
+// time, used by fields with default:"@name", e.g. a hostname or generated
+// ID. Defaults starting with @ that don't name a function are used as is.
+func WithDefaultFunc(name string, fn func() (string, error)) Option {
+	return func(o *Options) {
+		o.Matcher.DefaultFuncs[name] = fn
+	}
+}
+
+// WithDefaultEnvTag sets the struct tag name used for the environment
+// variable default values are read from. The default tag name is "defaultEnv".
+func WithDefaultEnvTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.DefaultEnvTag = tag
+	}
+}
+
+// WithRenamedFromTag sets the struct tag name used for deprecated variable names.
+// The default tag name is "renamedFrom".
+func WithRenamedFromTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.RenamedFromTag = tag
+	}
+}
+
+// WithRename keeps the deprecated environment variable old working in place of new.
+// A warning is logged whenever the deprecated name is used.
+func WithRename(old, new string) Option {
+	return func(o *Options) {
+		new = strings.ToUpper(new)
+		o.Matcher.Renames[new] = append(o.Matcher.Renames[new], strings.ToUpper(old))
+	}
+}
+
+// WithLogger sets the logger used for warnings such as deprecated variable usage.
+// By default, slog.Default() is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *Options) {
+		o.Matcher.Logger = logger
+	}
+}
+
+// WithSkippedSourceHandler sets the function called with the error of an
+// optional source that failed to load. By default, a warning is logged.
+func WithSkippedSourceHandler(handler func(source sources.Source, err error)) Option {
+	return func(o *Options) {
+		o.Loader.OnSkip = handler
+	}
+}
+
+// SourceConflict is a variable defined by multiple sources with different
+// values.
+type SourceConflict struct {
+	Key string
+	// Value is the value that is used and Source where it came from.
+	Value  string
+	Source SourceInfo
+	// IgnoredValue is the value that is not used and Ignored where it
+	// came from.
+	IgnoredValue string
+	Ignored      SourceInfo
+}
+
+// WithConflictHandler sets the function called when a variable is defined by
+// multiple sources with different values, such as a dotenv file overriding a
+// real secret. Returning an error fails Parse. The values are passed as is,
+// avoid logging them for sensitive variables.
+func WithConflictHandler(handler func(SourceConflict) error) Option {
+	return func(o *Options) {
+		o.Loader.OnConflict = func(c loader.Conflict) error {
+			return handler(SourceConflict{
+				Key:          c.Key,
+				Value:        c.Value,
+				Source:       sourceInfo(c.Origin),
+				IgnoredValue: c.IgnoredValue,
+				Ignored:      sourceInfo(c.Ignored),
+			})
+		}
+	}
+}
+
+// WithSensitiveTag sets the struct tag name used for sensitive values.
+// The default tag name is "sensitive".
+func WithSensitiveTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.SensitiveTag = tag
+	}
+}
+
 // WithExpandTag sets the struct tag name used for environment variable expansion.
 // The default tag name is "expand".
 func WithExpandTag(tag string) Option {
@@ -172,6 +559,34 @@ func WithFileTag(tag string) Option {
 	}
 }
 
+// WithFileFS sets the filesystem used to read file tag values,
+// such as an embed.FS or fstest.MapFS. By default, the OS filesystem is used.
+func WithFileFS(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.Matcher.FS = fsys
+	}
+}
+
+// WithFileBaseDir restricts the OS files read for file tag values to dir,
+// so that environment variables can't point them at arbitrary files such
+// as /etc/shadow. Relative paths are relative to dir, and paths that are
+// or resolve through symlinks to outside of it fail with
+// errors.ErrFileNotAllowed. defaultFile paths, which are set in code, are
+// not restricted.
+func WithFileBaseDir(dir string) Option {
+	return func(o *Options) {
+		o.Matcher.FileBaseDir = dir
+	}
+}
+
+// WithDefaultFileTag sets the struct tag name used for default values read from a file.
+// The default tag name is "defaultFile".
+func WithDefaultFileTag(tag string) Option {
+	return func(o *Options) {
+		o.Matcher.DefaultFileTag = tag
+	}
+}
+
 // WithNotEmptyTag sets the struct tag name used for validating that values are not empty.
 // The default tag name is "notempty".
 func WithNotEmptyTag(tag string) Option {
@@ -188,6 +603,22 @@ func WithNotEmpty() Option {
 	}
 }
 
+// WithNotEmptyTrimSpace treats values consisting only of whitespace as empty
+// for notempty fields, which can also be set per field with notempty:"trim".
+func WithNotEmptyTrimSpace() Option {
+	return func(o *Options) {
+		o.Matcher.NotEmptyTrimSpace = true
+	}
+}
+
+// WithTrimSpace trims surrounding whitespace from values before parsing
+// them into non-string types, e.g. " 8080 " is parsed as 8080.
+func WithTrimSpace() Option {
+	return func(o *Options) {
+		o.Walker.TrimSpace = true
+	}
+}
+
 // WithExpand is a global setting to expand environment variables in values.
 // By default, environment variables are not expanded.
 func WithExpand() Option {
@@ -220,6 +651,264 @@ func WithDisableFallback() Option {
 	}
 }
 
+// WithDisableFieldNameFallback disables matching the field name and its snake
+// case form, while other tags, such as json and yaml, are still matched.
+func WithDisableFieldNameFallback() Option {
+	return func(o *Options) {
+		o.Matcher.DisableFieldNameFallback = true
+	}
+}
+
+// WithDisableTagFallback disables matching tags other than the env tag,
+// such as json and yaml, while the field name and its snake case form are
+// still matched.
+func WithDisableTagFallback() Option {
+	return func(o *Options) {
+		o.Matcher.DisableTagFallback = true
+	}
+}
+
+// WithProfile selects the configuration profile, e.g. "prod".
+// Profile specific default tags such as `default_prod:"..."` take precedence
+// over the default tag, and sources with profile variants such as dotenv
+// files also load their profile file, e.g. ".env.prod" for ".env".
+func WithProfile(profile string) Option {
+	return func(o *Options) {
+		o.Matcher.Profile = profile
+		o.Loader.Profile = profile
+	}
+}
+
+// WithProfileEnv selects the configuration profile from the named OS environment
+// variable, e.g. "APP_ENV". See WithProfile for details.
+func WithProfileEnv(key string) Option {
+	return func(o *Options) {
+		if profile := os.Getenv(key); profile != "" {
+			WithProfile(profile)(o)
+		}
+	}
+}
+
+// WithKeySeparator sets the separator joining the names of nested fields,
+// "_" by default. With "__", FOO__BAR_BAZ matches Foo.BarBaz and
+// FOO_BAR__BAZ matches FooBar.Baz.
+func WithKeySeparator(sep string) Option {
+	return func(o *Options) {
+		o.Matcher.KeySeparator = sep
+	}
+}
+
+// WithFallbackTags sets the tags tried in order when the env tag doesn't
+// match, "struct" and "struct_snake" being the field name and its snake case
+// form, e.g. WithFallbackTags("json", "yaml", "struct_snake"). Tags not
+// listed are not used. By default, all tags are tried in the order they are
+// declared, followed by the field name and its snake case form.
+func WithFallbackTags(tags ...string) Option {
+	return func(o *Options) {
+		o.Matcher.FallbackTags = tags
+	}
+}
+
+// WithNamingStrategy sets the function returning the names of a field from
+// the field names of its path, e.g. ["DB", "MaxConns"], in place of the
+// field name and its snake case form. The names are tried in order and
+// joined with those of the parent fields, the first is the primary name.
+func WithNamingStrategy(strategy func(fieldPath []string) []string) Option {
+	return func(o *Options) {
+		o.Matcher.NamingStrategy = strategy
+	}
+}
+
+// WithSquashEmbedded doesn't prefix the fields of embedded structs with the
+// name of the embedded type, e.g. HOST instead of BASE_HOST for an embedded
+// Base struct. Use squash:"false" to keep the prefix of a single embed.
+func WithSquashEmbedded() Option {
+	return func(o *Options) {
+		o.Matcher.SquashEmbedded = true
+	}
+}
+
+// WithSliceGapsSkip skips missing indexes of indexed slices instead of
+// stopping at the first one, e.g. SERVERS_0 and SERVERS_2 populate two
+// elements. Custom matchers must implement IndexMatcher.
+func WithSliceGapsSkip() Option {
+	return func(o *Options) {
+		o.Walker.SliceGaps = walker.SliceGapsSkip
+	}
+}
+
+// WithSliceGapsError fails parsing with errors.ErrSliceGap when an index
+// of an indexed slice is missing, e.g. SERVERS_1 for SERVERS_0 and SERVERS_2.
+// Custom matchers must implement IndexMatcher.
+func WithSliceGapsError() Option {
+	return func(o *Options) {
+		o.Walker.SliceGaps = walker.SliceGapsError
+	}
+}
+
+// WithMaxSliceIndex limits the indexes of indexed slices that are looked up,
+// higher indexes are ignored.
+func WithMaxSliceIndex(n int) Option {
+	return func(o *Options) {
+		o.Walker.MaxSliceIndex = n
+	}
+}
+
+// WithMaxDepth limits the depth of the field paths that are walked, e.g.
+// 2 for DB.Host, deeper fields fail parsing with errors.ErrMaxDepth.
+// Pointers to recursive types, e.g. the *Node fields of a Node, are only
+// followed while variables have their prefix regardless of the limit.
+func WithMaxDepth(n int) Option {
+	return func(o *Options) {
+		o.Walker.MaxDepth = n
+	}
+}
+
+// WithEmptyAsUnset treats environment variables with empty values, e.g.
+// FOO=, as if they were not set: their fields get their default values
+// and are not marked as set.
+func WithEmptyAsUnset() Option {
+	return func(o *Options) {
+		o.Matcher.EmptyAsUnset = true
+	}
+}
+
+// WithUnsetToken sets a value, e.g. "__UNSET__", that zeroes a field and
+// suppresses its default, so that operators can override a default back
+// to the zero value. Nil pointers stay nil.
+func WithUnsetToken(token string) Option {
+	return func(o *Options) {
+		o.Matcher.UnsetToken = token
+		o.Walker.UnsetToken = token
+	}
+}
+
+// WithBracketIndex matches slice indexes written in brackets, e.g.
+// SERVERS[0]_HOST or PORTS[1], in addition to SERVERS_0_HOST and PORTS_1.
+func WithBracketIndex() Option {
+	return func(o *Options) {
+		o.Matcher.BracketIndex = true
+	}
+}
+
+// WithRequireExplicitTags returns an error for any field without an explicit
+// env tag name, disallowing implicit field name matching.
+func WithRequireExplicitTags() Option {
+	return func(o *Options) {
+		o.Walker.RequireExplicitTags = true
+	}
+}
+
+// WithMaxErrors collects field errors instead of failing on the first one.
+// Only the first n errors are returned, followed by an error wrapping
+// errors.ErrTooManyErrors with the number of errors left out.
+// By default, parsing stops at the first error.
+func WithMaxErrors(n int) Option {
+	return func(o *Options) {
+		o.Walker.MaxErrors = n
+	}
+}
+
+// WithCollectErrors walks the whole configuration and returns all required,
+// parse and validation errors joined, instead of failing on the first one,
+// so that every misconfiguration can be fixed at once.
+func WithCollectErrors() Option {
+	return func(o *Options) {
+		o.Walker.CollectErrors = true
+	}
+}
+
+// WithConfigVar decodes the whole configuration from a single environment
+// variable containing JSON or YAML, e.g. APP_CONFIG='{"port": 8080}'.
+// Individual environment variables still override the decoded fields,
+// default values only apply to fields left empty by the decoded value.
+func WithConfigVar(key string) Option {
+	return func(o *Options) {
+		o.configVar = key
+		o.Walker.KeepNonZeroDefaults = true
+	}
+}
+
+// WithKeepNonZeroDefaults prevents default values from overwriting fields
+// that already have a non-zero value before parsing, environment variables
+// still override them. Fields behind pointers that are already set are
+// overridden in place, and slice variables replace existing elements
+// instead of appending to them. WithConfigVar enables it for the decoded
+// fields.
+func WithKeepNonZeroDefaults() Option {
+	return func(o *Options) {
+		o.Walker.KeepNonZeroDefaults = true
+	}
+}
+
+// WithNoOverride leaves fields that already have a non-zero value before
+// parsing untouched, so that defaults set in code come first and
+// environment variables only fill the gaps. Use keep:"true" or env:",keep"
+// to keep a single field.
+func WithNoOverride() Option {
+	return func(o *Options) {
+		o.Walker.NoOverride = true
+	}
+}
+
+// WithEncodingTag sets the struct tag name used for the encodings of
+// values, e.g. encoding:"json+base64". The default tag name is "encoding".
+func WithEncodingTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.EncodingTag = tag
+	}
+}
+
+// WithBase64Tag sets the struct tag name used for base64 encoded values.
+// The default tag name is "base64".
+func WithBase64Tag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.Base64Tag = tag
+	}
+}
+
+// WithKeepTag sets the struct tag name used for keeping the values of
+// fields that are already set. The default tag name is "keep".
+func WithKeepTag(tag string) Option {
+	return func(o *Options) {
+		o.Walker.KeepTag = tag
+	}
+}
+
+// WithStrict fails parsing with errors.ErrUnusedEnvVar when loaded
+// environment variables are not used by any field, catching typos such as
+// DB_PASSWROD. Use it with WithPrefix or WithFilter so that only the
+// variables of the application, and not e.g. PATH, are loaded.
+func WithStrict() Option {
+	return func(o *Options) {
+		o.strict = true
+	}
+}
+
+// WithMatcher wraps or replaces the Matcher used to resolve field values.
+// The function receives the current Matcher, which can be used as a fallback.
+func WithMatcher(wrap func(Matcher) Matcher) Option {
+	return func(o *Options) {
+		o.matcherWrappers = append(o.matcherWrappers, wrap)
+	}
+}
+
+// WithParser wraps or replaces the Parser used to convert values.
+// The function receives the current Parser, which can be used as a fallback.
+func WithParser(wrap func(Parser) Parser) Option {
+	return func(o *Options) {
+		o.parserWrappers = append(o.parserWrappers, wrap)
+	}
+}
+
+// WithWalker wraps or replaces the Walker used by Parse.
+// The function receives the current Walker, which can be used as a fallback.
+func WithWalker(wrap func(Walker) Walker) Option {
+	return func(o *Options) {
+		o.walkerWrappers = append(o.walkerWrappers, wrap)
+	}
+}
+
 // WithDecoder registers a custom decoder function for a specific interface.
 func WithDecoder(iface any, f func(v any, value string) error) Option {
 	return func(o *Options) {
@@ -227,6 +916,51 @@ func WithDecoder(iface any, f func(v any, value string) error) Option {
 	}
 }
 
+// WithImplementation registers a concrete type of an interface field,
+// created by newFn. iface is a nil pointer to the interface, e.g.
+// (*Storage)(nil). The value of the field selects the implementation by
+// name, e.g. STORAGE=s3, and its fields are matched under the field prefix,
+// e.g. STORAGE_BUCKET. A single implementation is also used without a name
+// when any of its fields are set. Unknown names fail parsing with
+// errors.ErrUnknownImplementation.
+func WithImplementation(iface any, name string, newFn func() any) Option {
+	return func(o *Options) {
+		t := reflect.TypeOf(iface).Elem()
+
+		if o.Walker.Implementations[t] == nil {
+			o.Walker.Implementations[t] = map[string]func() any{}
+		}
+
+		o.Walker.Implementations[t][name] = newFn
+	}
+}
+
+// WithValidator registers a custom validation rule, applied to the fields
+// naming it in their validate tag, e.g. validate:"region". fn receives the
+// parsed field value, pointers dereferenced, and returns an error when it
+// is invalid. It replaces a built-in rule of the same name.
+func WithValidator(name string, fn func(v any) error) Option {
+	return func(o *Options) {
+		o.Walker.Validators[name] = fn
+	}
+}
+
+// WithDisableValidate prevents calling the Validate() error method
+// of the parsed struct and its nested structs.
+func WithDisableValidate() Option {
+	return func(o *Options) {
+		o.Walker.DisableValidate = true
+	}
+}
+
+// WithErrorMask sets the mask replacing the values of sensitive fields in
+// parse and validation errors, "******" by default.
+func WithErrorMask(mask string) Option {
+	return func(o *Options) {
+		o.Walker.Mask = mask
+	}
+}
+
 // WithTypeParser registers a custom parser function for a specific type.
 // This allows extending the parser to support additional types beyond
 // the built-in supported types.
@@ -269,34 +1003,189 @@ func WithKindParsers(parsers map[reflect.Kind]func(value string) (any, error)) O
 	}
 }
 
+// WithOverrideSource adds a source whose keys always take precedence over
+// every other source, regardless of the order sources are registered in.
+// Keys are matched as is, loader filters and transforms are not applied.
+func WithOverrideSource(source sources.Source) Option {
+	return func(o *Options) {
+		o.Loader.Overrides = append(o.Loader.Overrides, source)
+	}
+}
+
 type LoaderOption func(*loader.Loader)
 
+// WithLoader registers a loader configured by the given options.
+// A loader without sources loads the OS environment unless
+// WithNoDefaultSource is given.
 func WithLoader(opts ...LoaderOption) Option {
 	return func(o *Options) {
-		l := &loader.Loader{}
+		l := &loader.Loader{DefaultSource: osenv.New()}
 
 		for _, opt := range opts {
 			opt(l)
 		}
 
-		if len(l.Sources) == 0 {
-			l.Sources = []loader.Source{osenv.New()}
-		}
+		l.Wrap()
 
 		o.Loader.Sources = append(o.Loader.Sources, l)
 	}
 }
 
+// WithNoDefaultSource disables the OS environment fallback used when
+// the loader has no sources, so that it loads nothing instead.
+func WithNoDefaultSource() LoaderOption {
+	return func(l *loader.Loader) {
+		l.DefaultSource = nil
+	}
+}
+
 // WithSource adds a source to the loader.
-func WithSource(source loader.Source) LoaderOption {
+func WithSource(source sources.Source) LoaderOption {
 	return func(l *loader.Loader) {
 		l.Sources = append(l.Sources, source)
 	}
 }
 
+// WithCachedSource adds a source whose values are cached for ttl, so that
+// repeated Parse calls or reloads don't load a remote source every time.
+func WithCachedSource(source sources.Source, ttl time.Duration, opts ...cache.Option) LoaderOption {
+	return WithSource(cache.New(source, ttl, opts...))
+}
+
+// WithMiddleware wraps every source of the loader, e.g. to add logging,
+// metrics or redaction, regardless of the order the options are given in.
+// The first middleware is the outermost. Wrappers should implement
+// sources.ContextSource to pass the context on, sources.Func helps with that.
+func WithMiddleware(middleware ...func(sources.Source) sources.Source) LoaderOption {
+	return func(l *loader.Loader) {
+		l.Middleware = append(l.Middleware, middleware...)
+	}
+}
+
+// SourceOption configures a single source of a loader.
+type SourceOption func(*loader.Configured)
+
+// WithSourceOptions adds a source to the loader configured by the given options.
+func WithSourceOptions(source sources.Source, opts ...SourceOption) LoaderOption {
+	return func(l *loader.Loader) {
+		c := &loader.Configured{Source: source}
+
+		for _, opt := range opts {
+			opt(c)
+		}
+
+		l.Sources = append(l.Sources, c)
+	}
+}
+
+// WithPriority sets the priority of a source. Keys of a source with a higher
+// priority take precedence over those of lower priority sources, regardless
+// of the order the sources are added in or the merge strategy. Sources
+// default to priority 0.
+func WithPriority(priority int) SourceOption {
+	return func(c *loader.Configured) {
+		c.Priority = priority
+	}
+}
+
+// WithSourcePrefix adds a source whose keys are prefixed with prefix, so
+// that sources with overlapping keys, such as two secrets that both define
+// PASSWORD, can be mapped to different fields.
+func WithSourcePrefix(source sources.Source, prefix string) LoaderOption {
+	return WithSourceOptions(source, WithKeyPrefix(prefix))
+}
+
+// WithKeyPrefix prepends prefix to every key of a source.
+func WithKeyPrefix(prefix string) SourceOption {
+	return func(c *loader.Configured) {
+		c.Prefix = prefix
+	}
+}
+
+// WithSourceFilter adds a filter to a source, only keys of the source that
+// pass any of its filters are loaded. Unlike WithFilter it doesn't affect the
+// other sources of the loader.
+func WithSourceFilter(filter func(string) bool) SourceOption {
+	return func(c *loader.Configured) {
+		c.Filters = append(c.Filters, filter)
+	}
+}
+
+// WithSourceTransform adds a transform to the keys of a source, e.g. to
+// strip a prefix from a dotenv file only. Unlike WithTransform it doesn't
+// affect the other sources of the loader.
+func WithSourceTransform(transform func(string) string) SourceOption {
+	return func(c *loader.Configured) {
+		c.Transforms = append(c.Transforms, transform)
+	}
+}
+
+// WithTimeout bounds each attempt to load a source implementing
+// sources.ContextSource, such as a remote secret store.
+func WithTimeout(timeout time.Duration) SourceOption {
+	return func(c *loader.Configured) {
+		c.Timeout = timeout
+	}
+}
+
+// WithRetry retries a source that fails to load up to retries times, waiting
+// backoff before the first retry and doubling the wait after each one.
+func WithRetry(retries int, backoff time.Duration) SourceOption {
+	return func(c *loader.Configured) {
+		c.Retries = retries
+		c.Backoff = backoff
+	}
+}
+
+// WithJitter adds a random duration of up to jitter to every retry wait,
+// so that many instances don't retry a shared source at the same time.
+func WithJitter(jitter time.Duration) SourceOption {
+	return func(c *loader.Configured) {
+		c.Jitter = jitter
+	}
+}
+
+// WithOptionalSource adds a source that is skipped when it fails to load,
+// such as a missing .env file or an unreachable remote source, instead of
+// failing Parse. The error is passed to the WithSkippedSourceHandler handler.
+func WithOptionalSource(source sources.Source) LoaderOption {
+	return WithSourceOptions(source, Optional())
+}
+
+// Optional makes a source optional, see WithOptionalSource.
+func Optional() SourceOption {
+	return func(c *loader.Configured) {
+		c.Optional = true
+	}
+}
+
+// WithMergeLastWins uses the value of the source added last when sources of
+// the same priority define a key. This is the default.
+func WithMergeLastWins() LoaderOption {
+	return func(l *loader.Loader) {
+		l.Merge = loader.LastWins
+	}
+}
+
+// WithMergeFirstWins uses the value of the source added first when sources
+// of the same priority define a key.
+func WithMergeFirstWins() LoaderOption {
+	return func(l *loader.Loader) {
+		l.Merge = loader.FirstWins
+	}
+}
+
+// WithMergeErrorOnConflict fails loading when sources of the same priority
+// define a key with different values.
+func WithMergeErrorOnConflict() LoaderOption {
+	return func(l *loader.Loader) {
+		l.Merge = loader.ErrorOnConflict
+	}
+}
+
 // WithSources adds multiple sources to the loader.
 // This is a convenience function for adding multiple sources at once.
-func WithSources(sources ...loader.Source) LoaderOption {
+func WithSources(sources ...sources.Source) LoaderOption {
 	return func(l *loader.Loader) {
 		l.Sources = append(l.Sources, sources...)
 	}
@@ -404,6 +1293,26 @@ func WithTrimPrefix(prefix string) LoaderOption {
 	}
 }
 
+// WithKeyMap renames the keys of the loader's sources, e.g.
+// {"DATABASE_URL": "DB_URL"} loads DATABASE_URL as DB_URL.
+// Keys without a mapping are kept as is.
+func WithKeyMap(keys map[string]string) LoaderOption {
+	mapping := make(map[string]string, len(keys))
+	for from, to := range keys {
+		mapping[from] = to
+	}
+
+	return func(l *loader.Loader) {
+		l.Transforms = append(l.Transforms, func(key string) string {
+			if to, ok := mapping[key]; ok {
+				return to
+			}
+
+			return key
+		})
+	}
+}
+
 // WithTrimSuffix removes the specified suffix from environment variable names
 // before matching. Unlike WithHasSuffix, it does not filter variables.
 func WithTrimSuffix(suffix string) LoaderOption {
@@ -430,23 +1339,110 @@ func WithOSEnvSource() LoaderOption {
 }
 
 // WithDotEnvSource adds environment variables from a file as a source.
-// The file should contain environment variables in KEY=VALUE format.
-func WithDotEnvSource(path string) LoaderOption {
+// The file should contain environment variables in KEY=VALUE format,
+// ${VAR} references are interpolated when dotenv.WithInterpolation is given.
+func WithDotEnvSource(path string, opts ...dotenv.Option) LoaderOption {
+	return func(l *loader.Loader) {
+		l.Sources = append(l.Sources, dotenv.New(path, opts...))
+	}
+}
+
+// WithDotEnvFilesSource adds environment variables from multiple dotenv files
+// as a source. Patterns may be paths or glob patterns, later files take
+// precedence and missing files are skipped.
+func WithDotEnvFilesSource(patterns []string, opts ...dotenv.Option) LoaderOption {
 	return func(l *loader.Loader) {
-		l.Sources = append(l.Sources, dotenv.New(path))
+		l.Sources = append(l.Sources, dotenv.NewFiles(patterns, opts...))
+	}
+}
+
+// WithDotEnvCascadeSource adds the conventional dotenv file cascade for the
+// environment as a source: .env, .env.local, .env.<env> and .env.<env>.local,
+// in order of increasing precedence. .env.local is skipped for "test".
+func WithDotEnvCascadeSource(env string, opts ...dotenv.Option) LoaderOption {
+	return WithDotEnvFilesSource(dotenv.Cascade(env), opts...)
+}
+
+// WithDotEnvFSSource adds environment variables from a file in the provided
+// filesystem as a source, such as an embed.FS or fstest.MapFS.
+func WithDotEnvFSSource(fsys fs.FS, path string, opts ...dotenv.Option) LoaderOption {
+	return func(l *loader.Loader) {
+		l.Sources = append(l.Sources, dotenv.NewFS(fsys, path, opts...))
+	}
+}
+
+// WithFSSource adds environment variables from dotenv or JSON files in the
+// provided filesystem as a source, such as compiled-in defaults in an embed.FS.
+func WithFSSource(fsys fs.FS, paths ...string) LoaderOption {
+	return func(l *loader.Loader) {
+		l.Sources = append(l.Sources, fsenv.New(fsys, paths...))
+	}
+}
+
+// WithSystemdEnvSource adds environment variables from a systemd EnvironmentFile
+// as a source, parsed with systemd's quoting, continuation and comment rules.
+// A path prefixed with "-" is optional.
+func WithSystemdEnvSource(path string) LoaderOption {
+	return func(l *loader.Loader) {
+		l.Sources = append(l.Sources, systemd.New(path))
+	}
+}
+
+// WithEnvrcSource adds environment variables from a direnv .envrc file as a source.
+// Only `export KEY=value` lines and `dotenv` directives are supported.
+func WithEnvrcSource(path string) LoaderOption {
+	return func(l *loader.Loader) {
+		l.Sources = append(l.Sources, envrc.New(path))
 	}
 }
 
 // Parse processes the provided configuration struct using environment variables
 // and the specified options. It traverses the struct fields and applies the
 // environment configuration according to the defined rules and options.
+// Pointers to slices and maps are also supported, their elements are matched
+// without a prefix, e.g. all ENDPOINT_* variables can be parsed into a
+// map[string]string using WithPrefix("ENDPOINT_").
 func Parse(cfg any, opts ...Option) error {
-	b, err := build(opts...)
-	if err != nil {
-		return err
+	return ParseContext(context.Background(), cfg, opts...)
+}
+
+// ParseContext is like Parse but passes ctx to sources implementing
+// sources.ContextSource, so that loading honors cancellation and deadlines.
+func ParseContext(ctx context.Context, cfg any, opts ...Option) error {
+	_, err := parse(ctx, cfg, opts...)
+	return err
+}
+
+// parse parses cfg and returns the options it was parsed with.
+func parse(ctx context.Context, cfg any, opts ...Option) (*Options, error) {
+	b := newOptions(opts...)
+	b.selectKeys(cfg)
+
+	if err := b.load(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := b.setExpandLookups(ctx, cfg); err != nil {
+		return nil, err
 	}
 
-	return b.Walker.Walk(cfg)
+	if err := b.decodeConfigVar(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := b.walker().Walk(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := b.checkUnused(); err != nil {
+		return nil, err
+	}
+
+	if err := b.recordProvenance(cfg); err != nil {
+		return nil, err
+	}
+
+	return b, nil
 }
 
 // MustParse is like Parse but panics if an error occurs during parsing.
@@ -472,3 +1468,40 @@ func MustParseAs[T any](opts ...Option) T {
 	}
 	return t
 }
+
+// LoadDotEnv reads the given dotenv files and sets their variables in the
+// OS environment. Existing environment variables are not overridden.
+// If no paths are provided, ".env" is loaded.
+func LoadDotEnv(paths ...string) error {
+	return loadDotEnv(false, paths...)
+}
+
+// OverloadDotEnv is like LoadDotEnv but overrides existing environment variables.
+func OverloadDotEnv(paths ...string) error {
+	return loadDotEnv(true, paths...)
+}
+
+func loadDotEnv(overload bool, paths ...string) error {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+
+	for _, path := range paths {
+		envs, err := dotenv.New(path).Load()
+		if err != nil {
+			return fmt.Errorf("%w: %w", errs.ErrLoadEnv, err)
+		}
+
+		for k, v := range envs {
+			if _, ok := os.LookupEnv(k); ok && !overload {
+				continue
+			}
+
+			if err := os.Setenv(k, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
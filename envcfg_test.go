@@ -1,14 +1,27 @@
 package envcfg_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/sethpollack/envcfg"
 	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/sethpollack/envcfg/sources/dotenv"
+	"github.com/sethpollack/envcfg/sources/mapenv"
+	"github.com/sethpollack/envcfg/sources/mock"
 	"github.com/sethpollack/envcfg/sources/osenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +50,17 @@ func TestParse(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	tempEnvrcFile, err := os.CreateTemp("", ".envrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tempEnvrcFile.Name())
+
+	_, err = tempEnvrcFile.WriteString("export FIELD=value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	tt := map[string]struct {
 		env      map[string]string
 		cfg      any
@@ -77,6 +101,24 @@ func TestParse(t *testing.T) {
 				Field: []string{"key", "value"},
 			},
 		},
+		"WithSubDelimiterTag": {
+			env:     map[string]string{"FIELD": "a|b;c"},
+			options: []envcfg.Option{envcfg.WithSubDelimiterTag("custom_subdelim")},
+			expected: struct {
+				Field [][]string `custom_subdelim:"|"`
+			}{
+				Field: [][]string{{"a", "b;c"}},
+			},
+		},
+		"WithSubDelimiter": {
+			env:     map[string]string{"FIELD": "a|b,c"},
+			options: []envcfg.Option{envcfg.WithSubDelimiter("|")},
+			expected: struct {
+				Field [][]string
+			}{
+				Field: [][]string{{"a", "b"}, {"c"}},
+			},
+		},
 		"WithSeparatorTag": {
 			env:     map[string]string{"FIELD": "key1|value1,key2|value2"},
 			options: []envcfg.Option{envcfg.WithSeparatorTag("custom_sep")},
@@ -148,6 +190,52 @@ func TestParse(t *testing.T) {
 				Field: ptr(""),
 			},
 		},
+		"WithSkipUnlessTag": {
+			options: []envcfg.Option{envcfg.WithSkipUnlessTag("custom_skip")},
+			expected: struct {
+				Enabled bool
+				Feature struct {
+					Field string `required:"true"`
+				} `custom_skip:"Enabled=true"`
+			}{},
+		},
+		"WithRemainTag": {
+			env:     map[string]string{"DB_HOST": "localhost", "DB_SSL_MODE": "disable"},
+			options: []envcfg.Option{envcfg.WithRemainTag("rest")},
+			expected: struct {
+				DB struct {
+					Host  string
+					Extra map[string]string `rest:"true"`
+				}
+			}{
+				DB: struct {
+					Host  string
+					Extra map[string]string `rest:"true"`
+				}{
+					Host:  "localhost",
+					Extra: map[string]string{"SSL_MODE": "disable"},
+				},
+			},
+		},
+		"WithGroupTag": {
+			env:     map[string]string{"KEY_FILE": "key"},
+			options: []envcfg.Option{envcfg.WithGroupTag("set")},
+			expected: struct {
+				Token   string `set:"auth" grouprequired:"one"`
+				KeyFile string `set:"auth"`
+			}{
+				KeyFile: "key",
+			},
+		},
+		"WithGroupRequiredTag": {
+			env:     map[string]string{},
+			options: []envcfg.Option{envcfg.WithGroupRequiredTag("oneof")},
+			expected: struct {
+				Token   string `group:"auth" oneof:"true"`
+				KeyFile string `group:"auth"`
+			}{},
+			expectedErr: errs.ErrGroupRequired,
+		},
 		"WithDefaultTag": {
 			options: []envcfg.Option{envcfg.WithDefaultTag("custom_default")},
 			expected: struct {
@@ -174,6 +262,30 @@ func TestParse(t *testing.T) {
 				Field: "${OTHER_VAR}",
 			},
 		},
+		"WithDefaultFileTag": {
+			options: []envcfg.Option{
+				envcfg.WithDefaultFileTag("custom_default_file"),
+				envcfg.WithFileFS(fstest.MapFS{
+					"secrets/field": &fstest.MapFile{Data: []byte("value")},
+				}),
+			},
+			expected: struct {
+				Field string `custom_default_file:"secrets/field"`
+			}{
+				Field: "value",
+			},
+		},
+		"WithFileFS": {
+			env: map[string]string{"FIELD": "secrets/field"},
+			options: []envcfg.Option{envcfg.WithFileFS(fstest.MapFS{
+				"secrets/field": &fstest.MapFile{Data: []byte("value")},
+			})},
+			expected: struct {
+				Field string `file:"true"`
+			}{
+				Field: "value",
+			},
+		},
 		"WithNotEmptyTag": {
 			env:     map[string]string{"FIELD": ""},
 			options: []envcfg.Option{envcfg.WithNotEmptyTag("custom_notempty")},
@@ -220,6 +332,122 @@ func TestParse(t *testing.T) {
 				Field string
 			}{},
 		},
+		"WithProfile": {
+			options: []envcfg.Option{
+				envcfg.WithProfile("prod"),
+				envcfg.WithLoader(envcfg.WithDotEnvFSSource(fstest.MapFS{
+					".env":      &fstest.MapFile{Data: []byte("FIELD=value")},
+					".env.prod": &fstest.MapFile{Data: []byte("FIELD=prod")},
+				}, ".env")),
+			},
+			expected: struct {
+				Field string
+				Port  int `default:"8080" default_prod:"80"`
+			}{
+				Field: "prod",
+				Port:  80,
+			},
+		},
+		"WithProfileEnv": {
+			env:     map[string]string{"APP_ENV": "prod"},
+			options: []envcfg.Option{envcfg.WithProfileEnv("APP_ENV")},
+			expected: struct {
+				Port int `default:"8080" default_prod:"80"`
+			}{
+				Port: 80,
+			},
+		},
+		"WithBracketIndex": {
+			env: map[string]string{
+				"SERVERS[0]_HOST": "a",
+				"SERVERS[1]_HOST": "b",
+				"PORTS[0]":        "80",
+				"PORTS[1]":        "443",
+			},
+			options: []envcfg.Option{envcfg.WithBracketIndex()},
+			expected: struct {
+				Servers []struct {
+					Host string
+				}
+				Ports []int
+			}{
+				Servers: []struct {
+					Host string
+				}{{Host: "a"}, {Host: "b"}},
+				Ports: []int{80, 443},
+			},
+		},
+		"WithRequireExplicitTags": {
+			env:     map[string]string{"FIELD": "value"},
+			options: []envcfg.Option{envcfg.WithRequireExplicitTags()},
+			expected: struct {
+				Field string
+			}{},
+			expectedErr: errs.ErrMissingTag,
+		},
+		"WithMaxErrors": {
+			env:     map[string]string{"FIELD1": "a", "FIELD2": "b", "FIELD3": "c"},
+			options: []envcfg.Option{envcfg.WithMaxErrors(1)},
+			expected: struct {
+				Field1 int
+				Field2 int
+				Field3 int
+			}{},
+			expectedErr: errs.ErrTooManyErrors,
+		},
+		"WithCollectErrors": {
+			env:     map[string]string{"FIELD1": "a", "FIELD3": "0"},
+			options: []envcfg.Option{envcfg.WithCollectErrors()},
+			expected: struct {
+				Field1 int
+				Field2 int `required:"true"`
+				Field3 int `validate:"min=1"`
+			}{},
+			expectedErr: errs.ErrValidation,
+		},
+		"WithErrorMask": {
+			env:     map[string]string{"TOKEN": "s3cret"},
+			options: []envcfg.Option{envcfg.WithErrorMask("[hidden]")},
+			expected: struct {
+				Token int `sensitive:"true"`
+			}{},
+			expectedErr: strconv.ErrSyntax,
+		},
+		"WithValidator": {
+			env:     map[string]string{"REGION": "us-east-1"},
+			options: []envcfg.Option{envcfg.WithValidator("region", validRegion)},
+			expected: struct {
+				Region string `validate:"region"`
+			}{
+				Region: "us-east-1",
+			},
+		},
+		"WithValidator invalid": {
+			env:     map[string]string{"REGION": "mars-1"},
+			options: []envcfg.Option{envcfg.WithValidator("region", validRegion)},
+			expected: struct {
+				Region string `validate:"region"`
+			}{},
+			expectedErr: errUnknownRegion,
+		},
+		"WithValidateTag": {
+			env:     map[string]string{"PORT": "0"},
+			options: []envcfg.Option{envcfg.WithValidateTag("check")},
+			expected: struct {
+				Port int `check:"port"`
+			}{},
+			expectedErr: errs.ErrValidation,
+		},
+		"WithDisableValidate": {
+			env:      map[string]string{"MIN": "3", "MAX": "2"},
+			options:  []envcfg.Option{envcfg.WithDisableValidate()},
+			expected: rangeConfig{Min: 3, Max: 2},
+		},
+		"Validate": {
+			env:         map[string]string{"MIN": "3", "MAX": "2"},
+			expected:    rangeConfig{},
+			expectedErr: errs.ErrValidation,
+		},
 		"WithDecoder": {
 			env: map[string]string{"FIELD": "hello"},
 			options: []envcfg.Option{envcfg.WithDecoder((*customIface)(nil), func(v any, value string) error {
@@ -289,6 +517,16 @@ func TestParse(t *testing.T) {
 				Field: "value",
 			},
 		},
+		"WithNoDefaultSource": {
+			env: map[string]string{"FIELD": "value"},
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithNoDefaultSource(),
+				envcfg.WithTrimPrefix("APP_"),
+			)},
+			expected: struct {
+				Field string
+			}{},
+		},
 		"WithLoader Error": {
 			options: []envcfg.Option{envcfg.WithLoader(
 				envcfg.WithSource(&customSource{}),
@@ -298,6 +536,18 @@ func TestParse(t *testing.T) {
 			}{},
 			expectedErr: errs.ErrLoadEnv,
 		},
+		"WithOverrideSource": {
+			env: map[string]string{"FIELD": "value"},
+			options: []envcfg.Option{
+				envcfg.WithOverrideSource(mapenv.New(map[string]string{"FIELD": "override"})),
+				envcfg.WithLoader(envcfg.WithOSEnvSource()),
+			},
+			expected: struct {
+				Field string
+			}{
+				Field: "override",
+			},
+		},
 		"WithSources": {
 			env: map[string]string{"FIELD": "value"},
 			options: []envcfg.Option{envcfg.WithLoader(
@@ -476,6 +726,166 @@ func TestParse(t *testing.T) {
 				Field: "value",
 			},
 		},
+		"WithCachedSource": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithCachedSource(mapenv.New(map[string]string{"FIELD": "value"}), time.Minute),
+			)},
+			expected: struct {
+				Field string
+			}{
+				Field: "value",
+			},
+		},
+		"WithSourceOptions priority": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithMergeFirstWins(),
+				envcfg.WithSource(mapenv.New(map[string]string{"FIELD": "low", "OTHER": "first"})),
+				envcfg.WithSourceOptions(mapenv.New(map[string]string{"FIELD": "high", "OTHER": "second"}), envcfg.WithPriority(1)),
+			)},
+			expected: struct {
+				Field string
+				Other string
+			}{
+				Field: "high",
+				Other: "second",
+			},
+		},
+		"WithMiddleware": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithMiddleware(func(next sources.Source) sources.Source {
+					return sources.Func(func(ctx context.Context) (map[string]string, error) {
+						envs, err := sources.LoadContext(ctx, next)
+						if err != nil {
+							return nil, err
+						}
+
+						envs["OTHER"] = "added"
+
+						return envs, nil
+					})
+				}),
+				envcfg.WithMapEnvSource(map[string]string{"FIELD": "value"}),
+			)},
+			expected: struct {
+				Field string
+				Other string
+			}{
+				Field: "value",
+				Other: "added",
+			},
+		},
+		"WithKeyMap": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithMapEnvSource(map[string]string{"DATABASE_URL": "postgres://", "PORT": "8080"}),
+				envcfg.WithKeyMap(map[string]string{"DATABASE_URL": "DB_URL"}),
+			)},
+			expected: struct {
+				DBURL string `env:"DB_URL"`
+				Port  int
+			}{
+				DBURL: "postgres://",
+				Port:  8080,
+			},
+		},
+		"WithSourcePrefix": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithSourcePrefix(mapenv.New(map[string]string{"PASSWORD": "db"}), "DB_"),
+				envcfg.WithSourcePrefix(mapenv.New(map[string]string{"PASSWORD": "cache"}), "CACHE_"),
+			)},
+			expected: struct {
+				DB    struct{ Password string }
+				Cache struct{ Password string }
+			}{
+				DB:    struct{ Password string }{Password: "db"},
+				Cache: struct{ Password string }{Password: "cache"},
+			},
+		},
+		"WithSourceTransform": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithSource(mapenv.New(map[string]string{"APP_NAME": "os"})),
+				envcfg.WithSourceOptions(
+					mapenv.New(map[string]string{"APP_FIELD": "dotenv", "IGNORED": "ignored"}),
+					envcfg.WithSourceFilter(func(key string) bool { return strings.HasPrefix(key, "APP_") }),
+					envcfg.WithSourceTransform(func(key string) string { return strings.TrimPrefix(key, "APP_") }),
+				),
+			)},
+			expected: struct {
+				Field   string
+				AppName string
+				Ignored string
+			}{
+				Field:   "dotenv",
+				AppName: "os",
+			},
+		},
+		"WithDotEnvFSSource options": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithDotEnvFSSource(fstest.MapFS{
+					".env": &fstest.MapFile{Data: []byte("HOST=localhost\nFIELD=${HOST}")},
+				}, ".env", dotenv.WithInterpolation()),
+			)},
+			expected: struct {
+				Field string
+			}{
+				Field: "localhost",
+			},
+		},
+		"WithDotEnvFilesSource": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithDotEnvFilesSource([]string{tempDotEnvFile.Name(), tempDotEnvFile.Name() + ".missing"}),
+			)},
+			expected: struct {
+				Field string
+			}{
+				Field: "value",
+			},
+		},
+		"WithDotEnvFSSource": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithDotEnvFSSource(fstest.MapFS{
+					".env": &fstest.MapFile{Data: []byte("FIELD=value")},
+				}, ".env"),
+			)},
+			expected: struct {
+				Field string
+			}{
+				Field: "value",
+			},
+		},
+		"WithFSSource": {
+			env: map[string]string{"PORT": "9090"},
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithFSSource(fstest.MapFS{
+					"defaults.json": &fstest.MapFile{Data: []byte(`{"host": "localhost", "port": 8080}`)},
+				}, "defaults.json"),
+				envcfg.WithOSEnvSource(),
+			)},
+			expected: struct {
+				Host string
+				Port int
+			}{
+				Host: "localhost",
+				Port: 9090,
+			},
+		},
+		"WithSystemdEnvSource": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithSystemdEnvSource("-" + filepath.Join(os.TempDir(), "envcfg-missing.env")),
+			)},
+			expected: struct {
+				Field string
+			}{},
+		},
+		"WithEnvrcSource": {
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithEnvrcSource(tempEnvrcFile.Name()),
+			)},
+			expected: struct {
+				Field string
+			}{
+				Field: "value",
+			},
+		},
 	}
 
 	for name, tc := range tt {
@@ -579,6 +989,346 @@ func TestMustParseAs(t *testing.T) {
 	})
 }
 
+func TestParseProtobuf(t *testing.T) {
+	// mirrors the shape of protoc-gen-go generated messages
+	type Database struct {
+		state         struct{}
+		sizeCache     int32
+		unknownFields []byte
+
+		HostName string `protobuf:"bytes,1,opt,name=host,json=host,proto3" json:"host,omitempty"`
+		Port     int32  `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	}
+
+	type Config struct {
+		state         struct{}
+		sizeCache     int32
+		unknownFields []byte
+
+		ServiceName string            `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+		Db          *Database         `protobuf:"bytes,2,opt,name=db,proto3" json:"db,omitempty"`
+		Labels      map[string]string `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	}
+
+	t.Setenv("SERVICE_NAME", "api")
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("LABELS_TEAM", "core")
+	t.Setenv("BYTES", "wire type")
+
+	cfg, err := envcfg.ParseAs[Config]()
+	require.NoError(t, err)
+
+	assert.Equal(t, "api", cfg.ServiceName)
+	assert.Equal(t, &Database{HostName: "localhost", Port: 5432}, cfg.Db)
+	assert.Equal(t, map[string]string{"team": "core"}, cfg.Labels)
+}
+
+func TestParseTopLevel(t *testing.T) {
+	t.Setenv("ENDPOINT_USERS", "http://users")
+	t.Setenv("ENDPOINT_ORDERS", "http://orders")
+	t.Setenv("HOST_0", "host1")
+	t.Setenv("HOST_1", "host2")
+
+	t.Run("map", func(t *testing.T) {
+		endpoints, err := envcfg.ParseAs[map[string]string](envcfg.WithLoader(envcfg.WithPrefix("ENDPOINT_")))
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"users": "http://users", "orders": "http://orders"}, endpoints)
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		var hosts []string
+		err := envcfg.Parse(&hosts, envcfg.WithLoader(envcfg.WithPrefix("HOST_")))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"host1", "host2"}, hosts)
+	})
+}
+
+func TestParseContext(t *testing.T) {
+	type Config struct {
+		Field string
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var cfg Config
+		err := envcfg.ParseContext(ctx, &cfg, envcfg.WithLoader(
+			envcfg.WithSource(mock.New(mock.Response{Values: map[string]string{"FIELD": "value"}})),
+		))
+		assert.ErrorIs(t, err, errs.ErrLoadEnv)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		var cfg Config
+		err := envcfg.ParseContext(ctx, &cfg, envcfg.WithLoader(
+			envcfg.WithSource(mock.New(mock.Response{Delay: time.Second})),
+		))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("loaded", func(t *testing.T) {
+		var cfg Config
+		err := envcfg.ParseContext(context.Background(), &cfg, envcfg.WithLoader(
+			envcfg.WithSource(mock.New(mock.Response{Values: map[string]string{"FIELD": "value"}})),
+		))
+		require.NoError(t, err)
+		assert.Equal(t, "value", cfg.Field)
+	})
+}
+
+func TestConfigVar(t *testing.T) {
+	type DB struct {
+		Host string `json:"host" yaml:"host"`
+		Port int    `json:"port" yaml:"port"`
+	}
+
+	type Config struct {
+		Host    string   `json:"host" yaml:"host"`
+		Port    int      `json:"port" yaml:"port" default:"8080"`
+		Timeout string   `json:"timeout" yaml:"timeout" default:"30s"`
+		DB      DB       `json:"db" yaml:"db"`
+		Cache   *DB      `json:"cache" yaml:"cache"`
+		Hosts   []string `json:"hosts" yaml:"hosts"`
+		Replica []DB     `json:"replica" yaml:"replica"`
+	}
+
+	tt := map[string]struct {
+		env         map[string]string
+		expected    Config
+		expectedErr error
+	}{
+		"json": {
+			env:      map[string]string{"APP_CONFIG": `{"host": "localhost", "port": 9090}`},
+			expected: Config{Host: "localhost", Port: 9090, Timeout: "30s"},
+		},
+		"yaml": {
+			env:      map[string]string{"APP_CONFIG": "host: localhost\nport: 9090"},
+			expected: Config{Host: "localhost", Port: 9090, Timeout: "30s"},
+		},
+		"env var overrides": {
+			env:      map[string]string{"APP_CONFIG": `{"host": "localhost", "port": 9090}`, "HOST": "example.com"},
+			expected: Config{Host: "example.com", Port: 9090, Timeout: "30s"},
+		},
+		"unset": {
+			env:      map[string]string{"HOST": "example.com"},
+			expected: Config{Host: "example.com", Port: 8080, Timeout: "30s"},
+		},
+		"env var overrides nested struct": {
+			env: map[string]string{
+				"APP_CONFIG": `{"db": {"host": "h", "port": 5432}}`,
+				"DB_HOST":    "override",
+			},
+			expected: Config{Port: 8080, Timeout: "30s", DB: DB{Host: "override", Port: 5432}},
+		},
+		"env var overrides pointer to struct": {
+			env: map[string]string{
+				"APP_CONFIG": `{"cache": {"host": "h", "port": 6379}}`,
+				"CACHE_HOST": "override",
+			},
+			expected: Config{Port: 8080, Timeout: "30s", Cache: &DB{Host: "override", Port: 6379}},
+		},
+		"indexed env var replaces slice": {
+			env: map[string]string{
+				"APP_CONFIG": `{"hosts": ["x", "y"]}`,
+				"HOSTS_0":    "z",
+			},
+			expected: Config{Port: 8080, Timeout: "30s", Hosts: []string{"z"}},
+		},
+		"delimited env var replaces slice": {
+			env: map[string]string{
+				"APP_CONFIG": "hosts: [x, y]",
+				"HOSTS":      "a,b",
+			},
+			expected: Config{Port: 8080, Timeout: "30s", Hosts: []string{"a", "b"}},
+		},
+		"slice kept without env vars": {
+			env: map[string]string{
+				"APP_CONFIG": `{"hosts": ["x", "y"], "replica": [{"host": "r1"}]}`,
+			},
+			expected: Config{Port: 8080, Timeout: "30s", Hosts: []string{"x", "y"}, Replica: []DB{{Host: "r1"}}},
+		},
+		"env var replaces slice of structs": {
+			env: map[string]string{
+				"APP_CONFIG":     `{"replica": [{"host": "r1"}, {"host": "r2"}]}`,
+				"REPLICA_0_HOST": "r3",
+			},
+			expected: Config{Port: 8080, Timeout: "30s", Replica: []DB{{Host: "r3"}}},
+		},
+		"invalid": {
+			env:         map[string]string{"APP_CONFIG": `{"host": `},
+			expectedErr: errs.ErrConfigVar,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := envcfg.ParseAs[Config](
+				envcfg.WithConfigVar("APP_CONFIG"),
+				envcfg.WithLoader(envcfg.WithMapEnvSource(tc.env)),
+			)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, cfg)
+			}
+		})
+	}
+
+	t.Run("decoded required field", func(t *testing.T) {
+		type Config struct {
+			Host string `json:"host" required:"true"`
+		}
+
+		parse := func(env map[string]string) error {
+			_, err := envcfg.ParseAs[Config](
+				envcfg.WithConfigVar("APP_CONFIG"),
+				envcfg.WithLoader(envcfg.WithMapEnvSource(env)),
+			)
+			return err
+		}
+
+		assert.NoError(t, parse(map[string]string{"APP_CONFIG": `{"host": "localhost"}`}))
+		assert.ErrorIs(t, parse(map[string]string{"APP_CONFIG": `{}`}), errs.ErrRequired)
+	})
+}
+
+func TestRename(t *testing.T) {
+	tt := map[string]struct {
+		env      map[string]string
+		options  []envcfg.Option
+		expected any
+		warning  string
+	}{
+		"WithRename": {
+			env:     map[string]string{"OLD_FIELD": "value"},
+			options: []envcfg.Option{envcfg.WithRename("OLD_FIELD", "FIELD")},
+			expected: struct {
+				Field string
+			}{
+				Field: "value",
+			},
+			warning: "deprecated=OLD_FIELD replacement=FIELD",
+		},
+		"WithRename prefers new name": {
+			env:     map[string]string{"OLD_FIELD": "old", "FIELD": "new"},
+			options: []envcfg.Option{envcfg.WithRename("OLD_FIELD", "FIELD")},
+			expected: struct {
+				Field string
+			}{
+				Field: "new",
+			},
+		},
+		"renamedFrom tag": {
+			env: map[string]string{"OLD_HOST": "value"},
+			expected: struct {
+				Redis struct {
+					Host string `renamedFrom:"OLD_HOST"`
+				}
+			}{
+				Redis: struct {
+					Host string `renamedFrom:"OLD_HOST"`
+				}{Host: "value"},
+			},
+			warning: "deprecated=OLD_HOST replacement=REDIS_HOST",
+		},
+		"WithRenamedFromTag": {
+			env:     map[string]string{"OLD_FIELD": "value"},
+			options: []envcfg.Option{envcfg.WithRenamedFromTag("was")},
+			expected: struct {
+				Field string `was:"OLD_FIELD"`
+			}{
+				Field: "value",
+			},
+			warning: "deprecated=OLD_FIELD replacement=FIELD",
+		},
+		"WithOptionalSource": {
+			env: map[string]string{"FIELD": "value"},
+			options: []envcfg.Option{envcfg.WithLoader(
+				envcfg.WithOptionalSource(dotenv.New(filepath.Join(t.TempDir(), ".env"))),
+			)},
+			expected: struct {
+				Field string
+			}{
+				Field: "value",
+			},
+			warning: "optional source failed to load",
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			opts := append([]envcfg.Option{
+				envcfg.WithLoader(envcfg.WithMapEnvSource(tc.env)),
+				envcfg.WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+			}, tc.options...)
+
+			cfg := reflect.New(reflect.TypeOf(tc.expected)).Interface()
+
+			require.NoError(t, envcfg.Parse(cfg, opts...))
+			assert.Equal(t, tc.expected, reflect.ValueOf(cfg).Elem().Interface())
+
+			if tc.warning != "" {
+				assert.Contains(t, buf.String(), tc.warning)
+			} else {
+				assert.Empty(t, buf.String())
+			}
+		})
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	first := filepath.Join(dir, "first.env")
+	require.NoError(t, os.WriteFile(first, []byte("EXISTING=first\nFIRST=first\nSHARED=first"), 0644))
+
+	second := filepath.Join(dir, "second.env")
+	require.NoError(t, os.WriteFile(second, []byte("SHARED=second"), 0644))
+
+	t.Run("load", func(t *testing.T) {
+		t.Setenv("EXISTING", "existing")
+		t.Setenv("FIRST", "")
+		t.Setenv("SHARED", "")
+		os.Unsetenv("FIRST")
+		os.Unsetenv("SHARED")
+
+		require.NoError(t, envcfg.LoadDotEnv(first, second))
+
+		assert.Equal(t, "existing", os.Getenv("EXISTING"))
+		assert.Equal(t, "first", os.Getenv("FIRST"))
+		assert.Equal(t, "first", os.Getenv("SHARED"))
+	})
+
+	t.Run("overload", func(t *testing.T) {
+		t.Setenv("EXISTING", "existing")
+		t.Setenv("FIRST", "")
+		t.Setenv("SHARED", "")
+
+		require.NoError(t, envcfg.OverloadDotEnv(first, second))
+
+		assert.Equal(t, "first", os.Getenv("EXISTING"))
+		assert.Equal(t, "first", os.Getenv("FIRST"))
+		assert.Equal(t, "second", os.Getenv("SHARED"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := envcfg.LoadDotEnv(filepath.Join(dir, "missing.env"))
+		assert.ErrorIs(t, err, errs.ErrLoadEnv)
+	})
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
@@ -617,3 +1367,449 @@ type customSource struct {
 func (c *customSource) Load() (map[string]string, error) {
 	return nil, errors.New("source error")
 }
+
+func TestWithSkippedSourceHandler(t *testing.T) {
+	errLoad := errors.New("unavailable")
+	src := mock.New(mock.Response{Err: errLoad})
+
+	var skipped []error
+
+	var cfg struct {
+		Field string
+	}
+
+	err := envcfg.Parse(&cfg,
+		envcfg.WithLoader(
+			envcfg.WithMapEnvSource(map[string]string{"FIELD": "value"}),
+			envcfg.WithOptionalSource(src),
+		),
+		envcfg.WithSkippedSourceHandler(func(source sources.Source, err error) {
+			assert.Equal(t, src, source)
+			skipped = append(skipped, err)
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "value", cfg.Field)
+	assert.Equal(t, []error{errLoad}, skipped)
+
+	err = envcfg.Parse(&cfg, envcfg.WithLoader(envcfg.WithSource(src)))
+	assert.ErrorIs(t, err, errLoad)
+}
+
+type keySource struct {
+	envs map[string]string
+	keys []string
+}
+
+func (s *keySource) Load() (map[string]string, error) {
+	return s.envs, nil
+}
+
+func (s *keySource) LoadKeys(_ context.Context, keys []string) (map[string]string, error) {
+	s.keys = keys
+
+	envs := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := s.envs[key]; ok {
+			envs[key] = value
+		}
+	}
+
+	return envs, nil
+}
+
+func TestParseSelectsKeys(t *testing.T) {
+	src := &keySource{envs: map[string]string{"HOST": "localhost", "PORT": "8080", "LABELS_ENV": "prod"}}
+
+	var cfg struct {
+		Host string
+		Port int
+	}
+
+	require.NoError(t, envcfg.Parse(&cfg, envcfg.WithLoader(envcfg.WithSource(src))))
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, []string{"HOST", "PORT"}, src.keys)
+
+	src.keys = nil
+
+	var mapCfg struct {
+		Labels map[string]string
+	}
+
+	require.NoError(t, envcfg.Parse(&mapCfg, envcfg.WithLoader(envcfg.WithSource(src))))
+	assert.Equal(t, map[string]string{"env": "prod"}, mapCfg.Labels)
+	assert.Nil(t, src.keys)
+}
+
+func TestWithConflictHandler(t *testing.T) {
+	var cfg struct {
+		Password string
+	}
+
+	errConflict := errors.New("conflict")
+
+	err := envcfg.Parse(&cfg,
+		envcfg.WithLoader(
+			envcfg.WithMapEnvSource(map[string]string{"PASSWORD": "secret"}),
+			envcfg.WithDotEnvFSSource(fstest.MapFS{
+				".env": &fstest.MapFile{Data: []byte("PASSWORD=local")},
+			}, ".env"),
+		),
+		envcfg.WithConflictHandler(func(c envcfg.SourceConflict) error {
+			assert.Equal(t, "PASSWORD", c.Key)
+			assert.Equal(t, "*dotenv.source", c.Source.Type)
+			assert.Equal(t, "*mapenv.source", c.Ignored.Type)
+			assert.Equal(t, "secret", c.IgnoredValue)
+
+			return errConflict
+		}),
+	)
+	assert.ErrorIs(t, err, errConflict)
+}
+
+var errUnknownRegion = errors.New("unknown region")
+
+func validRegion(v any) error {
+	switch v.(string) {
+	case "us-east-1", "eu-west-1":
+		return nil
+	}
+
+	return errUnknownRegion
+}
+
+type rangeConfig struct {
+	Min int
+	Max int
+}
+
+func (c rangeConfig) Validate() error {
+	if c.Min > c.Max {
+		return errors.New("min must not exceed max")
+	}
+
+	return nil
+}
+
+func TestErrorMask(t *testing.T) {
+	type Config struct {
+		Token int `sensitive:"true"`
+	}
+
+	t.Setenv("TOKEN", "s3cret")
+
+	err := envcfg.Parse(&Config{}, envcfg.WithErrorMask("[hidden]"))
+
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+	assert.Contains(t, err.Error(), "[hidden]")
+}
+
+func TestStrict(t *testing.T) {
+	type Config struct {
+		Host   string
+		URL    string `expand:"true"`
+		Labels map[string]string
+		Ports  []int
+		DB     struct {
+			Password string
+		}
+	}
+
+	env := map[string]string{
+		"APP_HOST":        "localhost",
+		"APP_URL":         "http://${HOST}",
+		"APP_LABELS_ENV":  "prod",
+		"APP_PORTS_0":     "80",
+		"APP_DB_PASSWORD": "secret",
+	}
+
+	parse := func(env map[string]string, opts ...envcfg.Option) error {
+		return envcfg.Parse(&Config{}, append(opts, envcfg.WithLoader(
+			envcfg.WithMapEnvSource(env),
+			envcfg.WithPrefix("APP_"),
+		))...)
+	}
+
+	t.Run("all used", func(t *testing.T) {
+		assert.NoError(t, parse(env, envcfg.WithStrict()))
+	})
+
+	t.Run("unused", func(t *testing.T) {
+		typo := maps.Clone(env)
+		typo["APP_DB_PASSWROD"] = "secret"
+		typo["APP_TIMEOUT"] = "1s"
+		typo["OTHER"] = "ignored"
+
+		err := parse(typo, envcfg.WithStrict())
+
+		require.ErrorIs(t, err, errs.ErrUnusedEnvVar)
+		assert.EqualError(t, err, "unused environment variable: APP_DB_PASSWROD, APP_TIMEOUT")
+
+		assert.NoError(t, parse(typo))
+	})
+
+	t.Run("loads every key", func(t *testing.T) {
+		src := &keySource{envs: map[string]string{"HOST": "localhost", "POTR": "8080"}}
+
+		var cfg struct {
+			Host string
+			Port int
+		}
+
+		err := envcfg.Parse(&cfg, envcfg.WithStrict(), envcfg.WithLoader(envcfg.WithSource(src)))
+
+		assert.ErrorIs(t, err, errs.ErrUnusedEnvVar)
+		assert.Nil(t, src.keys)
+	})
+}
+
+func TestNoOverride(t *testing.T) {
+	type Config struct {
+		Host string `default:"localhost"`
+		Port int    `default:"8080"`
+	}
+
+	env := map[string]string{"PORT": "9090"}
+
+	parse := func(cfg *Config, opts ...envcfg.Option) error {
+		return envcfg.Parse(cfg, append(opts, envcfg.WithLoader(envcfg.WithMapEnvSource(env)))...)
+	}
+
+	t.Run("keep non-zero defaults", func(t *testing.T) {
+		cfg := Config{Host: "example.com", Port: 80}
+		require.NoError(t, parse(&cfg, envcfg.WithKeepNonZeroDefaults()))
+
+		assert.Equal(t, Config{Host: "example.com", Port: 9090}, cfg)
+	})
+
+	t.Run("no override", func(t *testing.T) {
+		cfg := Config{Port: 80}
+		require.NoError(t, parse(&cfg, envcfg.WithNoOverride(), envcfg.WithStrict()))
+
+		assert.Equal(t, Config{Host: "localhost", Port: 80}, cfg)
+	})
+}
+
+func TestFileBaseDir(t *testing.T) {
+	base := t.TempDir()
+	outside := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(base, "token"), []byte("s3cret"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "shadow"), []byte("root"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "shadow"), filepath.Join(base, "link")))
+
+	type Config struct {
+		Token string `file:"true"`
+	}
+
+	parse := func(path string) (Config, error) {
+		var cfg Config
+		err := envcfg.Parse(&cfg,
+			envcfg.WithFileBaseDir(base),
+			envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{"TOKEN": path})),
+		)
+		return cfg, err
+	}
+
+	t.Run("relative", func(t *testing.T) {
+		cfg, err := parse("token")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.Token)
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		cfg, err := parse(filepath.Join(base, "token"))
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", cfg.Token)
+	})
+
+	t.Run("outside", func(t *testing.T) {
+		_, err := parse(filepath.Join(outside, "shadow"))
+		assert.ErrorIs(t, err, errs.ErrFileNotAllowed)
+		assert.ErrorIs(t, err, errs.ErrReadFile)
+	})
+
+	t.Run("traversal", func(t *testing.T) {
+		_, err := parse("../" + filepath.Base(outside) + "/shadow")
+		assert.ErrorIs(t, err, errs.ErrFileNotAllowed)
+	})
+
+	t.Run("symlink escape", func(t *testing.T) {
+		_, err := parse("link")
+		assert.ErrorIs(t, err, errs.ErrFileNotAllowed)
+	})
+}
+
+func TestDefaultFunc(t *testing.T) {
+	type Config struct {
+		Host   string `default:"@hostname"`
+		ID     string `default:"@id"`
+		Email  string `default:"@example.com"`
+		Region string `default:"@region"`
+	}
+
+	errRegion := errors.New("no metadata service")
+	calls := 0
+
+	opts := []envcfg.Option{
+		envcfg.WithDefaultFunc("hostname", func() (string, error) { return "web-1", nil }),
+		envcfg.WithDefaultFunc("id", func() (string, error) {
+			calls++
+			return "generated", nil
+		}),
+		envcfg.WithDefaultFunc("region", func() (string, error) { return "", errRegion }),
+	}
+
+	var cfg Config
+	require.NoError(t, envcfg.Parse(&cfg, append(opts,
+		envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{"ID": "set", "REGION": "eu"})),
+	)...))
+	assert.Equal(t, Config{Host: "web-1", ID: "set", Email: "@example.com", Region: "eu"}, cfg)
+	assert.Zero(t, calls)
+
+	err := envcfg.Parse(&Config{}, append(opts,
+		envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{})),
+	)...)
+	require.ErrorIs(t, err, errs.ErrDefaultFunc)
+	assert.ErrorIs(t, err, errRegion)
+	assert.EqualError(t, err, "default function failed: @region: no metadata service")
+	assert.Equal(t, 1, calls)
+}
+
+type storage interface{ Kind() string }
+
+type s3Storage struct{ Bucket string }
+
+func (s *s3Storage) Kind() string { return "s3" }
+
+type localStorage struct{ Path string }
+
+func (s *localStorage) Kind() string { return "local" }
+
+func TestImplementation(t *testing.T) {
+	type Config struct {
+		Storage storage `default:"local"`
+	}
+
+	parse := func(env map[string]string) (Config, error) {
+		var cfg Config
+		err := envcfg.Parse(&cfg,
+			envcfg.WithImplementation((*storage)(nil), "s3", func() any { return &s3Storage{} }),
+			envcfg.WithImplementation((*storage)(nil), "local", func() any { return &localStorage{} }),
+			envcfg.WithLoader(envcfg.WithMapEnvSource(env)),
+		)
+		return cfg, err
+	}
+
+	t.Run("selected", func(t *testing.T) {
+		cfg, err := parse(map[string]string{"STORAGE": "s3", "STORAGE_BUCKET": "bucket"})
+		require.NoError(t, err)
+
+		assert.Equal(t, &s3Storage{Bucket: "bucket"}, cfg.Storage)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		cfg, err := parse(map[string]string{"STORAGE_PATH": "/data"})
+		require.NoError(t, err)
+
+		assert.Equal(t, &localStorage{Path: "/data"}, cfg.Storage)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := parse(map[string]string{"STORAGE": "gcs"})
+		assert.ErrorIs(t, err, errs.ErrUnknownImplementation)
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		d := envcfg.Describe(envcfg.WithImplementation((*storage)(nil), "s3", func() any { return &s3Storage{} }))
+		assert.Equal(t, []string{"envcfg_test.storage s3"}, d.Implementations)
+	})
+}
+
+func TestEmptyAsUnset(t *testing.T) {
+	type Config struct {
+		Host string `default:"localhost"`
+		Port int    `default:"8080"`
+	}
+
+	env := envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{"HOST": "", "PORT": ""}))
+
+	var cfg Config
+	require.NoError(t, envcfg.Parse(&cfg, env))
+	assert.Empty(t, cfg.Host)
+
+	var prov envcfg.Provenance
+	require.NoError(t, envcfg.Parse(&cfg, env, envcfg.WithEmptyAsUnset(), envcfg.WithProvenance(&prov)))
+	assert.Equal(t, Config{Host: "localhost", Port: 8080}, cfg)
+
+	source, _ := prov.Field("Host")
+	assert.Equal(t, "default", source)
+}
+
+func TestUnsetToken(t *testing.T) {
+	type Config struct {
+		Host    string         `default:"localhost"`
+		Port    int            `default:"8080" validate:"min=1"`
+		Timeout *time.Duration `default:"30s"`
+		Tags    []string       `default:"a,b"`
+		Token   string         `file:"true" default:"/run/secrets/token"`
+		TLS     struct {
+			Enabled bool `default:"true"`
+		}
+	}
+
+	var cfg Config
+	err := envcfg.Parse(&cfg,
+		envcfg.WithUnsetToken("__UNSET__"),
+		envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{
+			"HOST":    "__UNSET__",
+			"PORT":    "__UNSET__",
+			"TIMEOUT": "__UNSET__",
+			"TAGS":    "__UNSET__",
+			"TOKEN":   "__UNSET__",
+			"TLS":     "__UNSET__",
+		})),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, Config{}, cfg)
+
+	var plain struct {
+		Host string `default:"localhost"`
+	}
+	require.NoError(t, envcfg.Parse(&plain, envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{"HOST": "__UNSET__"}))))
+	assert.Equal(t, "__UNSET__", plain.Host)
+}
+
+func TestRemain(t *testing.T) {
+	type Plugin struct {
+		Name     string
+		Settings map[string]string `env:",remain"`
+	}
+
+	type Config struct {
+		Port   int
+		Plugin Plugin
+	}
+
+	var cfg Config
+	require.NoError(t, envcfg.Parse(&cfg, envcfg.WithLoader(
+		envcfg.WithMapEnvSource(map[string]string{
+			"APP_PORT":               "8080",
+			"APP_PLUGIN_NAME":        "cache",
+			"APP_PLUGIN_TTL":         "60s",
+			"APP_PLUGIN_MAX_ENTRIES": "100",
+			"OTHER_PLUGIN_TTL":       "30s",
+		}),
+		envcfg.WithPrefix("APP_"),
+	)))
+
+	assert.Equal(t, Config{
+		Port: 8080,
+		Plugin: Plugin{
+			Name:     "cache",
+			Settings: map[string]string{"TTL": "60s", "MAX_ENTRIES": "100"},
+		},
+	}, cfg)
+}
@@ -0,0 +1,13 @@
+// Command envcfgvet checks envcfg struct tags.
+//
+//	go vet -vettool=$(which envcfgvet) ./...
+package main
+
+import (
+	"github.com/sethpollack/envcfg/envcfgvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(envcfgvet.Analyzer)
+}
@@ -0,0 +1,278 @@
+// Package envcfgvet defines an analyzer that checks envcfg struct tags.
+package envcfgvet
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sethpollack/envcfg/tag"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `check envcfg struct tags
+
+The envcfgvet analyzer reports unknown env tag options, default values
+that cannot be parsed as the field type, duplicate env names within
+a struct, and fields that are both required and have a default.
+
+Only struct types that are passed to envcfg functions, such as
+envcfg.Parse, and the struct types of their fields are checked.`
+
+const envcfgPath = "github.com/sethpollack/envcfg"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "envcfgvet",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var knownOptions = map[string]bool{
+	"required":      true,
+	"default":       true,
+	"defaultFile":   true,
+	"expand":        true,
+	"file":          true,
+	"notempty":      true,
+	"delim":         true,
+	"subdelim":      true,
+	"sep":           true,
+	"init":          true,
+	"mapmode":       true,
+	"keep":          true,
+	"encoding":      true,
+	"base64":        true,
+	"ignore":        true,
+	"decodeunset":   true,
+	"renamedFrom":   true,
+	"defaultEnv":    true,
+	"skipUnless":    true,
+	"desc":          true,
+	"example":       true,
+	"sensitive":     true,
+	"remain":        true,
+	"group":         true,
+	"grouprequired": true,
+	"required_if":   true,
+	"required_with": true,
+	"squash":        true,
+	"alias":         true,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	reached := reachedStructs(pass, inspect)
+	if len(reached) == 0 {
+		return nil, nil
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.StructType)(nil),
+	}
+
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		st := n.(*ast.StructType)
+		if s, ok := pass.TypesInfo.TypeOf(st).(*types.Struct); ok && reached[s] {
+			checkStruct(pass, st)
+		}
+	})
+
+	return nil, nil
+}
+
+// reachedStructs returns the struct types passed to envcfg functions,
+// as arguments or type arguments, and the struct types of their fields.
+func reachedStructs(pass *analysis.Pass, inspect *inspector.Inspector) map[*types.Struct]bool {
+	reached := map[*types.Struct]bool{}
+
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+
+		fn := typeutil.Callee(pass.TypesInfo, call)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != envcfgPath {
+			return
+		}
+
+		for _, arg := range call.Args {
+			addStructs(reached, pass.TypesInfo.TypeOf(arg))
+		}
+
+		if id := calleeIdent(call.Fun); id != nil {
+			if inst, ok := pass.TypesInfo.Instances[id]; ok {
+				for i := 0; i < inst.TypeArgs.Len(); i++ {
+					addStructs(reached, inst.TypeArgs.At(i))
+				}
+			}
+		}
+	})
+
+	return reached
+}
+
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch f := ast.Unparen(fun).(type) {
+	case *ast.IndexExpr:
+		return calleeIdent(f.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(f.X)
+	case *ast.SelectorExpr:
+		return f.Sel
+	case *ast.Ident:
+		return f
+	}
+
+	return nil
+}
+
+func addStructs(reached map[*types.Struct]bool, typ types.Type) {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Pointer:
+		addStructs(reached, t.Elem())
+	case *types.Slice:
+		addStructs(reached, t.Elem())
+	case *types.Array:
+		addStructs(reached, t.Elem())
+	case *types.Map:
+		addStructs(reached, t.Elem())
+	case *types.Named:
+		addStructs(reached, t.Underlying())
+	case *types.Struct:
+		if reached[t] {
+			return
+		}
+
+		reached[t] = true
+		for i := 0; i < t.NumFields(); i++ {
+			addStructs(reached, t.Field(i).Type())
+		}
+	}
+}
+
+func checkStruct(pass *analysis.Pass, st *ast.StructType) {
+	names := map[string]bool{}
+
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+
+		tagStr, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+
+		tm := tag.ParseTags(reflect.StructField{Tag: reflect.StructTag(tagStr)})
+
+		env, hasEnv := tm.Tags["env"]
+		if hasEnv {
+			for opt := range env.Options {
+				if !knownOptions[opt] && !strings.HasPrefix(opt, "default_") {
+					pass.Reportf(field.Tag.Pos(), "unknown env tag option %q", opt)
+				}
+			}
+
+			if env.Value != "" && env.Value != "-" {
+				name := strings.ToUpper(env.Value)
+				if names[name] {
+					pass.Reportf(field.Tag.Pos(), "duplicate env name %q", name)
+				}
+				names[name] = true
+			}
+		}
+
+		def, hasDefault := lookup(tm, "default")
+		if _, required := lookup(tm, "required"); required && hasDefault {
+			pass.Reportf(field.Tag.Pos(), "field is required and has a default value")
+		}
+
+		if hasDefault {
+			if msg := checkDefault(pass.TypesInfo.TypeOf(field.Type), def); msg != "" {
+				pass.Reportf(field.Tag.Pos(), "invalid default value %q: %s", def, msg)
+			}
+		}
+	}
+}
+
+// lookup returns the value of a tag or env tag option.
+func lookup(tm tag.TagMap, name string) (string, bool) {
+	if t, ok := tm.Tags[name]; ok {
+		return t.Value, true
+	}
+
+	if env, ok := tm.Tags["env"]; ok {
+		if v, ok := env.Options[name]; ok {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+func checkDefault(typ types.Type, value string) string {
+	if typ == nil {
+		return ""
+	}
+
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	if named, ok := typ.(*types.Named); ok {
+		if obj := named.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Duration" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return "not a valid duration"
+			}
+			return ""
+		}
+	}
+
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return ""
+	}
+
+	var err error
+
+	switch basic.Kind() {
+	case types.Int, types.Int64:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case types.Int8:
+		_, err = strconv.ParseInt(value, 10, 8)
+	case types.Int16:
+		_, err = strconv.ParseInt(value, 10, 16)
+	case types.Int32:
+		_, err = strconv.ParseInt(value, 10, 32)
+	case types.Uint, types.Uint64:
+		_, err = strconv.ParseUint(value, 10, 64)
+	case types.Uint8:
+		_, err = strconv.ParseUint(value, 10, 8)
+	case types.Uint16:
+		_, err = strconv.ParseUint(value, 10, 16)
+	case types.Uint32:
+		_, err = strconv.ParseUint(value, 10, 32)
+	case types.Float32:
+		_, err = strconv.ParseFloat(value, 32)
+	case types.Float64:
+		_, err = strconv.ParseFloat(value, 64)
+	case types.Bool:
+		_, err = strconv.ParseBool(value)
+	}
+
+	if err != nil {
+		return "not a valid " + basic.Name()
+	}
+
+	return ""
+}
@@ -0,0 +1,12 @@
+package envcfgvet_test
+
+import (
+	"testing"
+
+	"github.com/sethpollack/envcfg/envcfgvet"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), envcfgvet.Analyzer, "a")
+}
@@ -0,0 +1,15 @@
+module github.com/sethpollack/envcfg/envcfgvet
+
+go 1.22.0
+
+replace github.com/sethpollack/envcfg => ../
+
+require (
+	github.com/sethpollack/envcfg v0.0.0-20241201181600-b026eb186a76
+	golang.org/x/tools v0.28.0
+)
+
+require (
+	golang.org/x/mod v0.22.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+)
@@ -0,0 +1,44 @@
+package a
+
+import (
+	"time"
+
+	"github.com/sethpollack/envcfg"
+)
+
+func init() {
+	_ = envcfg.Parse(&Config{})
+	_, _ = envcfg.NewReloader[Generic]()
+}
+
+type Config struct {
+	Valid    string        `env:"VALID,required"`
+	Port     int           `env:"PORT,default=8080"`
+	Timeout  time.Duration `default:"30s"`
+	Profiled int           `env:",default=1,default_prod=2"`
+
+	Unknown   string        `env:"UNKNOWN,requried"`        // want `unknown env tag option "requried"`
+	BadInt    int           `default:"abc"`                 // want `invalid default value "abc": not a valid int`
+	BadUint8  uint8         `env:",default=256"`            // want `invalid default value "256": not a valid uint8`
+	BadBool   *bool         `default:"yes"`                 // want `invalid default value "yes": not a valid bool`
+	BadTime   time.Duration `default:"30"`                  // want `invalid default value "30": not a valid duration`
+	Both      string        `env:",required,default=value"` // want `field is required and has a default value`
+	Duplicate string        `env:"port"`                    // want `duplicate env name "PORT"`
+}
+
+type Nested struct {
+	Port int `default:"abc"` // want `invalid default value "abc": not a valid int`
+}
+
+type Generic struct {
+	Nested *Nested
+	Inline struct {
+		Port int `env:",requried"` // want `unknown env tag option "requried"`
+	}
+}
+
+// Unrelated is never passed to envcfg and is not checked.
+type Unrelated struct {
+	Port int `default:"abc"`
+	Both int `env:",required,default=1"`
+}
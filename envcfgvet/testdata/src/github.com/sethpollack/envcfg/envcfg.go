@@ -0,0 +1,10 @@
+// Package envcfg is a stub of the envcfg API used by the analyzer tests.
+package envcfg
+
+type Option func()
+
+func Parse(cfg any, opts ...Option) error { return nil }
+
+type Reloader[T any] struct{}
+
+func NewReloader[T any](opts ...Option) (*Reloader[T], error) { return nil, nil }
@@ -4,8 +4,26 @@ import "errors"
 
 var ErrInvalidDuration = errors.New("time: invalid duration")
 var ErrInvalidMapValue = errors.New("invalid map value")
+var ErrSliceGap = errors.New("missing slice index")
+var ErrInvalidMapKey = errors.New("invalid map key")
 var ErrNotAPointer = errors.New("not a pointer to a struct")
 var ErrRequired = errors.New("required field not found")
 var ErrNotEmpty = errors.New("environment variable is empty")
 var ErrReadFile = errors.New("file read error")
 var ErrLoadEnv = errors.New("error loading environment variables")
+var ErrTooManyErrors = errors.New("too many errors")
+var ErrMissingTag = errors.New("missing env tag")
+var ErrConfigVar = errors.New("invalid config variable")
+var ErrInvalidRemain = errors.New("invalid remain field")
+var ErrGroupRequired = errors.New("required group not set")
+var ErrConflict = errors.New("conflicting values")
+var ErrValidation = errors.New("validation failed")
+var ErrInvalidRule = errors.New("invalid validation rule")
+var ErrUnusedEnvVar = errors.New("unused environment variable")
+var ErrExpand = errors.New("expansion error")
+var ErrDefaultFunc = errors.New("default function failed")
+var ErrFileNotAllowed = errors.New("file not allowed")
+var ErrUnknownImplementation = errors.New("unknown implementation")
+var ErrDecodeValue = errors.New("value decoding failed")
+var ErrMaxDepth = errors.New("maximum depth exceeded")
+var ErrDiffTypes = errors.New("cannot diff different types")
@@ -0,0 +1,107 @@
+package envcfg
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/sources"
+)
+
+// ExpandLookup is where the variables referenced by expanded values are
+// looked up, see WithExpandLookup.
+type ExpandLookup struct {
+	name   string
+	source sources.Source
+}
+
+var (
+	// ExpandEnvVars looks up the loaded environment variables.
+	ExpandEnvVars = ExpandLookup{name: "env"}
+	// ExpandOSEnv looks up the OS environment, including variables that
+	// were not loaded, e.g. because of a prefix or filter.
+	ExpandOSEnv = ExpandLookup{name: "os"}
+	// ExpandDefaults looks up the default values of the fields, by the
+	// environment variable name of the field.
+	ExpandDefaults = ExpandLookup{name: "default"}
+)
+
+// ExpandSource looks up the variables of a source that is not loaded
+// into the configuration, e.g. a dotenv file with shared settings.
+func ExpandSource(src sources.Source) ExpandLookup {
+	return ExpandLookup{name: "source", source: src}
+}
+
+func (l ExpandLookup) String() string {
+	if l.source != nil {
+		return fmt.Sprintf("%s %T", l.name, l.source)
+	}
+
+	return l.name
+}
+
+// WithExpandLookup sets where the variables referenced by expanded values
+// are looked up, the first lookup that has a variable is used. By default,
+// only the loaded environment variables are looked up.
+func WithExpandLookup(lookups ...ExpandLookup) Option {
+	return func(o *Options) {
+		o.expandLookups = lookups
+	}
+}
+
+// setExpandLookups resolves the expand lookups for cfg, loading the
+// sources they look up.
+func (o *Options) setExpandLookups(ctx context.Context, cfg any) error {
+	o.Matcher.ExpandLookups = nil
+
+	for _, l := range o.expandLookups {
+		switch l.name {
+		case "env":
+			o.Matcher.ExpandLookups = append(o.Matcher.ExpandLookups, o.Matcher.LookupEnv)
+		case "os":
+			o.Matcher.ExpandLookups = append(o.Matcher.ExpandLookups, os.LookupEnv)
+		case "default":
+			defaults, err := o.defaults(cfg)
+			if err != nil {
+				return err
+			}
+
+			o.Matcher.ExpandLookups = append(o.Matcher.ExpandLookups, lookupMap(defaults))
+		case "source":
+			envs, err := sources.LoadContext(ctx, l.source)
+			if err != nil {
+				return fmt.Errorf("%w: %w", errs.ErrLoadEnv, err)
+			}
+
+			o.Matcher.ExpandLookups = append(o.Matcher.ExpandLookups, lookupMap(envs))
+		}
+	}
+
+	return nil
+}
+
+// defaults returns the default values of the fields of cfg, keyed by
+// environment variable name.
+func (o *Options) defaults(cfg any) (map[string]string, error) {
+	fields, err := o.Walker.Fields(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := map[string]string{}
+	for _, path := range fields {
+		if spec := o.Matcher.Spec(path); spec.HasDefault {
+			defaults[spec.Key] = spec.Default
+		}
+	}
+
+	return defaults, nil
+}
+
+func lookupMap(m map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := m[name]
+		return value, ok
+	}
+}
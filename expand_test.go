@@ -0,0 +1,77 @@
+package envcfg_test
+
+import (
+	"testing"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/sources/mapenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandLookup(t *testing.T) {
+	type Config struct {
+		Host string `default:"localhost"`
+		Port int    `default:"5432"`
+		URL  string `expand:"true"`
+	}
+
+	t.Setenv("REGION", "us-east-1")
+	t.Setenv("APP_URL", "postgres://${HOST}:${PORT}/${REGION}")
+
+	loader := envcfg.WithLoader(envcfg.WithOSEnvSource(), envcfg.WithPrefix("APP_"))
+
+	t.Run("env vars", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, envcfg.Parse(&cfg, loader))
+		assert.Equal(t, "postgres://:/", cfg.URL)
+	})
+
+	t.Run("defaults and os env", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, envcfg.Parse(&cfg, loader, envcfg.WithExpandLookup(
+			envcfg.ExpandEnvVars,
+			envcfg.ExpandDefaults,
+			envcfg.ExpandOSEnv,
+		)))
+		assert.Equal(t, "postgres://localhost:5432/us-east-1", cfg.URL)
+	})
+
+	t.Run("order", func(t *testing.T) {
+		t.Setenv("APP_HOST", "db")
+
+		var cfg Config
+		require.NoError(t, envcfg.Parse(&cfg, loader, envcfg.WithExpandLookup(
+			envcfg.ExpandDefaults,
+			envcfg.ExpandEnvVars,
+		)))
+		assert.Equal(t, "db", cfg.Host)
+		assert.Equal(t, "postgres://localhost:5432/", cfg.URL)
+
+		require.NoError(t, envcfg.Parse(&cfg, loader, envcfg.WithExpandLookup(
+			envcfg.ExpandEnvVars,
+			envcfg.ExpandDefaults,
+		)))
+		assert.Equal(t, "postgres://db:5432/", cfg.URL)
+	})
+
+	t.Run("source", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, envcfg.Parse(&cfg, loader, envcfg.WithExpandLookup(
+			envcfg.ExpandSource(mapenv.New(map[string]string{"HOST": "shared", "REGION": "eu"})),
+		)))
+		assert.Equal(t, "postgres://shared:/eu", cfg.URL)
+	})
+
+	t.Run("source error", func(t *testing.T) {
+		var cfg Config
+		err := envcfg.Parse(&cfg, loader, envcfg.WithExpandLookup(envcfg.ExpandSource(&customSource{})))
+		assert.ErrorIs(t, err, errs.ErrLoadEnv)
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		d := envcfg.Describe(envcfg.WithExpandLookup(envcfg.ExpandOSEnv, envcfg.ExpandSource(mapenv.New(nil))))
+		assert.Equal(t, []string{"os", "source *mapenv.source"}, d.ExpandLookups)
+	})
+}
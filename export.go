@@ -0,0 +1,224 @@
+package envcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/sethpollack/envcfg/internal/loader"
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/sethpollack/envcfg/tag"
+	"gopkg.in/yaml.v3"
+)
+
+type ExportFormat string
+
+const (
+	ExportJSON ExportFormat = "json"
+	ExportYAML ExportFormat = "yaml"
+)
+
+// Mask replaces the values of sensitive fields in exported configuration.
+const Mask = "******"
+
+// Provenance records where the fields of a parsed configuration got their
+// values from: the matched environment variable, the default value, or unset,
+// and which source supplied each loaded environment variable.
+type Provenance struct {
+	fields  map[string]string
+	matches []FieldMatch
+	sources map[string]SourceInfo
+}
+
+// FieldMatch reports how a field of a parsed configuration was matched.
+type FieldMatch struct {
+	// Field is the dotted path of the field, e.g. "Redis.Host".
+	Field string
+	// Status is "env" when the field matched an environment variable,
+	// "default" when it got its default value and "unset" otherwise.
+	Status string
+	// Key is the matched environment variable, empty unless Status is "env".
+	Key string
+	// Candidates are the environment variables the field could have matched,
+	// e.g. to debug why a variable wasn't picked up. It is nil when the
+	// value depends on other variables, such as an expanded value.
+	Candidates []string
+	// Source is the source Key was loaded from, if any.
+	Source SourceInfo
+}
+
+// SourceInfo describes the source an environment variable was loaded from.
+type SourceInfo struct {
+	// Type is the Go type of the source, e.g. "*dotenv.source".
+	Type string
+	// Key is the name of the variable in the source, before source
+	// prefixes and loader transforms were applied.
+	Key string
+	// Source is the source itself.
+	Source sources.Source
+}
+
+func sourceInfo(origin loader.Origin) SourceInfo {
+	return SourceInfo{
+		Type:   fmt.Sprintf("%T", origin.Source),
+		Key:    origin.Key,
+		Source: origin.Source,
+	}
+}
+
+// Provenance returns the source each loaded environment variable came
+// from, keyed by variable name. It is empty until the sources are loaded.
+func (o *Options) Provenance() map[string]SourceInfo {
+	return o.sources
+}
+
+// WithProvenance records into p where each field got its value from
+// when parsing. Pass it to Export to annotate the exported values.
+func WithProvenance(p *Provenance) Option {
+	return func(o *Options) {
+		o.fieldProvenance = p
+	}
+}
+
+// Field returns where the field with the dotted path, e.g. "Redis.Host",
+// got its value from.
+func (p *Provenance) Field(field string) (string, bool) {
+	source, ok := p.fields[field]
+	return source, ok
+}
+
+// Source returns the source the environment variable key was loaded from,
+// e.g. to answer where DB_PASSWORD came from.
+func (p *Provenance) Source(key string) (SourceInfo, bool) {
+	info, ok := p.sources[key]
+	return info, ok
+}
+
+// Fields returns how each field was matched, in field order, e.g. to log
+// the effective configuration surface of a service.
+func (p *Provenance) Fields() []FieldMatch {
+	return slices.Clone(p.matches)
+}
+
+func (o *Options) recordProvenance(cfg any) error {
+	if o.fieldProvenance == nil {
+		return nil
+	}
+
+	fields, err := o.Walker.Fields(cfg)
+	if err != nil {
+		return err
+	}
+
+	o.fieldProvenance.sources = o.Provenance()
+	o.fieldProvenance.fields = make(map[string]string, len(fields))
+	o.fieldProvenance.matches = make([]FieldMatch, 0, len(fields))
+	for _, path := range fields {
+		match := fieldMatch(o, path)
+		o.fieldProvenance.matches = append(o.fieldProvenance.matches, match)
+		o.fieldProvenance.fields[match.Field] = match.String()
+	}
+
+	return nil
+}
+
+// Export returns the populated configuration keyed by environment variable name,
+// with the values of sensitive fields, or fields of sensitive structs, masked.
+// No sources are loaded. When the provenance recorded by Parse is passed with
+// WithProvenance, the YAML format annotates each value with where it came from.
+func Export(cfg any, format ExportFormat, opts ...Option) ([]byte, error) {
+	o := newOptions(opts...)
+
+	fields, err := o.Walker.Fields(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(cfg))
+
+	switch format {
+	case ExportJSON:
+		values := make(map[string]any, len(fields))
+		for _, path := range fields {
+			spec := o.Matcher.Spec(path)
+			values[spec.Key] = exportValue(rv, path, spec.Sensitive)
+		}
+
+		return json.MarshalIndent(values, "", "  ")
+	case ExportYAML:
+		doc := &yaml.Node{Kind: yaml.MappingNode}
+
+		for _, path := range fields {
+			spec := o.Matcher.Spec(path)
+
+			value := &yaml.Node{}
+			if err := value.Encode(exportValue(rv, path, spec.Sensitive)); err != nil {
+				return nil, err
+			}
+			if o.fieldProvenance != nil {
+				value.LineComment, _ = o.fieldProvenance.Field(fieldName(path))
+			}
+
+			doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: spec.Key}, value)
+		}
+
+		return yaml.Marshal(doc)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func fieldMatch(o *Options, path []tag.TagMap) FieldMatch {
+	match := FieldMatch{Field: fieldName(path), Status: "unset"}
+	match.Candidates, _ = o.Matcher.Keys(path)
+
+	switch key, found := o.Matcher.Lookup(path); {
+	case found:
+		match.Status, match.Key = "env", key
+		match.Source = o.sources[key]
+	case o.Matcher.Spec(path).HasDefault:
+		match.Status = "default"
+	}
+
+	return match
+}
+
+// String returns where the field got its value from, e.g. "env PORT".
+func (m FieldMatch) String() string {
+	if m.Status == "env" {
+		return "env " + m.Key
+	}
+
+	return m.Status
+}
+
+func exportValue(rv reflect.Value, path []tag.TagMap, sensitive bool) any {
+	for _, tm := range path {
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return nil
+			}
+			rv = rv.Elem()
+		}
+
+		rv = rv.FieldByName(tm.FieldName)
+	}
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if sensitive {
+		return Mask
+	}
+
+	if s, ok := rv.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return rv.Interface()
+}
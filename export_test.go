@@ -0,0 +1,136 @@
+package envcfg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportConfig struct {
+	Port     int           `default:"8080"`
+	Timeout  time.Duration `default:"30s"`
+	Password string        `sensitive:"true"`
+	Redis    *struct {
+		Host string
+	}
+	Name string
+	DB   struct {
+		User     string
+		Password string
+	} `sensitive:"true"`
+	Token string `sensitive:"false"`
+}
+
+func TestExport(t *testing.T) {
+	env := map[string]string{"PORT": "9090", "PASSWORD": "secret", "DB_PASSWORD": "db-secret", "TOKEN": "token"}
+
+	var prov envcfg.Provenance
+	opts := []envcfg.Option{envcfg.WithProvenance(&prov)}
+
+	cfg := exportConfig{}
+	require.NoError(t, envcfg.Parse(&cfg, append(opts, envcfg.WithLoader(envcfg.WithMapEnvSource(env)))...))
+
+	t.Run("json", func(t *testing.T) {
+		out, err := envcfg.Export(&cfg, envcfg.ExportJSON, opts...)
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"PORT": 9090,
+			"TIMEOUT": "30s",
+			"PASSWORD": "******",
+			"REDIS_HOST": null,
+			"NAME": "",
+			"DB_USER": "******",
+			"DB_PASSWORD": "******",
+			"TOKEN": "token"
+		}`, string(out))
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		out, err := envcfg.Export(cfg, envcfg.ExportYAML, opts...)
+
+		require.NoError(t, err)
+		assert.Equal(t, `PORT: 9090 # env PORT
+TIMEOUT: 30s # default
+PASSWORD: '******' # env PASSWORD
+REDIS_HOST: null # unset
+NAME: "" # unset
+DB_USER: '******' # unset
+DB_PASSWORD: '******' # env DB_PASSWORD
+TOKEN: token # env TOKEN
+`, string(out))
+	})
+
+	t.Run("yaml without provenance", func(t *testing.T) {
+		out, err := envcfg.Export(&struct{ Port int }{Port: 80}, envcfg.ExportYAML)
+
+		require.NoError(t, err)
+		assert.Equal(t, "PORT: 80\n", string(out))
+	})
+
+	t.Run("provenance", func(t *testing.T) {
+		source, ok := prov.Field("DB.Password")
+		assert.True(t, ok)
+		assert.Equal(t, "env DB_PASSWORD", source)
+	})
+
+	t.Run("source provenance", func(t *testing.T) {
+		info, ok := prov.Source("DB_PASSWORD")
+		require.True(t, ok)
+		assert.Equal(t, "*mapenv.source", info.Type)
+		assert.Equal(t, "DB_PASSWORD", info.Key)
+
+		_, ok = prov.Source("REDIS_HOST")
+		assert.False(t, ok)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		fields := prov.Fields()
+		require.Len(t, fields, 8)
+
+		assert.Equal(t, "Port", fields[0].Field)
+		assert.Equal(t, "env", fields[0].Status)
+		assert.Equal(t, "PORT", fields[0].Key)
+		assert.Equal(t, "*mapenv.source", fields[0].Source.Type)
+		assert.Equal(t, "env PORT", fields[0].String())
+
+		assert.Equal(t, "Timeout", fields[1].Field)
+		assert.Equal(t, "default", fields[1].Status)
+		assert.Empty(t, fields[1].Key)
+
+		assert.Equal(t, "Redis.Host", fields[3].Field)
+		assert.Equal(t, "unset", fields[3].Status)
+		assert.Contains(t, fields[3].Candidates, "REDIS_HOST")
+	})
+
+	t.Run("WithSensitiveTag", func(t *testing.T) {
+		out, err := envcfg.Export(&struct {
+			Secret string `secret:"true"`
+		}{Secret: "value"}, envcfg.ExportJSON, envcfg.WithSensitiveTag("secret"))
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"SECRET": "******"}`, string(out))
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := envcfg.Export(&cfg, "toml", opts...)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		_, err := envcfg.Export("not a struct", envcfg.ExportJSON, opts...)
+
+		assert.ErrorIs(t, err, errs.ErrNotAPointer)
+	})
+
+	t.Run("does not load sources", func(t *testing.T) {
+		_, err := envcfg.Export(&cfg, envcfg.ExportJSON, envcfg.WithLoader(envcfg.WithSource(&customSource{})))
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,98 @@
+package envcfg_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sethpollack/envcfg"
+	"github.com/sethpollack/envcfg/tag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orgMatcher resolves fields from ORG_ prefixed variables before
+// falling back to the default matcher.
+type orgMatcher struct {
+	envcfg.Matcher
+	env map[string]string
+}
+
+func (m *orgMatcher) GetValue(path []tag.TagMap) (string, bool, bool, error) {
+	if value, ok := m.env["ORG_"+strings.ToUpper(path[len(path)-1].FieldName)]; ok {
+		return value, true, false, nil
+	}
+
+	return m.Matcher.GetValue(path)
+}
+
+type upperParser struct {
+	envcfg.Parser
+}
+
+func (p *upperParser) ParseKind(k reflect.Kind, value string) (any, bool, error) {
+	if k == reflect.String {
+		return strings.ToUpper(value), true, nil
+	}
+
+	return p.Parser.ParseKind(k, value)
+}
+
+type walkerFunc func(v any) error
+
+func (f walkerFunc) Walk(v any) error {
+	return f(v)
+}
+
+func TestExtensions(t *testing.T) {
+	type Config struct {
+		Name string
+		Port int
+	}
+
+	t.Run("WithMatcher", func(t *testing.T) {
+		cfg, err := envcfg.ParseAs[Config](
+			envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{"PORT": "8080"})),
+			envcfg.WithMatcher(func(m envcfg.Matcher) envcfg.Matcher {
+				return &orgMatcher{Matcher: m, env: map[string]string{"ORG_NAME": "name"}}
+			}),
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "name", Port: 8080}, cfg)
+	})
+
+	t.Run("WithParser", func(t *testing.T) {
+		cfg, err := envcfg.ParseAs[Config](
+			envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{"NAME": "name", "PORT": "8080"})),
+			envcfg.WithParser(func(p envcfg.Parser) envcfg.Parser {
+				return &upperParser{Parser: p}
+			}),
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, Config{Name: "NAME", Port: 8080}, cfg)
+	})
+
+	t.Run("WithWalker", func(t *testing.T) {
+		walkErr := errors.New("walk error")
+
+		var walked bool
+		_, err := envcfg.ParseAs[Config](
+			envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{"NAME": "name"})),
+			envcfg.WithWalker(func(w envcfg.Walker) envcfg.Walker {
+				return walkerFunc(func(v any) error {
+					walked = true
+					if err := w.Walk(v); err != nil {
+						return err
+					}
+					return walkErr
+				})
+			}),
+		)
+
+		assert.True(t, walked)
+		assert.ErrorIs(t, err, walkErr)
+	})
+}
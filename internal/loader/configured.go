@@ -0,0 +1,136 @@
+package loader
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Configured is a source with per-source settings.
+type Configured struct {
+	Source Source
+	// Priority orders the source when merging, sources with a higher
+	// priority take precedence regardless of the merge strategy.
+	Priority int
+	// Prefix is prepended to every key of the source, so that sources
+	// with overlapping keys can be mapped to different fields.
+	Prefix string
+	// Filters and Transforms apply to the keys of the source only, before
+	// the Prefix is added and the filters and transforms of the loader run.
+	Filters    []func(string) bool
+	Transforms []func(string) string
+	// Timeout bounds each attempt to load the source. It applies to sources
+	// implementing sources.ContextSource.
+	Timeout time.Duration
+	// Retries is the number of times a failed load is retried, waiting
+	// Backoff before the first retry and doubling it after each one.
+	// A random duration of up to Jitter is added to every wait.
+	Retries int
+	Backoff time.Duration
+	Jitter  time.Duration
+	// Optional sources that fail to load are skipped instead of failing the
+	// load, the error is passed to the OnSkip hook of the loader.
+	Optional bool
+}
+
+func (c *Configured) Load() (map[string]string, error) {
+	return c.LoadContext(context.Background())
+}
+
+func (c *Configured) LoadContext(ctx context.Context) (map[string]string, error) {
+	loaded, err := load(ctx, c, state{})
+	if err != nil {
+		return nil, err
+	}
+
+	return values(loaded), nil
+}
+
+func (c *Configured) load(ctx context.Context, st state) (map[string]entry, error) {
+	st.keys = c.keys(st.keys)
+
+	loaded, err := c.loadRetry(ctx, st)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Prefix == "" && len(c.Filters) == 0 && len(c.Transforms) == 0 {
+		return loaded, nil
+	}
+
+	envs := make(map[string]entry, len(loaded))
+	for k, e := range loaded {
+		if matches(c.Filters, k) {
+			envs[c.Prefix+transform(c.Transforms, k)] = e
+		}
+	}
+
+	return envs, nil
+}
+
+// keys returns the keys to select from the source, without the Prefix.
+func (c *Configured) keys(keys []string) []string {
+	if keys == nil || len(c.Transforms) > 0 {
+		return nil
+	}
+
+	if c.Prefix == "" {
+		return keys
+	}
+
+	selected := []string{}
+	for _, key := range keys {
+		if rest, ok := strings.CutPrefix(key, c.Prefix); ok {
+			selected = append(selected, rest)
+		}
+	}
+
+	return selected
+}
+
+func (c *Configured) loadRetry(ctx context.Context, st state) (map[string]entry, error) {
+	backoff := c.Backoff
+
+	for attempt := 0; ; attempt++ {
+		loaded, err := c.loadOnce(ctx, st)
+		if err == nil || attempt >= c.Retries || ctx.Err() != nil {
+			return loaded, err
+		}
+
+		wait := backoff
+		if c.Jitter > 0 {
+			wait += rand.N(c.Jitter)
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+func (c *Configured) loadOnce(ctx context.Context, st state) (map[string]entry, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	return load(ctx, c.Source, st)
+}
+
+func priority(s Source) int {
+	if c, ok := s.(*Configured); ok {
+		return c.Priority
+	}
+
+	return 0
+}
@@ -1,47 +1,383 @@
 package loader
 
 import (
+	"context"
 	"fmt"
+	"sort"
 
 	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/sources"
 )
 
-type Source interface {
-	Load() (map[string]string, error)
-}
+type Source = sources.Source
+
+type ProfileSource = sources.ProfileSource
+
+// MergeStrategy decides which value is used when sources
+// of the same priority define the same key.
+type MergeStrategy int
+
+const (
+	// LastWins uses the value of the source added last.
+	LastWins MergeStrategy = iota
+	// FirstWins uses the value of the source added first.
+	FirstWins
+	// ErrorOnConflict returns an error when the values differ.
+	ErrorOnConflict
+)
 
 type Loader struct {
 	Sources    []Source
 	Filters    []func(string) bool
 	Transforms []func(string) string
+	// Overrides are loaded after all other sources and always take precedence.
+	// Their keys are used as is, filters and transforms are not applied.
+	Overrides []Source
+	// DefaultSource is loaded in place of Sources when none are set.
+	DefaultSource Source
+	// Profile selects profile specific variants of ProfileSource sources,
+	// it is inherited by nested loaders.
+	Profile string
+	// Merge is the strategy for keys defined by sources of the same priority,
+	// a source with a higher priority always takes precedence.
+	Merge MergeStrategy
+	// OnSkip is called with the error of an optional source that failed to
+	// load, it is inherited by nested loaders.
+	OnSkip func(Source, error)
+	// Keys, when not nil, are the only keys that are used. Sources
+	// implementing sources.KeySource load just these keys, except below
+	// transforms, as the keys of the source can't be derived from them.
+	// Keys are inherited by nested loaders.
+	Keys []string
+	// OnConflict is called when a key is defined by multiple sources with
+	// different values, an error fails the load. It is inherited by nested
+	// loaders.
+	OnConflict func(Conflict) error
+	// Middleware wraps the sources of the loader when Wrap is called.
+	Middleware []func(Source) Source
+}
+
+// Wrap wraps every source of the loader, including those of configured
+// sources and nested loaders, with the Middleware, the first being the
+// outermost. Sources are copied rather than modified.
+func (l *Loader) Wrap() {
+	if len(l.Middleware) == 0 {
+		return
+	}
+
+	for i, s := range l.Sources {
+		l.Sources[i] = l.wrap(s)
+	}
+
+	for i, s := range l.Overrides {
+		l.Overrides[i] = l.wrap(s)
+	}
+
+	if l.DefaultSource != nil {
+		l.DefaultSource = l.wrap(l.DefaultSource)
+	}
+}
+
+func (l *Loader) wrap(s Source) Source {
+	switch s := s.(type) {
+	case *Configured:
+		c := *s
+		c.Source = l.wrap(s.Source)
+
+		return &c
+	case *Loader:
+		sub := *s
+		sub.Sources = append([]Source{}, s.Sources...)
+		sub.Overrides = append([]Source{}, s.Overrides...)
+		sub.Middleware = l.Middleware
+		sub.Wrap()
+		sub.Middleware = s.Middleware
+
+		return &sub
+	}
+
+	for i := len(l.Middleware) - 1; i >= 0; i-- {
+		s = l.Middleware[i](s)
+	}
+
+	return s
+}
+
+// Conflict is a key defined by multiple sources with different values.
+type Conflict struct {
+	Key string
+	// Value is the value that is used and Origin where it came from.
+	Value  string
+	Origin Origin
+	// IgnoredValue is the value that is not used and Ignored where it
+	// came from.
+	IgnoredValue string
+	Ignored      Origin
+}
+
+// state is inherited by nested loaders.
+type state struct {
+	profile    string
+	onSkip     func(Source, error)
+	onConflict func(Conflict) error
+	keys       []string
 }
 
 func (l *Loader) Load() (map[string]string, error) {
-	envs := make(map[string]string)
+	return l.LoadContext(context.Background())
+}
+
+// LoadContext loads all sources, passing ctx to those implementing
+// sources.ContextSource.
+func (l *Loader) LoadContext(ctx context.Context) (map[string]string, error) {
+	envs, _, err := l.LoadOrigins(ctx)
+	return envs, err
+}
+
+// Origin is where a loaded key came from.
+type Origin struct {
+	// Source is the source that supplied the key.
+	Source Source
+	// Key is the key as named by the source, before prefixes and
+	// transforms were applied.
+	Key string
+}
+
+// LoadOrigins is like LoadContext, but also returns the origin of each key.
+func (l *Loader) LoadOrigins(ctx context.Context) (map[string]string, map[string]Origin, error) {
+	loaded, err := l.loadContext(ctx, state{
+		profile:    l.Profile,
+		onSkip:     l.OnSkip,
+		onConflict: l.OnConflict,
+		keys:       l.Keys,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	for _, s := range l.Sources {
-		loaded, err := s.Load()
+	origins := make(map[string]Origin, len(loaded))
+	for k, e := range loaded {
+		origins[k] = e.origin
+	}
+
+	return values(loaded), origins, nil
+}
+
+// entry is a loaded value and where it came from.
+type entry struct {
+	value  string
+	origin Origin
+}
+
+// loadContext loads all sources with the given state, which nested
+// loaders inherit unless they set their own.
+func (l *Loader) loadContext(ctx context.Context, st state) (map[string]entry, error) {
+	envs := make(map[string]entry)
+
+	srcs := l.Sources
+	if len(srcs) == 0 && l.DefaultSource != nil {
+		srcs = []Source{l.DefaultSource}
+	}
+
+	results := make([]result, 0, len(srcs))
+
+	child := st
+	if len(l.Transforms) > 0 {
+		child.keys = nil
+	}
+
+	for _, s := range srcs {
+		loaded, err := load(ctx, s, child)
+		if err != nil {
+			if c, ok := s.(*Configured); ok && c.Optional && ctx.Err() == nil {
+				if st.onSkip != nil {
+					st.onSkip(c.Source, err)
+				}
+
+				continue
+			}
+
+			return nil, fmt.Errorf("%w: %w", errs.ErrLoadEnv, err)
+		}
+
+		results = append(results, result{envs: loaded, priority: priority(s)})
+	}
+
+	// sources are merged in order of increasing priority.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].priority < results[j].priority
+	})
+
+	priorities := make(map[string]int)
+
+	for _, r := range results {
+		for k, e := range r.envs {
+			if !l.matches(k) {
+				continue
+			}
+
+			if err := l.merge(st, envs, priorities, l.transform(k), e, r.priority); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, s := range l.Overrides {
+		loaded, err := loadContext(ctx, s, st.keys)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %w", errs.ErrLoadEnv, err)
 		}
 
-		for k, v := range loaded {
-			if l.matches(k) {
-				k = l.transform(k)
-				envs[k] = v
+		for k, e := range entries(s, loaded) {
+			if current, ok := envs[k]; ok {
+				if err := conflict(st, k, e, current); err != nil {
+					return nil, err
+				}
 			}
+
+			envs[k] = e
 		}
 	}
 
 	return envs, nil
 }
 
+// result is the values loaded from a source.
+type result struct {
+	envs     map[string]entry
+	priority int
+}
+
+func (l *Loader) merge(st state, envs map[string]entry, priorities map[string]int, key string, value entry, priority int) error {
+	if current, ok := envs[key]; ok {
+		if priorities[key] == priority {
+			switch l.Merge {
+			case FirstWins:
+				return conflict(st, key, current, value)
+			case ErrorOnConflict:
+				if current.value != value.value {
+					return fmt.Errorf("%w: %s", errs.ErrConflict, key)
+				}
+			}
+		}
+
+		if err := conflict(st, key, value, current); err != nil {
+			return err
+		}
+	}
+
+	envs[key] = value
+	priorities[key] = priority
+
+	return nil
+}
+
+// conflict reports used and ignored values of key that differ.
+func conflict(st state, key string, used, ignored entry) error {
+	if st.onConflict == nil || used.value == ignored.value {
+		return nil
+	}
+
+	return st.onConflict(Conflict{
+		Key:          key,
+		Value:        used.value,
+		Origin:       used.origin,
+		IgnoredValue: ignored.value,
+		Ignored:      ignored.origin,
+	})
+}
+
+func load(ctx context.Context, s Source, st state) (map[string]entry, error) {
+	if c, ok := s.(*Configured); ok {
+		return c.load(ctx, st)
+	}
+
+	if sub, ok := s.(*Loader); ok {
+		if sub.Profile != "" {
+			st.profile = sub.Profile
+		}
+
+		if sub.OnSkip != nil {
+			st.onSkip = sub.OnSkip
+		}
+
+		if sub.OnConflict != nil {
+			st.onConflict = sub.OnConflict
+		}
+
+		if sub.Keys != nil {
+			st.keys = sub.Keys
+		}
+
+		return sub.loadContext(ctx, st)
+	}
+
+	loaded, err := loadContext(ctx, s, st.keys)
+	if err != nil {
+		return nil, err
+	}
+
+	ps, ok := s.(ProfileSource)
+	if !ok || st.profile == "" {
+		return entries(s, loaded), nil
+	}
+
+	profiled, err := ps.LoadProfile(st.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]string, len(loaded)+len(profiled))
+	for k, v := range loaded {
+		merged[k] = v
+	}
+
+	for k, v := range profiled {
+		merged[k] = v
+	}
+
+	return entries(s, merged), nil
+}
+
+// entries records s as the origin of the loaded values.
+func entries(s Source, loaded map[string]string) map[string]entry {
+	m := make(map[string]entry, len(loaded))
+	for k, v := range loaded {
+		m[k] = entry{value: v, origin: Origin{Source: s, Key: k}}
+	}
+
+	return m
+}
+
+// loadContext loads s, selecting keys when they are not nil
+// and s implements sources.KeySource.
+func loadContext(ctx context.Context, s Source, keys []string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if ks, ok := s.(sources.KeySource); ok && keys != nil {
+		return ks.LoadKeys(ctx, keys)
+	}
+
+	return sources.LoadContext(ctx, s)
+}
+
 func (l *Loader) matches(key string) bool {
-	if len(l.Filters) == 0 {
+	return matches(l.Filters, key)
+}
+
+func (l *Loader) transform(key string) string {
+	return transform(l.Transforms, key)
+}
+
+// matches reports whether key passes any of the filters.
+func matches(filters []func(string) bool, key string) bool {
+	if len(filters) == 0 {
 		return true
 	}
 
-	for _, f := range l.Filters {
+	for _, f := range filters {
 		if f(key) {
 			return true
 		}
@@ -50,10 +386,44 @@ func (l *Loader) matches(key string) bool {
 	return false
 }
 
-func (l *Loader) transform(key string) string {
-	for _, t := range l.Transforms {
+func transform(transforms []func(string) string, key string) string {
+	for _, t := range transforms {
 		key = t(key)
 	}
 
 	return key
 }
+
+func values(entries map[string]entry) map[string]string {
+	m := make(map[string]string, len(entries))
+	for k, e := range entries {
+		m[k] = e.value
+	}
+
+	return m
+}
+
+// Files returns the paths of the files read by s and the sources of it,
+// for sources implementing sources.FileSource.
+func Files(s Source) []string {
+	switch s := s.(type) {
+	case *Configured:
+		return Files(s.Source)
+	case *Loader:
+		srcs := s.Sources
+		if len(srcs) == 0 && s.DefaultSource != nil {
+			srcs = []Source{s.DefaultSource}
+		}
+
+		var files []string
+		for _, src := range append(append([]Source{}, srcs...), s.Overrides...) {
+			files = append(files, Files(src)...)
+		}
+
+		return files
+	case sources.FileSource:
+		return s.Files()
+	}
+
+	return nil
+}
@@ -1,10 +1,15 @@
 package loader
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/sethpollack/envcfg/sources/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,6 +23,15 @@ func (s *testSource) Load() (map[string]string, error) {
 	return s.envs, s.err
 }
 
+type testProfileSource struct {
+	testSource
+	profiles map[string]map[string]string
+}
+
+func (s *testProfileSource) LoadProfile(profile string) (map[string]string, error) {
+	return s.profiles[profile], s.err
+}
+
 func TestLoad(t *testing.T) {
 	tt := []struct {
 		name        string
@@ -53,6 +67,70 @@ func TestLoad(t *testing.T) {
 			},
 			expected: map[string]string{"TRANSFORMED_TEST_KEY": "value"},
 		},
+		{
+			name: "with overrides",
+			loader: Loader{
+				Overrides: []Source{&testSource{envs: map[string]string{"TEST_KEY": "override"}}},
+				Sources: []Source{&testSource{envs: map[string]string{
+					"TEST_KEY":  "value",
+					"OTHER_KEY": "other_value",
+				}}},
+				Filters: []func(string) bool{func(key string) bool { return key == "OTHER_KEY" }},
+			},
+			expected: map[string]string{"TEST_KEY": "override", "OTHER_KEY": "other_value"},
+		},
+		{
+			name: "with override error",
+			loader: Loader{
+				Overrides: []Source{&testSource{err: errors.New("test error")}},
+			},
+			expectedErr: errs.ErrLoadEnv,
+		},
+		{
+			name: "with profile",
+			loader: Loader{
+				Profile: "prod",
+				Sources: []Source{&Loader{Sources: []Source{&testProfileSource{
+					testSource: testSource{envs: map[string]string{"TEST_KEY": "value", "OTHER_KEY": "other_value"}},
+					profiles: map[string]map[string]string{
+						"prod": {"TEST_KEY": "prod_value"},
+					},
+				}}}},
+			},
+			expected: map[string]string{"TEST_KEY": "prod_value", "OTHER_KEY": "other_value"},
+		},
+		{
+			name: "without profile",
+			loader: Loader{
+				Sources: []Source{&testProfileSource{
+					testSource: testSource{envs: map[string]string{"TEST_KEY": "value"}},
+					profiles: map[string]map[string]string{
+						"prod": {"TEST_KEY": "prod_value"},
+					},
+				}},
+			},
+			expected: map[string]string{"TEST_KEY": "value"},
+		},
+		{
+			name: "with default source",
+			loader: Loader{
+				DefaultSource: &testSource{envs: map[string]string{"TEST_KEY": "default"}},
+			},
+			expected: map[string]string{"TEST_KEY": "default"},
+		},
+		{
+			name: "with sources ignores default source",
+			loader: Loader{
+				Sources:       []Source{&testSource{envs: map[string]string{"TEST_KEY": "value"}}},
+				DefaultSource: &testSource{envs: map[string]string{"TEST_KEY": "default"}},
+			},
+			expected: map[string]string{"TEST_KEY": "value"},
+		},
+		{
+			name:     "without sources",
+			loader:   Loader{},
+			expected: map[string]string{},
+		},
 		{
 			name: "with error",
 			loader: Loader{
@@ -60,6 +138,87 @@ func TestLoad(t *testing.T) {
 			},
 			expectedErr: errs.ErrLoadEnv,
 		},
+		{
+			name: "last wins",
+			loader: Loader{
+				Sources: []Source{
+					&testSource{envs: map[string]string{"TEST_KEY": "first"}},
+					&testSource{envs: map[string]string{"TEST_KEY": "second"}},
+				},
+			},
+			expected: map[string]string{"TEST_KEY": "second"},
+		},
+		{
+			name: "first wins",
+			loader: Loader{
+				Merge: FirstWins,
+				Sources: []Source{
+					&testSource{envs: map[string]string{"TEST_KEY": "first"}},
+					&testSource{envs: map[string]string{"TEST_KEY": "second", "OTHER_KEY": "other"}},
+				},
+			},
+			expected: map[string]string{"TEST_KEY": "first", "OTHER_KEY": "other"},
+		},
+		{
+			name: "error on conflict",
+			loader: Loader{
+				Merge: ErrorOnConflict,
+				Sources: []Source{
+					&testSource{envs: map[string]string{"TEST_KEY": "first"}},
+					&testSource{envs: map[string]string{"TEST_KEY": "second"}},
+				},
+			},
+			expectedErr: errs.ErrConflict,
+		},
+		{
+			name: "error on conflict with equal values",
+			loader: Loader{
+				Merge: ErrorOnConflict,
+				Sources: []Source{
+					&testSource{envs: map[string]string{"TEST_KEY": "value"}},
+					&testSource{envs: map[string]string{"TEST_KEY": "value"}},
+				},
+			},
+			expected: map[string]string{"TEST_KEY": "value"},
+		},
+		{
+			name: "with priority",
+			loader: Loader{
+				Merge: ErrorOnConflict,
+				Sources: []Source{
+					&Configured{Source: &testSource{envs: map[string]string{"TEST_KEY": "high"}}, Priority: 10},
+					&testSource{envs: map[string]string{"TEST_KEY": "low"}},
+				},
+			},
+			expected: map[string]string{"TEST_KEY": "high"},
+		},
+		{
+			name: "with source prefix",
+			loader: Loader{
+				Sources: []Source{
+					&Configured{Source: &testSource{envs: map[string]string{"PASSWORD": "db"}}, Prefix: "DB_"},
+					&Configured{Source: &testSource{envs: map[string]string{"PASSWORD": "cache"}}, Prefix: "CACHE_"},
+				},
+			},
+			expected: map[string]string{"DB_PASSWORD": "db", "CACHE_PASSWORD": "cache"},
+		},
+		{
+			name: "with source filters and transforms",
+			loader: Loader{
+				Sources: []Source{
+					&Configured{
+						Source: &testSource{envs: map[string]string{
+							"APP_KEY":   "value",
+							"OTHER_KEY": "other_value",
+						}},
+						Filters:    []func(string) bool{func(key string) bool { return strings.HasPrefix(key, "APP_") }},
+						Transforms: []func(string) string{func(key string) string { return strings.TrimPrefix(key, "APP_") }},
+					},
+					&testSource{envs: map[string]string{"APP_NAME": "name"}},
+				},
+			},
+			expected: map[string]string{"KEY": "value", "APP_NAME": "name"},
+		},
 	}
 
 	for _, tc := range tt {
@@ -76,3 +235,308 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+type testContextSource struct {
+	testSource
+}
+
+func (s *testContextSource) LoadContext(ctx context.Context) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"TEST_KEY": "context"}, nil
+}
+
+func TestLoadContext(t *testing.T) {
+	l := Loader{
+		Sources: []Source{
+			&Loader{Sources: []Source{&testContextSource{}}},
+		},
+	}
+
+	envs, err := l.LoadContext(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TEST_KEY": "context"}, envs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = l.LoadContext(ctx)
+	assert.ErrorIs(t, err, errs.ErrLoadEnv)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLoadProfileDoesNotMutateNestedLoaders(t *testing.T) {
+	sub := &Loader{Sources: []Source{&testProfileSource{
+		testSource: testSource{envs: map[string]string{"TEST_KEY": "value"}},
+		profiles:   map[string]map[string]string{"prod": {"TEST_KEY": "prod_value"}},
+	}}}
+
+	l := Loader{Profile: "prod", Sources: []Source{sub}}
+
+	envs, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TEST_KEY": "prod_value"}, envs)
+	assert.Empty(t, sub.Profile)
+}
+
+func TestLoadRetry(t *testing.T) {
+	src := mock.New(
+		mock.Response{Err: errors.New("unavailable")},
+		mock.Response{Values: map[string]string{"TEST_KEY": "value"}, Delay: time.Minute},
+		mock.Response{Values: map[string]string{"TEST_KEY": "value"}},
+	)
+
+	l := Loader{Sources: []Source{&Configured{
+		Source:  src,
+		Timeout: 10 * time.Millisecond,
+		Retries: 2,
+		Backoff: time.Millisecond,
+		Jitter:  time.Millisecond,
+	}}}
+
+	envs, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TEST_KEY": "value"}, envs)
+	assert.Equal(t, 3, src.Calls())
+}
+
+func TestLoadRetryExhausted(t *testing.T) {
+	errLoad := errors.New("unavailable")
+	src := mock.New(mock.Response{Err: errLoad})
+
+	l := Loader{Sources: []Source{&Configured{Source: src, Retries: 2, Backoff: time.Millisecond}}}
+
+	_, err := l.Load()
+	assert.ErrorIs(t, err, errLoad)
+	assert.Equal(t, 3, src.Calls())
+}
+
+func TestLoadOptional(t *testing.T) {
+	errLoad := errors.New("unavailable")
+	optional := &testSource{err: errLoad}
+
+	var skipped []Source
+
+	l := Loader{
+		OnSkip: func(s Source, err error) {
+			assert.ErrorIs(t, err, errLoad)
+			skipped = append(skipped, s)
+		},
+		Sources: []Source{&Loader{Sources: []Source{
+			&testSource{envs: map[string]string{"TEST_KEY": "value"}},
+			&Configured{Source: optional, Optional: true},
+		}}},
+	}
+
+	envs, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TEST_KEY": "value"}, envs)
+	assert.Equal(t, []Source{optional}, skipped)
+}
+
+func TestLoadOrigins(t *testing.T) {
+	dotenv := &testSource{envs: map[string]string{"PASSWORD": "dotenv", "APP_HOST": "localhost"}}
+	secrets := &testSource{envs: map[string]string{"PASSWORD": "secret"}}
+	override := &testSource{envs: map[string]string{"PORT": "8080"}}
+
+	l := Loader{
+		Sources: []Source{
+			&Loader{Sources: []Source{dotenv}, Transforms: []func(string) string{
+				func(key string) string { return strings.TrimPrefix(key, "APP_") },
+			}},
+			&Configured{Source: secrets, Prefix: "DB_"},
+			&Configured{Source: &testSource{envs: map[string]string{"DB_PASSWORD": "low"}}, Priority: -1},
+		},
+		Overrides: []Source{override},
+	}
+
+	envs, origins, err := l.LoadOrigins(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PASSWORD":    "dotenv",
+		"HOST":        "localhost",
+		"DB_PASSWORD": "secret",
+		"PORT":        "8080",
+	}, envs)
+	assert.Equal(t, map[string]Origin{
+		"PASSWORD":    {Source: dotenv, Key: "PASSWORD"},
+		"HOST":        {Source: dotenv, Key: "APP_HOST"},
+		"DB_PASSWORD": {Source: secrets, Key: "PASSWORD"},
+		"PORT":        {Source: override, Key: "PORT"},
+	}, origins)
+}
+
+type testKeySource struct {
+	testSource
+	keys []string
+}
+
+func (s *testKeySource) LoadKeys(_ context.Context, keys []string) (map[string]string, error) {
+	s.keys = keys
+
+	envs := make(map[string]string)
+	for _, k := range keys {
+		if v, ok := s.envs[k]; ok {
+			envs[k] = v
+		}
+	}
+
+	return envs, nil
+}
+
+func TestLoadKeys(t *testing.T) {
+	envs := map[string]string{"HOST": "localhost", "PORT": "8080", "OTHER": "other"}
+
+	plain := &testKeySource{testSource: testSource{envs: envs}}
+	prefixed := &testKeySource{testSource: testSource{envs: envs}}
+	transformed := &testKeySource{testSource: testSource{envs: map[string]string{"APP_NAME": "app"}}}
+
+	l := Loader{
+		Keys: []string{"HOST", "DB_PORT", "NAME"},
+		Sources: []Source{
+			plain,
+			&Configured{Source: prefixed, Prefix: "DB_"},
+			&Loader{Sources: []Source{transformed}, Transforms: []func(string) string{
+				func(key string) string { return strings.TrimPrefix(key, "APP_") },
+			}},
+		},
+	}
+
+	loaded, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"HOST": "localhost", "DB_PORT": "8080", "NAME": "app"}, loaded)
+	assert.Equal(t, []string{"HOST", "DB_PORT", "NAME"}, plain.keys)
+	assert.Equal(t, []string{"PORT"}, prefixed.keys)
+	assert.Nil(t, transformed.keys)
+}
+
+func TestWrap(t *testing.T) {
+	suffix := func(s string) func(Source) Source {
+		return func(next Source) Source {
+			return sources.Func(func(ctx context.Context) (map[string]string, error) {
+				envs, err := sources.LoadContext(ctx, next)
+				if err != nil {
+					return nil, err
+				}
+
+				wrapped := make(map[string]string, len(envs))
+				for k, v := range envs {
+					wrapped[k] = v + s
+				}
+
+				return wrapped, nil
+			})
+		}
+	}
+
+	nested := &Loader{Sources: []Source{&testSource{envs: map[string]string{"NESTED": "value"}}}}
+
+	l := &Loader{
+		Middleware: []func(Source) Source{suffix("_outer"), suffix("_inner")},
+		Sources: []Source{
+			&testSource{envs: map[string]string{"PLAIN": "value"}},
+			&Configured{Source: &testSource{envs: map[string]string{"KEY": "value"}}, Prefix: "CONFIGURED_"},
+			nested,
+		},
+	}
+	l.Wrap()
+
+	envs, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLAIN":          "value_inner_outer",
+		"CONFIGURED_KEY": "value_inner_outer",
+		"NESTED":         "value_inner_outer",
+	}, envs)
+
+	envs, err = nested.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"NESTED": "value"}, envs)
+}
+
+func TestLoadConflicts(t *testing.T) {
+	first := &testSource{envs: map[string]string{"TEST_KEY": "first", "SAME": "value"}}
+	second := &testSource{envs: map[string]string{"TEST_KEY": "second", "SAME": "value"}}
+	override := &testSource{envs: map[string]string{"TEST_KEY": "override"}}
+
+	var conflicts []Conflict
+
+	l := Loader{
+		Sources:   []Source{first, second},
+		Overrides: []Source{override},
+		OnConflict: func(c Conflict) error {
+			conflicts = append(conflicts, c)
+			return nil
+		},
+	}
+
+	_, err := l.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []Conflict{
+		{
+			Key:          "TEST_KEY",
+			Value:        "second",
+			Origin:       Origin{Source: second, Key: "TEST_KEY"},
+			IgnoredValue: "first",
+			Ignored:      Origin{Source: first, Key: "TEST_KEY"},
+		},
+		{
+			Key:          "TEST_KEY",
+			Value:        "override",
+			Origin:       Origin{Source: override, Key: "TEST_KEY"},
+			IgnoredValue: "second",
+			Ignored:      Origin{Source: second, Key: "TEST_KEY"},
+		},
+	}, conflicts)
+
+	t.Run("first wins", func(t *testing.T) {
+		conflicts = nil
+		l := Loader{
+			Merge:      FirstWins,
+			Sources:    []Source{first, second},
+			OnConflict: func(c Conflict) error { conflicts = append(conflicts, c); return nil },
+		}
+
+		_, err := l.Load()
+		require.NoError(t, err)
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, "first", conflicts[0].Value)
+		assert.Equal(t, "second", conflicts[0].IgnoredValue)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		errConflict := errors.New("conflict")
+		l := Loader{
+			Sources:    []Source{&Loader{Sources: []Source{first, second}}},
+			OnConflict: func(Conflict) error { return errConflict },
+		}
+
+		_, err := l.Load()
+		assert.ErrorIs(t, err, errConflict)
+	})
+}
+
+type testFileSource struct {
+	testSource
+	files []string
+}
+
+func (s *testFileSource) Files() []string {
+	return s.files
+}
+
+func TestFiles(t *testing.T) {
+	l := &Loader{
+		Sources: []Source{
+			&testFileSource{files: []string{".env"}},
+			&Configured{Source: &testFileSource{files: []string{"/run/secrets"}}},
+			&Loader{DefaultSource: &testFileSource{files: []string{"default.env"}}},
+			&testSource{},
+		},
+		Overrides: []Source{&testFileSource{files: []string{"override.env"}}},
+	}
+
+	assert.Equal(t, []string{".env", "/run/secrets", "default.env", "override.env"}, Files(l))
+}
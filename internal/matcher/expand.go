@@ -0,0 +1,188 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+
+	errs "github.com/sethpollack/envcfg/errors"
+)
+
+// expandValue replaces references to environment variables in value,
+// using the shell syntax:
+//
+//	$VAR, ${VAR}   the value of VAR
+//	${VAR:-word}   word when VAR is unset or empty, ${VAR-word} when unset
+//	${VAR:?msg}    an error with msg when VAR is unset or empty, ${VAR?msg} when unset
+//	${VAR:+word}   word when VAR is set and not empty, ${VAR+word} when set
+//	$$             a literal $
+//
+// Words may contain references themselves.
+func (m *Matcher) expandValue(value string) (string, error) {
+	var buf strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			buf.WriteByte(value[i])
+			continue
+		}
+
+		switch c := value[i+1]; {
+		case c == '$':
+			buf.WriteByte('$')
+			i++
+		case c == '{':
+			end := closingBrace(value, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("%w: missing } in %q", errs.ErrExpand, value)
+			}
+
+			s, err := m.expandParam(value[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+
+			buf.WriteString(s)
+			i = end
+		case isNameChar(c):
+			j := i + 1
+			for j < len(value) && isNameChar(value[j]) {
+				j++
+			}
+
+			s, _ := m.lookup(value[i+1 : j])
+			buf.WriteString(s)
+			i = j - 1
+		default:
+			buf.WriteByte('$')
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// expand expands a value of GetValue, which returns found and isDefault
+// unless the expansion fails.
+func (m *Matcher) expand(value string, found, isDefault bool) (string, bool, bool, error) {
+	value, err := m.expandValue(value)
+	if err != nil {
+		return "", false, false, err
+	}
+
+	return value, found, isDefault, nil
+}
+
+// expandParam expands the expression between the braces of ${...}.
+func (m *Matcher) expandParam(expr string) (string, error) {
+	n := 0
+	for n < len(expr) && isNameChar(expr[n]) {
+		n++
+	}
+
+	name, op := expr[:n], expr[n:]
+	if name == "" {
+		return "", fmt.Errorf("%w: bad substitution ${%s}", errs.ErrExpand, expr)
+	}
+
+	value, set := m.lookup(name)
+	if op == "" {
+		return value, nil
+	}
+
+	// with a colon, empty values are treated as unset.
+	if strings.HasPrefix(op, ":") {
+		set = set && value != ""
+		op = op[1:]
+	}
+
+	if op == "" {
+		return "", fmt.Errorf("%w: bad substitution ${%s}", errs.ErrExpand, expr)
+	}
+
+	word := op[1:]
+
+	switch op[0] {
+	case '-':
+		if set {
+			return value, nil
+		}
+
+		return m.expandValue(word)
+	case '+':
+		if !set {
+			return "", nil
+		}
+
+		return m.expandValue(word)
+	case '?':
+		if set {
+			return value, nil
+		}
+
+		msg, err := m.expandValue(word)
+		if err != nil {
+			return "", err
+		}
+
+		if msg == "" {
+			msg = "not set"
+		}
+
+		return "", fmt.Errorf("%w: %s: %s", errs.ErrExpand, name, msg)
+	}
+
+	return "", fmt.Errorf("%w: bad substitution ${%s}", errs.ErrExpand, expr)
+}
+
+// lookup returns the value of a referenced variable from the first of
+// the ExpandLookups that has it, or from the environment variables.
+func (m *Matcher) lookup(name string) (string, bool) {
+	if len(m.ExpandLookups) == 0 {
+		return m.LookupEnv(name)
+	}
+
+	for _, lookup := range m.ExpandLookups {
+		if value, ok := lookup(name); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// LookupEnv returns the value of an environment variable referenced by an
+// expanded value, marking it as used.
+func (m *Matcher) LookupEnv(name string) (string, bool) {
+	value, ok := m.EnvVars[name]
+	if ok {
+		m.markUsed(name)
+	}
+
+	return value, ok
+}
+
+// closingBrace returns the index of the brace closing the reference
+// starting at i, skipping nested references, or -1.
+func closingBrace(s string, i int) int {
+	depth := 0
+
+	for ; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+
+	return -1
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
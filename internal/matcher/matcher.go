@@ -1,91 +1,674 @@
 package matcher
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
 	errs "github.com/sethpollack/envcfg/errors"
-	"github.com/sethpollack/envcfg/internal/tag"
+	"github.com/sethpollack/envcfg/tag"
 )
 
 type Matcher struct {
 	// tags
-	TagName     string
-	DefaultTag  string
-	ExpandTag   string
-	FileTag     string
-	NotEmptyTag string
-	RequiredTag string
+	TagName        string
+	DefaultTag     string
+	ExpandTag      string
+	FileTag        string
+	DefaultFileTag string
+	NotEmptyTag    string
+	RequiredTag    string
+	DescTag        string
+	ExampleTag     string
+	SensitiveTag   string
+	// RenamedFromTag names the deprecated environment variable a field used to be read from.
+	RenamedFromTag string
+	// DefaultEnvTag names another environment variable the default value
+	// is read from when the field is not set, e.g. defaultEnv:"HOSTNAME".
+	DefaultEnvTag string
+	// GroupTag and GroupRequiredTag name the required group tags.
+	GroupTag         string
+	GroupRequiredTag string
+	// RequiredIfTag and RequiredWithTag name the conditionally required tags.
+	RequiredIfTag   string
+	RequiredWithTag string
+	// AliasTag lists alternative names of a field, separated by "|" and
+	// tried in order after its env tag name, e.g. alias:"DB_URL|PG_URL".
+	AliasTag string
+	// SquashTag marks a struct field whose nested fields are not prefixed
+	// with its name, e.g. squash:"true", or are with squash:"false".
+	SquashTag string
+	// PrefixTag sets the prefix of the nested fields of a struct field in
+	// place of its name, e.g. envPrefix:"PRIMARY_DB_".
+	PrefixTag string
+	// ValidateTag names the validation rules tag.
+	ValidateTag string
 	// default options
 	Expand          bool
 	Required        bool
 	NotEmpty        bool
 	DisableFallback bool
+	// DisableFieldNameFallback disables matching the field name, its snake
+	// case form and the NamingStrategy names, DisableTagFallback disables
+	// matching other tags, such as json and yaml.
+	DisableFieldNameFallback bool
+	DisableTagFallback       bool
+	// SquashEmbedded doesn't prefix the fields of embedded structs
+	// with the name of the embedded type.
+	SquashEmbedded bool
+	// NotEmptyTrimSpace treats values consisting only of whitespace as
+	// empty for notempty fields, like notempty:"trim" does per field.
+	NotEmptyTrimSpace bool
+	// Profile selects profile specific default tags, e.g. default_prod.
+	Profile string
+	// UnsetToken is a value returned as is, without reading files or
+	// expanding it, for the walker to zero the field, e.g. "__UNSET__".
+	UnsetToken string
+	// EmptyAsUnset ignores environment variables with empty values, as if
+	// they were not set, so that fields fall through to their defaults.
+	EmptyAsUnset bool
+	// BracketIndex accepts slice indexes written as FIELD[0]_HOST in
+	// addition to FIELD_0_HOST.
+	BracketIndex bool
+
+	// Renames maps new environment variable names to the deprecated names
+	// that are still accepted in their place.
+	Renames map[string][]string
+	// KeySeparator joins the names of nested fields, "_" by default. A
+	// different separator, such as "__", distinguishes nested fields from
+	// the underscores of snake case names.
+	KeySeparator string
+
+	// FallbackTags are the tags tried in order when the env tag doesn't
+	// match, "struct" and "struct_snake" being the field name and its snake
+	// case form. By default, all tags are tried in the order they are
+	// declared, followed by the field name and its snake case form.
+	FallbackTags []string
+
+	// NamingStrategy returns the names of the last field of a path of field
+	// names, in place of the field name and its snake case form. The names
+	// are joined with those of the parent fields, e.g. ["DB", "MaxConns"]
+	// may return ["MAX_CONNS"] to be matched as DB_MAX_CONNS.
+	NamingStrategy func(fieldPath []string) []string
+
+	// DefaultFuncs compute default values named with an @ prefix at parse
+	// time, e.g. default:"@hostname".
+	DefaultFuncs map[string]func() (string, error)
+
+	// ExpandLookups look up the variables referenced by expanded values,
+	// the first one that has a variable is used. When empty, EnvVars are
+	// looked up.
+	ExpandLookups []func(name string) (string, bool)
+
+	// Logger receives deprecation warnings, slog.Default() is used when nil.
+	Logger *slog.Logger
+
+	// FS is used to read file tag values when set, otherwise the OS filesystem is used.
+	FS fs.FS
+	// FileBaseDir restricts the OS files read for file tag values to a
+	// directory, relative paths are relative to it. Symlinks are resolved
+	// so that they can't point outside of it.
+	FileBaseDir string
 
 	EnvVars map[string]string
+
+	// used records the environment variables matched by GetValue.
+	used map[string]bool
+	// files records the OS files read for file and default_file tags.
+	files []string
 }
 
 func New() *Matcher {
 	return &Matcher{
-		TagName:     "env",
-		DefaultTag:  "default",
-		ExpandTag:   "expand",
-		FileTag:     "file",
-		NotEmptyTag: "notempty",
-		RequiredTag: "required",
-		EnvVars:     map[string]string{},
+		TagName:          "env",
+		DefaultTag:       "default",
+		ExpandTag:        "expand",
+		FileTag:          "file",
+		DefaultFileTag:   "defaultFile",
+		NotEmptyTag:      "notempty",
+		RequiredTag:      "required",
+		DescTag:          "desc",
+		ExampleTag:       "example",
+		SensitiveTag:     "sensitive",
+		RenamedFromTag:   "renamedFrom",
+		DefaultEnvTag:    "defaultEnv",
+		GroupTag:         "group",
+		GroupRequiredTag: "grouprequired",
+		RequiredIfTag:    "required_if",
+		RequiredWithTag:  "required_with",
+		PrefixTag:        "envPrefix",
+		SquashTag:        "squash",
+		AliasTag:         "alias",
+		KeySeparator:     "_",
+		ValidateTag:      "validate",
+		Renames:          map[string][]string{},
+		DefaultFuncs:     map[string]func() (string, error){},
+		EnvVars:          map[string]string{},
+	}
+}
+
+var bracketIndex = regexp.MustCompile(`\[(\d+)\]`)
+
+// SetEnvVars sets the environment variables to match against.
+func (m *Matcher) SetEnvVars(envs map[string]string) {
+	if !m.BracketIndex && !m.EmptyAsUnset {
+		m.EnvVars = envs
+		return
+	}
+
+	m.EnvVars = make(map[string]string, len(envs))
+	for key, value := range envs {
+		if m.EmptyAsUnset && value == "" {
+			continue
+		}
+
+		if m.BracketIndex {
+			key = bracketIndex.ReplaceAllString(key, m.KeySeparator+"${1}")
+		}
+
+		m.EnvVars[key] = value
 	}
 }
 
 func (m *Matcher) GetValue(path []tag.TagMap) (string, bool, bool, error) {
-	opts := m.parseOptions(path[len(path)-1])
+	current := path[len(path)-1]
+	opts := m.parseOptions(current)
 
-	foundMatch, foundKey, foundValue := m.getValue("", path)
+	foundMatch, foundKey, foundValue := m.getValue("", path, 0)
+
+	if !foundMatch {
+		if old, ok := opts[m.RenamedFromTag]; ok && old != "" {
+			if value, ok := m.EnvVars[strings.ToUpper(old)]; ok {
+				m.warnRenamed(strings.ToUpper(old), m.key(path))
+				foundMatch, foundKey, foundValue = true, strings.ToUpper(old), value
+			}
+		}
+	}
 
 	if !foundMatch {
 		if _, ok := opts[m.RequiredTag]; ok {
 			return "", false, false, fmt.Errorf("%w: %s", errs.ErrRequired, fieldPath(path))
 		}
 
+		if key, ok := opts[m.DefaultEnvTag]; ok && key != "" {
+			if value, ok := m.EnvVars[strings.ToUpper(key)]; ok {
+				m.markUsed(strings.ToUpper(key))
+
+				if _, ok := opts[m.ExpandTag]; ok {
+					return m.expand(value, false, true)
+				}
+				return value, false, true, nil
+			}
+		}
+
+		// a missing default file falls back to the default value.
+		if path, ok := opts[m.DefaultFileTag]; ok && path != "" {
+			bytes, err := m.readFile(path, current.Tags[m.DefaultFileTag].Options)
+			if err == nil {
+				if _, ok := opts[m.ExpandTag]; ok {
+					return m.expand(string(bytes), false, true)
+				}
+				return string(bytes), false, true, nil
+			}
+
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", false, false, fmt.Errorf("%w: %s", errs.ErrReadFile, err)
+			}
+		}
+
 		if _, ok := opts[m.DefaultTag]; ok {
+			value, err := m.defaultValue(opts[m.DefaultTag])
+			if err != nil {
+				return "", false, false, err
+			}
+
 			if _, ok := opts[m.ExpandTag]; ok {
-				return m.expandValue(opts[m.DefaultTag]), false, true, nil
+				return m.expand(value, false, true)
 			}
-			return opts[m.DefaultTag], false, true, nil
+			return value, false, true, nil
 		}
 
 		return "", false, false, nil
 	}
 
-	if _, ok := opts[m.NotEmptyTag]; ok && foundValue == "" {
+	m.markUsed(foundKey)
+
+	if m.UnsetToken != "" && foundValue == m.UnsetToken {
+		return foundValue, true, false, nil
+	}
+
+	if notEmpty, ok := opts[m.NotEmptyTag]; ok && m.empty(foundValue, notEmpty) {
 		return "", false, false, fmt.Errorf("%w: %s", errs.ErrNotEmpty, foundKey)
 	}
 
 	if _, ok := opts[m.FileTag]; ok {
-		bytes, err := os.ReadFile(foundValue)
+		file, err := m.filePath(foundValue)
+		if err != nil {
+			return "", false, false, fmt.Errorf("%w: %w", errs.ErrReadFile, err)
+		}
+
+		bytes, err := m.readFile(file, current.Tags[m.FileTag].Options)
 		if err != nil {
 			return "", false, false, fmt.Errorf("%w: %s", errs.ErrReadFile, err)
 		}
 
 		if _, ok := opts[m.ExpandTag]; ok {
-			return m.expandValue(string(bytes)), true, false, nil
+			return m.expand(string(bytes), true, false)
 		}
 
 		return string(bytes), true, false, nil
 	}
 
 	if _, ok := opts[m.ExpandTag]; ok {
-		return m.expandValue(foundValue), true, false, nil
+		return m.expand(foundValue, true, false)
 	}
 
 	return foundValue, true, false, nil
 }
 
+// Remain returns the environment variables under the prefix of the path
+// that were not matched by GetValue, keyed by their name after the prefix.
+// When claim is true, the returned variables are marked as matched.
+func (m *Matcher) Remain(path []tag.TagMap, claim bool) map[string]string {
+	values := map[string]string{}
+
+	for key, value := range m.EnvVars {
+		if m.used[key] {
+			continue
+		}
+
+		found, prefix := m.toPrefix(key, "", path, 0)
+		if !found {
+			continue
+		}
+
+		name := key
+		if prefix != "" {
+			if !strings.HasPrefix(key, m.join(prefix, "")) {
+				continue
+			}
+
+			name = strings.TrimPrefix(key, m.join(prefix, ""))
+		}
+
+		values[name] = value
+
+		if claim {
+			m.markUsed(key)
+		}
+	}
+
+	return values
+}
+
+// Unused returns the environment variables that were not matched by
+// GetValue, claimed by a remain field or referenced by an expanded value.
+func (m *Matcher) Unused() []string {
+	var keys []string
+
+	for key := range m.EnvVars {
+		if !m.used[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+func (m *Matcher) markUsed(key string) {
+	if m.used == nil {
+		m.used = map[string]bool{}
+	}
+
+	m.used[key] = true
+}
+
+// Spec describes the environment variable a field path is matched against.
+type Spec struct {
+	Key         string
+	Default     string
+	HasDefault  bool
+	Required    bool
+	NotEmpty    bool
+	Expand      bool
+	File        bool
+	Description string
+	Example     string
+	Sensitive   bool
+}
+
+// Spec returns the primary environment variable name and options of the path
+// without consulting any environment variables.
+func (m *Matcher) Spec(path []tag.TagMap) Spec {
+	current := path[len(path)-1]
+	opts := m.parseOptions(current)
+
+	spec := Spec{
+		Key: m.key(path),
+	}
+
+	spec.Default, spec.HasDefault = opts[m.DefaultTag]
+	_, spec.Required = opts[m.RequiredTag]
+	_, spec.NotEmpty = opts[m.NotEmptyTag]
+	_, spec.Expand = opts[m.ExpandTag]
+	_, spec.File = opts[m.FileTag]
+
+	if t, ok := current.Tags[m.DescTag]; ok {
+		spec.Description = t.Value
+	}
+
+	if t, ok := current.Tags[m.ExampleTag]; ok {
+		spec.Example = t.Value
+	}
+
+	spec.Sensitive = m.sensitive(path)
+
+	return spec
+}
+
+// sensitive reports whether the field or any of its parents is marked sensitive.
+func (m *Matcher) sensitive(path []tag.TagMap) bool {
+	for _, tm := range path {
+		value, ok := m.parseOptions(tm)[m.SensitiveTag]
+		if !ok {
+			continue
+		}
+
+		if b, err := strconv.ParseBool(value); value == "" || (err == nil && b) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// key builds the preferred environment variable name of the path
+// using the env tag, falling back to the snake case field name.
+func (m *Matcher) key(path []tag.TagMap) string {
+	key := ""
+
+	for i, tm := range path {
+		if m.squash(tm) {
+			continue
+		}
+
+		name := tm.Tags["struct_snake"].Value
+
+		if m.NamingStrategy != nil {
+			if names := m.NamingStrategy(fieldNames(path[:i+1])); len(names) > 0 {
+				name = names[0]
+			}
+		}
+
+		if t, ok := tm.Tags[m.TagName]; ok && t.Value != "" {
+			name = t.Value
+		}
+
+		if t, ok := tm.Tags[m.PrefixTag]; ok && t.Value != "" {
+			name = t.Value
+		}
+
+		key = m.join(key, name)
+	}
+
+	return strings.ToUpper(key)
+}
+
+// names returns the names the last element of the path is matched by, the
+// env tag first, even when empty, then its aliases, followed by the fallback
+// tags. A prefix tag replaces all other names. The NamingStrategy replaces
+// the field name fallbacks. Fallbacks are ignored with DisableFallback,
+// unless all is true.
+func (m *Matcher) names(path []tag.TagMap, all bool) []string {
+	tm := path[len(path)-1]
+
+	if t, ok := tm.Tags[m.PrefixTag]; ok && t.Value != "" && m.PrefixTag != "" {
+		return []string{t.Value}
+	}
+
+	var names []string
+
+	// fields with only tag options, e.g. env:",required", fall back to
+	// their field name, only the root of top level maps is unnamed.
+	if t, ok := tm.Tags[m.TagName]; ok && (t.Value != "" || tm.FieldName == "") {
+		names = append(names, t.Value)
+	}
+
+	names = append(names, m.aliases(tm)...)
+
+	if m.DisableFallback && !all {
+		return names
+	}
+
+	for _, tagName := range m.fallbackTags(tm) {
+		t, ok := tm.Tags[tagName]
+		if !ok || t.Value == "" || m.isKnownTag(tagName) {
+			continue
+		}
+
+		fieldName := tagName == "struct" || tagName == "struct_snake"
+
+		if fieldName && (m.NamingStrategy != nil || m.DisableFieldNameFallback && !all) {
+			continue
+		}
+
+		if !fieldName && m.DisableTagFallback && !all {
+			continue
+		}
+
+		names = append(names, t.Value)
+	}
+
+	if m.NamingStrategy != nil && (!m.DisableFieldNameFallback || all) {
+		names = append(names, m.NamingStrategy(fieldNames(path))...)
+	}
+
+	return names
+}
+
+// fallbackTags returns the names of the fallback tags in the order they are
+// tried, tags of paths not built by tag.ParseTags are sorted by name.
+func (m *Matcher) fallbackTags(tm tag.TagMap) []string {
+	if m.FallbackTags != nil {
+		return m.FallbackTags
+	}
+
+	if len(tm.Order) == len(tm.Tags) {
+		return tm.Order
+	}
+
+	names := make([]string, 0, len(tm.Tags))
+	for name := range tm.Tags {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// fieldNames returns the field names of the path.
+func fieldNames(path []tag.TagMap) []string {
+	names := make([]string, 0, len(path))
+	for _, tm := range path {
+		names = append(names, tm.FieldName)
+	}
+
+	return names
+}
+
+// prefixes returns the names of the last element of the path joined to
+// the prefix. Empty names only match at the root, e.g. of top level
+// slices and maps.
+func (m *Matcher) prefixes(prefix string, path []tag.TagMap, all bool) []string {
+	if m.squash(path[len(path)-1]) {
+		return []string{prefix}
+	}
+
+	var prefixes []string
+
+	for _, name := range m.names(path, all) {
+		if name == "" && prefix != "" {
+			continue
+		}
+
+		prefixes = append(prefixes, m.join(prefix, name))
+	}
+
+	return prefixes
+}
+
+// aliases returns the alternative names of the path element in order.
+func (m *Matcher) aliases(tm tag.TagMap) []string {
+	value := ""
+
+	if t, ok := tm.Tags[m.AliasTag]; ok && m.AliasTag != "" {
+		value = t.Value
+	}
+
+	if t, ok := tm.Tags[m.TagName]; ok {
+		if v, ok := t.Options[m.AliasTag]; ok {
+			value = v
+		}
+	}
+
+	var aliases []string
+	for _, alias := range strings.Split(value, "|") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+// squash reports whether the path element adds nothing to the prefix of
+// its nested fields, by default embedded structs with SquashEmbedded.
+func (m *Matcher) squash(tm tag.TagMap) bool {
+	value, ok := "", false
+
+	if t, found := tm.Tags[m.SquashTag]; found && m.SquashTag != "" {
+		value, ok = t.Value, true
+	}
+
+	if t, found := tm.Tags[m.TagName]; found {
+		if v, found := t.Options[m.SquashTag]; found {
+			value, ok = v, true
+		}
+	}
+
+	if !ok {
+		return tm.Anonymous && m.SquashEmbedded
+	}
+
+	b, err := strconv.ParseBool(value)
+
+	return value == "" || (err == nil && b)
+}
+
+// join appends name to the prefix, separated by the key separator
+// unless the prefix already ends with it, e.g. envPrefix:"DB_".
+func (m *Matcher) join(prefix, name string) string {
+	if prefix == "" || strings.HasSuffix(prefix, m.KeySeparator) {
+		return prefix + name
+	}
+
+	return prefix + m.KeySeparator + name
+}
+
+// Lookup returns the environment variable the path is matched against, if any.
+func (m *Matcher) Lookup(path []tag.TagMap) (string, bool) {
+	found, key, _ := m.getValue("", path, 0)
+	return key, found
+}
+
+// Keys returns every environment variable GetValue may match the path to.
+// ok is false when the value of the path depends on other variables, such
+// as the references of an expanded value.
+func (m *Matcher) Keys(path []tag.TagMap) ([]string, bool) {
+	opts := m.parseOptions(path[len(path)-1])
+
+	if _, ok := opts[m.ExpandTag]; ok {
+		return nil, false
+	}
+
+	var keys []string
+	m.keys("", path, 0, &keys)
+
+	if old, ok := opts[m.RenamedFromTag]; ok && old != "" {
+		keys = append(keys, strings.ToUpper(old))
+	}
+
+	if key, ok := opts[m.DefaultEnvTag]; ok && key != "" {
+		keys = append(keys, strings.ToUpper(key))
+	}
+
+	slices.Sort(keys)
+
+	return slices.Compact(keys), true
+}
+
+// keys collects the variables getValue looks up.
+func (m *Matcher) keys(prefix string, path []tag.TagMap, i int, keys *[]string) {
+	if i == len(path) {
+		envVarName := strings.ToUpper(prefix)
+
+		*keys = append(*keys, envVarName)
+		*keys = append(*keys, m.Renames[envVarName]...)
+
+		return
+	}
+
+	for _, next := range m.prefixes(prefix, path[:i+1], false) {
+		m.keys(next, path, i+1, keys)
+	}
+}
+
 func (m *Matcher) HasPrefix(path []tag.TagMap) bool {
-	return m.hasPrefix("", path)
+	return m.hasPrefix("", path, 0)
+}
+
+// SliceIndexes returns the sorted indexes of the elements of the slice
+// path, e.g. 0 and 2 for SERVERS_0 and SERVERS_2_HOST.
+func (m *Matcher) SliceIndexes(path []tag.TagMap) []int {
+	var indexes []int
+
+	for key := range m.EnvVars {
+		found, prefix := m.toPrefix(key, "", path, 0)
+		if !found {
+			continue
+		}
+
+		rest, ok := strings.CutPrefix(key, m.join(prefix, ""))
+		if !ok {
+			continue
+		}
+
+		index, _, _ := strings.Cut(rest, m.KeySeparator)
+		if index == "" || strings.Trim(index, "0123456789") != "" {
+			continue
+		}
+
+		if i, err := strconv.Atoi(index); err == nil {
+			indexes = append(indexes, i)
+		}
+	}
+
+	slices.Sort(indexes)
+
+	return slices.Compact(indexes)
 }
 
 func (m *Matcher) GetMapKeys(path []tag.TagMap) []string {
@@ -95,22 +678,47 @@ func (m *Matcher) GetMapKeys(path []tag.TagMap) []string {
 
 	current := path[len(path)-1]
 
-	switch current.Type.Elem().Kind() {
+	switch indirect(indirect(current.Type).Elem()).Kind() {
 	case reflect.Struct:
 		return m.getStructMapKeys(path)
 	case reflect.Slice:
 		return m.getSliceMapKeys(path)
+	case reflect.Map:
+		return m.getNestedMapKeys(path)
 	default:
 		return m.getPrimitiveMapKeys(path)
 	}
 }
 
+// getNestedMapKeys returns the keys of a map of maps, the outer key is the
+// name up to the first key separator, e.g. tenanta for LIMITS_TENANTA_READS.
+func (m *Matcher) getNestedMapKeys(path []tag.TagMap) []string {
+	uniqueKeys := make(map[string]struct{})
+
+	for _, key := range m.getPrimitiveMapKeys(path) {
+		if before, _, ok := strings.Cut(key, strings.ToLower(m.KeySeparator)); ok && m.KeySeparator != "" {
+			key = before
+		}
+
+		if key != "" {
+			uniqueKeys[key] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(uniqueKeys))
+	for key := range uniqueKeys {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
 func (m *Matcher) getPrimitiveMapKeys(path []tag.TagMap) []string {
 	uniqueKeys := make(map[string]struct{})
 
 	for key := range m.EnvVars {
-		if found, prefix := m.toPrefix(key, "", path); found {
-			if key := parseMapKey(key, prefix, ""); key != "" {
+		if found, prefix := m.toPrefix(key, "", path, 0); found {
+			if key := m.parseMapKey(key, prefix, ""); key != "" {
 				uniqueKeys[key] = struct{}{}
 			}
 		}
@@ -130,8 +738,8 @@ func (m *Matcher) getSliceMapKeys(path []tag.TagMap) []string {
 	for i := 0; ; i++ {
 		found := false
 		for key := range m.EnvVars {
-			if ok, prefix := m.toPrefix(key, "", path); ok {
-				if mapKey := parseMapKey(key, prefix, strconv.Itoa(i)); mapKey != "" {
+			if ok, prefix := m.toPrefix(key, "", path, 0); ok {
+				if mapKey := m.parseMapKey(key, prefix, strconv.Itoa(i)); mapKey != "" {
 					uniqueKeys[mapKey] = struct{}{}
 					found = true
 				}
@@ -142,6 +750,16 @@ func (m *Matcher) getSliceMapKeys(path []tag.TagMap) []string {
 		}
 	}
 
+	// slices of non-structs may also be set with a delimited value of
+	// the key, e.g. TAGS_EU=a,b, names ending with an index are elements.
+	if indirect(indirect(indirect(path[len(path)-1].Type).Elem()).Elem()).Kind() != reflect.Struct {
+		for _, key := range m.getPrimitiveMapKeys(path) {
+			if !m.hasIndexSuffix(key) {
+				uniqueKeys[key] = struct{}{}
+			}
+		}
+	}
+
 	keys := make([]string, 0, len(uniqueKeys))
 	for key := range uniqueKeys {
 		keys = append(keys, key)
@@ -153,7 +771,7 @@ func (m *Matcher) getStructMapKeys(path []tag.TagMap) []string {
 	uniqueKeys := make(map[string]struct{})
 
 	for envVarName := range m.EnvVars {
-		if found, prefix := m.toPrefix(envVarName, "", path); found {
+		if found, prefix := m.toPrefix(envVarName, "", path, 0); found {
 			if key := m.findLongestMatchingKey(envVarName, prefix, path); key != "" {
 				uniqueKeys[key] = struct{}{}
 			}
@@ -172,15 +790,15 @@ func (m *Matcher) findLongestMatchingKey(key, prefix string, path []tag.TagMap)
 	bestKey := ""
 	longestMatch := 0
 
-	current := path[len(path)-1]
+	elemType := indirect(indirect(path[len(path)-1].Type).Elem())
 
-	for i := 0; i < current.Type.Elem().NumField(); i++ {
-		field := current.Type.Elem().Field(i)
+	for i := 0; i < elemType.NumField(); i++ {
+		field := elemType.Field(i)
 
 		parsedTags := tag.ParseTags(field)
 
 		if tag, ok := parsedTags.Tags[m.TagName]; ok {
-			if mapKey := parseMapKey(key, prefix, strings.ToUpper(tag.Value)); mapKey != "" {
+			if mapKey := m.parseMapKey(key, prefix, strings.ToUpper(tag.Value)); mapKey != "" {
 				if len(tag.Value) > longestMatch {
 					longestMatch = len(tag.Value)
 					bestKey = mapKey
@@ -193,7 +811,7 @@ func (m *Matcher) findLongestMatchingKey(key, prefix string, path []tag.TagMap)
 				continue
 			}
 
-			if mapKey := parseMapKey(key, prefix, strings.ToUpper(tag.Value)); mapKey != "" {
+			if mapKey := m.parseMapKey(key, prefix, strings.ToUpper(tag.Value)); mapKey != "" {
 				if len(tag.Value) > longestMatch {
 					longestMatch = len(tag.Value)
 					bestKey = mapKey
@@ -205,52 +823,35 @@ func (m *Matcher) findLongestMatchingKey(key, prefix string, path []tag.TagMap)
 	return bestKey
 }
 
-func (m *Matcher) getValue(prefix string, path []tag.TagMap) (bool, string, string) {
-	if len(path) == 0 {
+func (m *Matcher) getValue(prefix string, path []tag.TagMap, i int) (bool, string, string) {
+	if i == len(path) {
 		envVarName := strings.ToUpper(prefix)
 
 		if value, ok := m.EnvVars[envVarName]; ok {
 			return true, envVarName, value
 		}
 
-		return false, "", ""
-	}
-
-	current, rest := path[0], path[1:]
-
-	if tag, ok := current.Tags[m.TagName]; ok {
-		if prefix == "" {
-			if found, envvar, value := m.getValue(tag.Value, rest); found {
-				return found, envvar, value
-			}
-		} else {
-			if found, envvar, value := m.getValue(fmt.Sprint(prefix, "_", tag.Value), rest); found {
-				return found, envvar, value
+		for _, old := range m.Renames[envVarName] {
+			if value, ok := m.EnvVars[old]; ok {
+				m.warnRenamed(old, envVarName)
+				return true, old, value
 			}
 		}
-	}
 
-	for tagName, tag := range current.Tags {
-		if tag.Value == "" || m.isKnownTag(tagName) || m.DisableFallback {
-			continue
-		}
+		return false, "", ""
+	}
 
-		if prefix == "" {
-			if found, envvar, value := m.getValue(tag.Value, rest); found {
-				return found, envvar, value
-			}
-		} else {
-			if found, envvar, value := m.getValue(fmt.Sprint(prefix, "_", tag.Value), rest); found {
-				return found, envvar, value
-			}
+	for _, next := range m.prefixes(prefix, path[:i+1], false) {
+		if found, envvar, value := m.getValue(next, path, i+1); found {
+			return found, envvar, value
 		}
 	}
 
 	return false, "", ""
 }
 
-func (m *Matcher) hasPrefix(prefix string, path []tag.TagMap) bool {
-	if len(path) == 0 {
+func (m *Matcher) hasPrefix(prefix string, path []tag.TagMap, i int) bool {
+	if i == len(path) {
 		envVarName := strings.ToUpper(prefix)
 
 		for env := range m.EnvVars {
@@ -262,41 +863,17 @@ func (m *Matcher) hasPrefix(prefix string, path []tag.TagMap) bool {
 		return false
 	}
 
-	current, rest := path[0], path[1:]
-
-	if tag, ok := current.Tags[m.TagName]; ok {
-		if prefix == "" {
-			if found := m.hasPrefix(tag.Value, rest); found {
-				return found
-			}
-		} else {
-			if found := m.hasPrefix(fmt.Sprint(prefix, "_", tag.Value), rest); found {
-				return found
-			}
-		}
-	}
-
-	for tagName, tag := range current.Tags {
-		if tag.Value == "" || m.isKnownTag(tagName) {
-			continue
-		}
-
-		if prefix == "" {
-			if found := m.hasPrefix(tag.Value, rest); found {
-				return found
-			}
-		} else {
-			if found := m.hasPrefix(fmt.Sprint(prefix, "_", tag.Value), rest); found {
-				return found
-			}
+	for _, next := range m.prefixes(prefix, path[:i+1], true) {
+		if m.hasPrefix(next, path, i+1) {
+			return true
 		}
 	}
 
 	return false
 }
 
-func (m *Matcher) toPrefix(key, prefix string, path []tag.TagMap) (bool, string) {
-	if len(path) == 0 {
+func (m *Matcher) toPrefix(key, prefix string, path []tag.TagMap, i int) (bool, string) {
+	if i == len(path) {
 		envVarPrefix := strings.ToUpper(prefix)
 		if strings.HasPrefix(key, envVarPrefix) {
 			return true, envVarPrefix
@@ -305,43 +882,156 @@ func (m *Matcher) toPrefix(key, prefix string, path []tag.TagMap) (bool, string)
 		return false, ""
 	}
 
-	current, rest := path[0], path[1:]
-
-	if tag, ok := current.Tags[m.TagName]; ok {
-		var newPrefix string
-		if prefix == "" {
-			newPrefix = tag.Value
-		} else {
-			newPrefix = fmt.Sprint(prefix, "_", tag.Value)
+	for _, next := range m.prefixes(prefix, path[:i+1], true) {
+		if found, match := m.toPrefix(key, next, path, i+1); found {
+			return found, match
 		}
+	}
 
-		if found, match := m.toPrefix(key, newPrefix, rest); found {
-			return found, match
+	return false, ""
+}
+
+func (m *Matcher) warnRenamed(old, new string) {
+	logger := m.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.Warn("deprecated environment variable used", "deprecated", old, "replacement", new)
+}
+
+// filePath returns the path of the file named by a file tag value,
+// which must be inside of the FileBaseDir when it is set.
+func (m *Matcher) filePath(path string) (string, error) {
+	if m.FileBaseDir == "" || m.FS != nil {
+		return path, nil
+	}
+
+	base, err := filepath.Abs(m.FileBaseDir)
+	if err != nil {
+		return "", err
+	}
+
+	if base, err = filepath.EvalSymlinks(base); err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(base, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is outside of %s", errs.ErrFileNotAllowed, path, m.FileBaseDir)
+	}
+
+	return resolved, nil
+}
+
+// readFile reads the file of a file or defaultFile tag, applying the
+// options of the tag, e.g. file:"true,trim,base64,maxsize=4096":
+// trim removes trailing newlines, base64 decodes the contents and
+// maxsize limits the size of the file in bytes.
+func (m *Matcher) readFile(path string, options map[string]string) ([]byte, error) {
+	maxSize := int64(-1)
+	if value, ok := options["maxsize"]; ok {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s: invalid maxsize %q", path, value)
 		}
+
+		maxSize = n
 	}
 
-	for tagName, tag := range current.Tags {
-		if tag.Value == "" || m.isKnownTag(tagName) {
-			continue
+	var f io.Reader
+	if m.FS != nil {
+		file, err := m.FS.Open(path)
+		if err != nil {
+			return nil, err
 		}
+		defer file.Close()
+
+		f = file
+	} else {
+		m.files = append(m.files, path)
 
-		var newPrefix string
-		if prefix == "" {
-			newPrefix = tag.Value
-		} else {
-			newPrefix = fmt.Sprint(prefix, "_", tag.Value)
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
 		}
+		defer file.Close()
 
-		if found, match := m.toPrefix(key, newPrefix, rest); found {
-			return found, match
+		f = file
+	}
+
+	if maxSize >= 0 {
+		f = io.LimitReader(f, maxSize+1)
+	}
+
+	bytes, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxSize >= 0 && int64(len(bytes)) > maxSize {
+		return nil, fmt.Errorf("%s: exceeds the maximum size of %d bytes", path, maxSize)
+	}
+
+	if _, ok := options["trim"]; ok {
+		bytes = []byte(strings.TrimRight(string(bytes), "\r\n"))
+	}
+
+	if _, ok := options["base64"]; ok {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
 		}
+
+		bytes = decoded
 	}
 
-	return false, ""
+	return bytes, nil
 }
 
-func (m *Matcher) expandValue(value string) string {
-	return os.Expand(value, func(s string) string { return m.EnvVars[s] })
+// defaultValue returns the value of a default tag, calling the default
+// function it names with an @ prefix, if one is registered.
+func (m *Matcher) defaultValue(value string) (string, error) {
+	name, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+
+	fn, ok := m.DefaultFuncs[name]
+	if !ok {
+		return value, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return "", fmt.Errorf("%w: @%s: %w", errs.ErrDefaultFunc, name, err)
+	}
+
+	return value, nil
+}
+
+// Files returns the OS files read for file and default_file tags.
+func (m *Matcher) Files() []string {
+	return m.files
+}
+
+// empty reports whether value is empty for the notempty option,
+// with the trim option whitespace only values are empty as well.
+func (m *Matcher) empty(value, option string) bool {
+	if m.NotEmptyTrimSpace || option == "trim" {
+		value = strings.TrimSpace(value)
+	}
+
+	return value == ""
 }
 
 func (m *Matcher) parseOptions(tm tag.TagMap) map[string]string {
@@ -367,6 +1057,10 @@ func (m *Matcher) parseOptions(tm tag.TagMap) map[string]string {
 		opts[m.DefaultTag] = tag.Value
 	}
 
+	if tag, ok := tm.Tags[m.profileDefaultTag()]; ok && m.Profile != "" {
+		opts[m.DefaultTag] = tag.Value
+	}
+
 	if tag, ok := tm.Tags[m.ExpandTag]; ok {
 		opts[m.ExpandTag] = tag.Value
 	}
@@ -379,12 +1073,32 @@ func (m *Matcher) parseOptions(tm tag.TagMap) map[string]string {
 		opts[m.FileTag] = tag.Value
 	}
 
+	if tag, ok := tm.Tags[m.RenamedFromTag]; ok {
+		opts[m.RenamedFromTag] = tag.Value
+	}
+
+	if tag, ok := tm.Tags[m.DefaultEnvTag]; ok {
+		opts[m.DefaultEnvTag] = tag.Value
+	}
+
+	if tag, ok := tm.Tags[m.SensitiveTag]; ok {
+		opts[m.SensitiveTag] = tag.Value
+	}
+
+	if tag, ok := tm.Tags[m.DefaultFileTag]; ok {
+		opts[m.DefaultFileTag] = tag.Value
+	}
+
 	// then check for env tag options
 	if tagName, ok := tm.Tags[m.TagName]; ok {
 		if value, ok := tagName.Options[m.DefaultTag]; ok {
 			opts[m.DefaultTag] = value
 		}
 
+		if value, ok := tagName.Options[m.profileDefaultTag()]; ok && m.Profile != "" {
+			opts[m.DefaultTag] = value
+		}
+
 		if value, ok := tagName.Options[m.RequiredTag]; ok {
 			opts[m.RequiredTag] = value
 		}
@@ -400,6 +1114,22 @@ func (m *Matcher) parseOptions(tm tag.TagMap) map[string]string {
 		if value, ok := tagName.Options[m.FileTag]; ok {
 			opts[m.FileTag] = value
 		}
+
+		if value, ok := tagName.Options[m.RenamedFromTag]; ok {
+			opts[m.RenamedFromTag] = value
+		}
+
+		if value, ok := tagName.Options[m.DefaultEnvTag]; ok {
+			opts[m.DefaultEnvTag] = value
+		}
+
+		if value, ok := tagName.Options[m.SensitiveTag]; ok {
+			opts[m.SensitiveTag] = value
+		}
+
+		if value, ok := tagName.Options[m.DefaultFileTag]; ok {
+			opts[m.DefaultFileTag] = value
+		}
 	}
 
 	return opts
@@ -407,39 +1137,89 @@ func (m *Matcher) parseOptions(tm tag.TagMap) map[string]string {
 
 func (m *Matcher) isKnownTag(tagName string) bool {
 	tags := map[string]bool{
-		m.TagName:     true,
-		m.RequiredTag: true,
-		m.DefaultTag:  true,
-		m.ExpandTag:   true,
-		m.NotEmptyTag: true,
-		m.FileTag:     true,
+		m.TagName:          true,
+		m.RequiredTag:      true,
+		m.DefaultTag:       true,
+		m.ExpandTag:        true,
+		m.NotEmptyTag:      true,
+		m.FileTag:          true,
+		m.DescTag:          true,
+		m.ExampleTag:       true,
+		m.RenamedFromTag:   true,
+		m.DefaultEnvTag:    true,
+		m.SensitiveTag:     true,
+		m.DefaultFileTag:   true,
+		m.GroupTag:         true,
+		m.GroupRequiredTag: true,
+		m.RequiredIfTag:    true,
+		m.RequiredWithTag:  true,
+		m.PrefixTag:        true,
+		m.SquashTag:        true,
+		m.AliasTag:         true,
+		m.ValidateTag:      true,
+	}
+
+	if m.Profile != "" {
+		tags[m.profileDefaultTag()] = true
 	}
 
 	_, ok := tags[tagName]
+
 	return ok
 }
 
-func parseMapKey(key, prefix, suffix string) string {
+func (m *Matcher) profileDefaultTag() string {
+	return m.DefaultTag + "_" + m.Profile
+}
+
+func (m *Matcher) parseMapKey(key, prefix, suffix string) string {
 	if !strings.HasPrefix(key, prefix) {
 		return ""
 	}
 
-	// Get the part after prefix, removing the leading underscore
-	afterPrefix := strings.TrimPrefix(key, fmt.Sprintf("%s_", prefix))
+	sep := m.KeySeparator
+
+	// Get the part after prefix, removing the leading separator
+	// unless the prefix ends with it.
+	afterPrefix := strings.TrimPrefix(key, prefix+sep)
+	if strings.HasSuffix(prefix, sep) {
+		afterPrefix = strings.TrimPrefix(key, prefix)
+	}
 
 	// First try exact suffix match
 	if strings.HasSuffix(afterPrefix, suffix) {
-		return strings.ToLower(strings.TrimSuffix(afterPrefix, "_"+suffix))
+		return strings.ToLower(strings.TrimSuffix(afterPrefix, sep+suffix))
 	}
 
 	// If no exact match, look for suffix elsewhere in the string
-	if idx := strings.Index(afterPrefix, "_"+suffix+"_"); idx >= 0 {
+	if idx := strings.Index(afterPrefix, sep+suffix+sep); idx >= 0 {
 		return strings.ToLower(afterPrefix[:idx])
 	}
 
 	return ""
 }
 
+// hasIndexSuffix reports whether the key ends with a slice index, e.g. eu_0.
+func (m *Matcher) hasIndexSuffix(key string) bool {
+	i := strings.LastIndex(key, m.KeySeparator)
+	if i < 0 {
+		return false
+	}
+
+	_, err := strconv.Atoi(key[i+len(m.KeySeparator):])
+
+	return err == nil
+}
+
+// indirect returns the type pointers of t point to.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
 func fieldPath(path []tag.TagMap) string {
 	prefix := path[0].FieldName
 
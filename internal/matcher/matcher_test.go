@@ -1,12 +1,14 @@
 package matcher
 
 import (
+	"fmt"
 	"os"
 	"reflect"
 	"testing"
+	"testing/fstest"
 
 	errs "github.com/sethpollack/envcfg/errors"
-	"github.com/sethpollack/envcfg/internal/tag"
+	"github.com/sethpollack/envcfg/tag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -28,10 +30,14 @@ func TestGetValue(t *testing.T) {
 		EnvVars map[string]string
 
 		// Options
-		Required        bool
-		NotEmpty        bool
-		Expand          bool
-		DisableFallback bool
+		Required          bool
+		NotEmpty          bool
+		NotEmptyTrimSpace bool
+		Expand            bool
+		DisableFallback   bool
+		FallbackTags      []string
+		DisableFieldName  bool
+		DisableTag        bool
 
 		Expected          string
 		ExpectedIsFound   bool
@@ -137,6 +143,112 @@ func TestGetValue(t *testing.T) {
 			NotEmpty:    true,
 			ExpectedErr: errs.ErrNotEmpty,
 		},
+		"notempty whitespace": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: `notempty:"true"`},
+			),
+			EnvVars:         map[string]string{"FOO_BAR": "  "},
+			Expected:        "  ",
+			ExpectedIsFound: true,
+		},
+		"notempty trim": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: `env:",notempty=trim"`},
+			),
+			EnvVars:     map[string]string{"FOO_BAR": " \t"},
+			ExpectedErr: errs.ErrNotEmpty,
+		},
+		"notempty trim override": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: `notempty:"true"`},
+			),
+			EnvVars:           map[string]string{"FOO_BAR": "  "},
+			NotEmptyTrimSpace: true,
+			ExpectedErr:       errs.ErrNotEmpty,
+		},
+		"fallback order": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `yaml:"data_source" json:"db_url"`},
+			),
+			EnvVars:         map[string]string{"DB_URL": "json", "DATA_SOURCE": "yaml", "DATABASE_URL": "snake"},
+			Expected:        "yaml",
+			ExpectedIsFound: true,
+		},
+		"fallback tags": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `yaml:"data_source" json:"db_url"`},
+			),
+			EnvVars:         map[string]string{"DB_URL": "json", "DATA_SOURCE": "yaml", "DATABASE_URL": "snake"},
+			FallbackTags:    []string{"struct_snake", "json"},
+			Expected:        "snake",
+			ExpectedIsFound: true,
+		},
+		"fallback tags not listed": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `yaml:"data_source"`},
+			),
+			EnvVars:      map[string]string{"DATA_SOURCE": "yaml"},
+			FallbackTags: []string{"json"},
+		},
+		"disable field name fallback": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `json:"db_url"`},
+			),
+			EnvVars:          map[string]string{"DB_URL": "json", "DATABASE_URL": "snake"},
+			DisableFieldName: true,
+			Expected:         "json",
+			ExpectedIsFound:  true,
+		},
+		"disable field name fallback unmatched": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL"},
+			),
+			EnvVars:          map[string]string{"DATABASE_URL": "snake"},
+			DisableFieldName: true,
+		},
+		"disable tag fallback": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `json:"db_url"`},
+			),
+			EnvVars:         map[string]string{"DB_URL": "json", "DATABASE_URL": "snake"},
+			DisableTag:      true,
+			Expected:        "snake",
+			ExpectedIsFound: true,
+		},
+		"alias": {
+			Path: parsePath(
+				element{FieldName: "App"},
+				element{FieldName: "DatabaseURL", TagStr: `env:"DATABASE_URL,alias=DB_URL|POSTGRES_URL"`},
+			),
+			EnvVars:         map[string]string{"APP_POSTGRES_URL": "pg", "POSTGRES_URL": "root"},
+			Expected:        "pg",
+			ExpectedIsFound: true,
+		},
+		"alias order": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `env:"DATABASE_URL" alias:"DB_URL|POSTGRES_URL"`},
+			),
+			EnvVars:         map[string]string{"DB_URL": "db", "POSTGRES_URL": "pg"},
+			Expected:        "db",
+			ExpectedIsFound: true,
+		},
+		"alias without fallback": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `env:"DATABASE_URL,alias=DB_URL"`},
+			),
+			EnvVars:         map[string]string{"DB_URL": "db"},
+			DisableFallback: true,
+			Expected:        "db",
+			ExpectedIsFound: true,
+		},
+		"env before alias": {
+			Path: parsePath(
+				element{FieldName: "DatabaseURL", TagStr: `env:"DATABASE_URL,alias=DB_URL"`},
+			),
+			EnvVars:         map[string]string{"DB_URL": "db", "DATABASE_URL": "url"},
+			Expected:        "url",
+			ExpectedIsFound: true,
+		},
 		"expand": {
 			Path: parsePath(
 				element{FieldName: "App"},
@@ -192,6 +304,37 @@ func TestGetValue(t *testing.T) {
 			ExpectedIsFound:   false,
 			ExpectedIsDefault: true,
 		},
+		"default env": {
+			Path: parsePath(
+				element{FieldName: "Host", TagStr: `defaultEnv:"HOSTNAME" default:"localhost"`},
+			),
+			EnvVars:           map[string]string{"HOSTNAME": "web-1"},
+			Expected:          "web-1",
+			ExpectedIsDefault: true,
+		},
+		"default env alt": {
+			Path: parsePath(
+				element{FieldName: "Host", TagStr: `env:",defaultEnv=hostname"`},
+			),
+			EnvVars:           map[string]string{"HOSTNAME": "web-1"},
+			Expected:          "web-1",
+			ExpectedIsDefault: true,
+		},
+		"default env unset": {
+			Path: parsePath(
+				element{FieldName: "Host", TagStr: `defaultEnv:"HOSTNAME" default:"localhost"`},
+			),
+			Expected:          "localhost",
+			ExpectedIsDefault: true,
+		},
+		"default env set": {
+			Path: parsePath(
+				element{FieldName: "Host", TagStr: `defaultEnv:"HOSTNAME"`},
+			),
+			EnvVars:         map[string]string{"HOST": "db", "HOSTNAME": "web-1"},
+			Expected:        "db",
+			ExpectedIsFound: true,
+		},
 		"default + expand": {
 			Path: parsePath(
 				element{FieldName: "FooBar", TagStr: `default:"${OTHER_VAR}" expand:"true"`},
@@ -223,6 +366,49 @@ func TestGetValue(t *testing.T) {
 			Expected:        "${OTHER_VAR}",
 			ExpectedIsFound: true,
 		},
+		"default file": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: fmt.Sprintf(`defaultFile:%q default:"default"`, tempFile.Name())},
+			),
+			Expected:          "${OTHER_VAR}",
+			ExpectedIsDefault: true,
+		},
+		"default file option": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: fmt.Sprintf(`env:",defaultFile=%s"`, tempFile.Name())},
+			),
+			Expected:          "${OTHER_VAR}",
+			ExpectedIsDefault: true,
+		},
+		"default file + expand": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: fmt.Sprintf(`defaultFile:%q expand:"true"`, tempFile.Name())},
+			),
+			EnvVars:           map[string]string{"OTHER_VAR": "foo"},
+			Expected:          "foo",
+			ExpectedIsDefault: true,
+		},
+		"default file with env var": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: fmt.Sprintf(`defaultFile:%q`, tempFile.Name())},
+			),
+			EnvVars:         map[string]string{"FOO_BAR": "foo"},
+			Expected:        "foo",
+			ExpectedIsFound: true,
+		},
+		"missing default file falls back to default": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: `defaultFile:"/non/existent/file" default:"default"`},
+			),
+			Expected:          "default",
+			ExpectedIsDefault: true,
+		},
+		"default file read error": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: fmt.Sprintf(`defaultFile:%q`, os.TempDir())},
+			),
+			ExpectedErr: errs.ErrReadFile,
+		},
 		"expand + file": {
 			Path: parsePath(
 				element{FieldName: "FooBar", TagStr: `file:"true" expand:"true"`},
@@ -241,6 +427,14 @@ func TestGetValue(t *testing.T) {
 			EnvVars:     map[string]string{"FOO_BAR": "invalid"},
 			ExpectedErr: errs.ErrReadFile,
 		},
+		"default_ prefixed user tag fallback": {
+			Path: parsePath(
+				element{FieldName: "FooBar", TagStr: `default_name:"legacy"`},
+			),
+			EnvVars:         map[string]string{"LEGACY": "foo"},
+			Expected:        "foo",
+			ExpectedIsFound: true,
+		},
 	}
 
 	for name, tc := range tt {
@@ -250,8 +444,12 @@ func TestGetValue(t *testing.T) {
 			m.EnvVars = tc.EnvVars
 			m.Required = tc.Required
 			m.NotEmpty = tc.NotEmpty
+			m.NotEmptyTrimSpace = tc.NotEmptyTrimSpace
 			m.Expand = tc.Expand
 			m.DisableFallback = tc.DisableFallback
+			m.FallbackTags = tc.FallbackTags
+			m.DisableFieldNameFallback = tc.DisableFieldName
+			m.DisableTagFallback = tc.DisableTag
 
 			actual, isFound, isDefault, err := m.GetValue(tc.Path)
 
@@ -431,6 +629,21 @@ func TestGetMapKeys(t *testing.T) {
 			EnvVars:  map[string]string{"MAP_SLICE_0": "foo", "MAP_SLICE_1": "bar"},
 			Expected: []string{"slice"},
 		},
+		"map of maps": {
+			Path: parsePath(
+				element{
+					FieldName: "Limits",
+					TagStr:    `env:"LIMITS"`,
+					Type:      reflect.TypeOf(map[string]map[string]int{}),
+				},
+			),
+			EnvVars: map[string]string{
+				"LIMITS_TENANTA_READS":  "10",
+				"LIMITS_TENANTA_WRITES": "5",
+				"LIMITS_TENANTB":        "reads:1",
+			},
+			Expected: []string{"tenanta", "tenantb"},
+		},
 		"map of slices of structs": {
 			Path: parsePath(
 				element{
@@ -442,6 +655,28 @@ func TestGetMapKeys(t *testing.T) {
 			EnvVars:  map[string]string{"MAP_SLICE_0_KEY": "foo", "MAP_SLICE_1_KEY": "bar"},
 			Expected: []string{"slice"},
 		},
+		"map of delimited slices": {
+			Path: parsePath(
+				element{
+					FieldName: "Map",
+					TagStr:    `env:"MAP"`,
+					Type:      reflect.TypeOf(map[string][]string{}),
+				},
+			),
+			EnvVars:  map[string]string{"MAP_EU": "a,b", "MAP_US_0": "c", "MAP_US_1": "d"},
+			Expected: []string{"eu", "us"},
+		},
+		"pointer to map of slices of structs": {
+			Path: parsePath(
+				element{
+					FieldName: "Map",
+					Type:      reflect.TypeOf(&map[string][]*struct{ Key string }{}),
+					TagStr:    `env:"MAP"`,
+				},
+			),
+			EnvVars:  map[string]string{"MAP_EU_0_KEY": "foo", "MAP_EU": "ignored"},
+			Expected: []string{"eu"},
+		},
 	}
 
 	for name, tc := range tt {
@@ -454,6 +689,313 @@ func TestGetMapKeys(t *testing.T) {
 	}
 }
 
+func TestSpec(t *testing.T) {
+	tt := map[string]struct {
+		Path           []tag.TagMap
+		Required       bool
+		Profile        string
+		NamingStrategy func([]string) []string
+		Expected       Spec
+	}{
+		"field name": {
+			Path: parsePath(
+				element{FieldName: "App"},
+				element{FieldName: "FooBar"},
+			),
+			Expected: Spec{Key: "APP_FOO_BAR"},
+		},
+		"env tag": {
+			Path: parsePath(
+				element{FieldName: "App", TagStr: `env:"service"`},
+				element{FieldName: "FooBar", TagStr: `env:"FOO,default=bar,notempty" json:"other"`},
+			),
+			Expected: Spec{Key: "SERVICE_FOO", Default: "bar", HasDefault: true, NotEmpty: true},
+		},
+		"naming strategy": {
+			Path: parsePath(
+				element{FieldName: "App", TagStr: `env:"service"`},
+				element{FieldName: "FooBar"},
+			),
+			NamingStrategy: func(path []string) []string {
+				return []string{"X_" + path[len(path)-1]}
+			},
+			Expected: Spec{Key: "SERVICE_X_FOOBAR"},
+		},
+		"prefix tag": {
+			Path: parsePath(
+				element{FieldName: "App", TagStr: `env:"service" envPrefix:"MY_APP_"`},
+				element{FieldName: "FooBar"},
+			),
+			Expected: Spec{Key: "MY_APP_FOO_BAR"},
+		},
+		"description and example": {
+			Path: parsePath(
+				element{FieldName: "Port", TagStr: `desc:"The port to listen on" example:"8080" required:"true" file:"true" expand:"true"`},
+			),
+			Expected: Spec{
+				Key:         "PORT",
+				Required:    true,
+				File:        true,
+				Expand:      true,
+				Description: "The port to listen on",
+				Example:     "8080",
+			},
+		},
+		"profile default": {
+			Path: parsePath(
+				element{FieldName: "Port", TagStr: `default:"8080" default_prod:"80"`},
+			),
+			Profile:  "prod",
+			Expected: Spec{Key: "PORT", Default: "80", HasDefault: true},
+		},
+		"profile default option": {
+			Path: parsePath(
+				element{FieldName: "Port", TagStr: `env:",default=8080,default_prod=80"`},
+			),
+			Profile:  "prod",
+			Expected: Spec{Key: "PORT", Default: "80", HasDefault: true},
+		},
+		"other profile default": {
+			Path: parsePath(
+				element{FieldName: "Port", TagStr: `default:"8080" default_prod:"80"`},
+			),
+			Profile:  "dev",
+			Expected: Spec{Key: "PORT", Default: "8080", HasDefault: true},
+		},
+		"sensitive": {
+			Path: parsePath(
+				element{FieldName: "Password", TagStr: `env:",sensitive"`},
+			),
+			Expected: Spec{Key: "PASSWORD", Sensitive: true},
+		},
+		"global options": {
+			Path: parsePath(
+				element{FieldName: "Port"},
+			),
+			Required: true,
+			Expected: Spec{Key: "PORT", Required: true},
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			m := New()
+			m.Required = tc.Required
+			m.Profile = tc.Profile
+			m.NamingStrategy = tc.NamingStrategy
+
+			assert.Equal(t, tc.Expected, m.Spec(tc.Path))
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	m := New()
+	m.EnvVars = map[string]string{"APP_FOO_BAR": "foo"}
+
+	key, found := m.Lookup(parsePath(
+		element{FieldName: "App"},
+		element{FieldName: "FooBar"},
+	))
+	assert.True(t, found)
+	assert.Equal(t, "APP_FOO_BAR", key)
+
+	_, found = m.Lookup(parsePath(element{FieldName: "Other"}))
+	assert.False(t, found)
+}
+
+func TestKeys(t *testing.T) {
+	m := New()
+	m.Renames["APP_DB_HOST"] = []string{"DB_HOST"}
+
+	keys, ok := m.Keys(parsePath(
+		element{FieldName: "App"},
+		element{FieldName: "DB", TagStr: `json:"database"`},
+		element{FieldName: "Host"},
+	))
+	require.True(t, ok)
+	assert.Equal(t, []string{"APP_DATABASE_HOST", "APP_DB_HOST", "DB_HOST"}, keys)
+
+	m.RenamedFromTag = "was"
+	keys, ok = m.Keys(parsePath(element{FieldName: "Host", TagStr: `env:"HOST" was:"OLD_HOST"`}))
+	require.True(t, ok)
+	assert.Equal(t, []string{"HOST", "OLD_HOST"}, keys)
+
+	keys, ok = m.Keys(parsePath(element{FieldName: "Host", TagStr: `defaultEnv:"HOSTNAME"`}))
+	require.True(t, ok)
+	assert.Equal(t, []string{"HOST", "HOSTNAME"}, keys)
+
+	_, ok = m.Keys(parsePath(element{FieldName: "URL", TagStr: `expand:"true"`}))
+	assert.False(t, ok)
+}
+
+func TestRemain(t *testing.T) {
+	m := New()
+	m.EnvVars = map[string]string{
+		"DB_HOST":     "localhost",
+		"DB_SSL_MODE": "disable",
+		"DBX":         "other",
+	}
+
+	path := parsePath(element{FieldName: "DB"}, element{FieldName: "Host"})
+
+	_, _, _, err := m.GetValue(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"SSL_MODE": "disable"}, m.Remain(path[:1], false))
+	assert.Equal(t, map[string]string{"SSL_MODE": "disable"}, m.Remain(path[:1], true))
+	assert.Empty(t, m.Remain(path[:1], true))
+}
+
+func TestSliceIndexes(t *testing.T) {
+	m := New()
+	m.EnvVars = map[string]string{
+		"APP_SERVERS_0":      "a",
+		"APP_SERVERS_2_HOST": "b",
+		"APP_SERVERS_2_PORT": "80",
+		"APP_SERVERS_12":     "c",
+		"APP_SERVERS_X":      "d",
+		"APP_SERVERSX_1":     "e",
+	}
+
+	path := parsePath(element{FieldName: "App"}, element{FieldName: "Servers"})
+	assert.Equal(t, []int{0, 2, 12}, m.SliceIndexes(path))
+
+	m.EnvVars = map[string]string{"0": "a", "3": "b"}
+	assert.Equal(t, []int{0, 3}, m.SliceIndexes(nil))
+}
+
+func TestSetEnvVars(t *testing.T) {
+	env := map[string]string{"SERVERS[0]_HOST": "a", "PORTS[10]": "80"}
+
+	m := New()
+	m.SetEnvVars(env)
+	assert.Equal(t, env, m.EnvVars)
+
+	m.BracketIndex = true
+	m.SetEnvVars(env)
+	assert.Equal(t, map[string]string{"SERVERS_0_HOST": "a", "PORTS_10": "80"}, m.EnvVars)
+
+	m.KeySeparator = "__"
+	m.SetEnvVars(map[string]string{"SERVERS[0]__HOST": "a"})
+	assert.Equal(t, map[string]string{"SERVERS__0__HOST": "a"}, m.EnvVars)
+
+	m.BracketIndex = false
+	m.EmptyAsUnset = true
+	m.SetEnvVars(map[string]string{"HOST": "", "PORT": "80"})
+	assert.Equal(t, map[string]string{"PORT": "80"}, m.EnvVars)
+}
+
+func TestFileOptions(t *testing.T) {
+	m := New()
+	m.FS = fstest.MapFS{
+		"token":   {Data: []byte("s3cret\n")},
+		"cert":    {Data: []byte("aGVsbG8=\n")},
+		"invalid": {Data: []byte("not base64")},
+	}
+	m.EnvVars = map[string]string{"TOKEN": "token", "CERT": "cert", "INVALID": "invalid"}
+
+	tt := map[string]struct {
+		Path        []tag.TagMap
+		Expected    string
+		ExpectedErr string
+	}{
+		"raw": {
+			Path:     parsePath(element{FieldName: "Token", TagStr: `file:"true"`}),
+			Expected: "s3cret\n",
+		},
+		"trim": {
+			Path:     parsePath(element{FieldName: "Token", TagStr: `file:"true,trim"`}),
+			Expected: "s3cret",
+		},
+		"base64": {
+			Path:     parsePath(element{FieldName: "Cert", TagStr: `file:"true,trim,base64"`}),
+			Expected: "hello",
+		},
+		"invalid base64": {
+			Path:        parsePath(element{FieldName: "Invalid", TagStr: `file:"true,base64"`}),
+			ExpectedErr: "file read error: invalid: illegal base64 data at input byte 3",
+		},
+		"max size": {
+			Path:     parsePath(element{FieldName: "Token", TagStr: `file:"true,maxsize=7"`}),
+			Expected: "s3cret\n",
+		},
+		"too large": {
+			Path:        parsePath(element{FieldName: "Token", TagStr: `file:"true,maxsize=6"`}),
+			ExpectedErr: "file read error: token: exceeds the maximum size of 6 bytes",
+		},
+		"invalid max size": {
+			Path:        parsePath(element{FieldName: "Token", TagStr: `file:"true,maxsize=1MB"`}),
+			ExpectedErr: `file read error: token: invalid maxsize "1MB"`,
+		},
+		"default file": {
+			Path:     parsePath(element{FieldName: "Missing", TagStr: `defaultFile:"token,trim"`}),
+			Expected: "s3cret",
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			value, _, _, err := m.GetValue(tc.Path)
+
+			if tc.ExpectedErr != "" {
+				require.ErrorIs(t, err, errs.ErrReadFile)
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, value)
+		})
+	}
+}
+
+func TestExpandValue(t *testing.T) {
+	m := New()
+	m.EnvVars = map[string]string{"HOST": "localhost", "EMPTY": "", "PORT": "8080"}
+
+	tt := map[string]struct {
+		Value       string
+		Expected    string
+		ExpectedErr string
+	}{
+		"plain":               {Value: "$HOST:${PORT}", Expected: "localhost:8080"},
+		"unset":               {Value: "${MISSING}$MISSING", Expected: ""},
+		"escape":              {Value: "$$HOST costs $$5", Expected: "$HOST costs $5"},
+		"trailing dollar":     {Value: "5$", Expected: "5$"},
+		"default":             {Value: "${MISSING:-127.0.0.1}", Expected: "127.0.0.1"},
+		"default empty":       {Value: "${EMPTY:-127.0.0.1}", Expected: "127.0.0.1"},
+		"default unset only":  {Value: "${EMPTY-127.0.0.1}", Expected: ""},
+		"default set":         {Value: "${HOST:-127.0.0.1}", Expected: "localhost"},
+		"nested default":      {Value: "${MISSING:-${HOST}:${PORT:-80}}", Expected: "localhost:8080"},
+		"alternative":         {Value: "${PORT:+:$PORT}", Expected: ":8080"},
+		"alternative unset":   {Value: "${MISSING:+:$PORT}", Expected: ""},
+		"alternative empty":   {Value: "${EMPTY+set}${EMPTY:+set}", Expected: "set"},
+		"error set":           {Value: "${HOST:?host is required}", Expected: "localhost"},
+		"error":               {Value: "${MISSING:?host is required}", ExpectedErr: "expansion error: MISSING: host is required"},
+		"error empty":         {Value: "${EMPTY:?}", ExpectedErr: "expansion error: EMPTY: not set"},
+		"error unset only":    {Value: "${EMPTY?}", Expected: ""},
+		"missing brace":       {Value: "${HOST", ExpectedErr: `expansion error: missing } in "${HOST"`},
+		"bad substitution":    {Value: "${HOST:}", ExpectedErr: "expansion error: bad substitution ${HOST:}"},
+		"bad substitution op": {Value: "${HOST/a}", ExpectedErr: "expansion error: bad substitution ${HOST/a}"},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			value, err := m.expandValue(tc.Value)
+
+			if tc.ExpectedErr != "" {
+				require.ErrorIs(t, err, errs.ErrExpand)
+				assert.EqualError(t, err, tc.ExpectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, value)
+		})
+	}
+}
+
 type element struct {
 	FieldName string
 	TagStr    string
@@ -0,0 +1,200 @@
+package walker
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/tag"
+	"gopkg.in/yaml.v3"
+)
+
+// valueEncodings decode values before they are parsed or unmarshaled.
+var valueEncodings = map[string]func(string) (string, error){
+	"base64": decodeBase64,
+	"hex":    decodeHex,
+	"gzip":   decompressGzip,
+}
+
+// encoding returns the value of the encoding tag of a field, e.g.
+// encoding:"json" or env:",encoding=json".
+func (w *Walker) encoding(path []tag.TagMap) string {
+	current := path[len(path)-1]
+
+	if t, ok := current.Tags[w.EncodingTag]; ok {
+		return t.Value
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		return tagName.Options[w.EncodingTag]
+	}
+
+	return ""
+}
+
+// encodings returns the encodings of a field, outermost first. Encodings
+// are applied from the right, e.g. json+base64 is base64 encoded JSON, the
+// base64 tag adds an outermost base64 encoding.
+func (w *Walker) encodings(path []tag.TagMap) []string {
+	var encodings []string
+
+	if w.base64(path) {
+		encodings = append(encodings, "base64")
+	}
+
+	if encoding := w.encoding(path); encoding != "" {
+		parts := strings.Split(encoding, "+")
+		for i := len(parts) - 1; i >= 0; i-- {
+			encodings = append(encodings, parts[i])
+		}
+	}
+
+	return encodings
+}
+
+func (w *Walker) base64(path []tag.TagMap) bool {
+	current := path[len(path)-1]
+
+	if t, ok := current.Tags[w.Base64Tag]; ok {
+		b, err := strconv.ParseBool(t.Value)
+		return t.Value == "" || (err == nil && b)
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		if _, ok := tagName.Options[w.Base64Tag]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unmarshaled reports whether the values of a field are unmarshaled, so
+// that the field is set from a single variable.
+func (w *Walker) unmarshaled(path []tag.TagMap) bool {
+	encodings := w.encodings(path)
+
+	if len(encodings) == 0 {
+		return false
+	}
+
+	_, ok := valueEncodings[encodings[len(encodings)-1]]
+
+	return !ok
+}
+
+// decodeValue decodes a value with the value encodings of a field and
+// returns the encoding it is unmarshaled with, if any, e.g. json.
+func decodeValue(encodings []string, value string) (string, string, error) {
+	for i, encoding := range encodings {
+		decode, ok := valueEncodings[encoding]
+		if !ok {
+			if i == len(encodings)-1 {
+				return value, encoding, nil
+			}
+
+			return "", "", fmt.Errorf("%w: unknown encoding %q", errors.ErrDecodeValue, encoding)
+		}
+
+		decoded, err := decode(value)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %s: %w", errors.ErrDecodeValue, encoding, err)
+		}
+
+		value = decoded
+	}
+
+	return value, "", nil
+}
+
+// isBytes reports whether a field is a byte slice without a parser, which
+// is set to the decoded bytes of encoded values.
+func (w *Walker) isBytes(v *Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 && !w.hasParserOrSetter(v)
+}
+
+// unmarshal sets a field with an encoding tag by unmarshaling its value,
+// replacing the whole field. Without an encoding, the field is a byte
+// slice set to the value.
+func (w *Walker) unmarshal(v *Value, encoding, value string, isDefault bool) error {
+	ptr := reflect.New(v.Type())
+
+	switch encoding {
+	case "":
+		ptr.Elem().SetBytes([]byte(value))
+	case "json":
+		if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+			return fmt.Errorf("%w: json: %w", errors.ErrDecodeValue, err)
+		}
+	case "yaml":
+		if err := yaml.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+			return fmt.Errorf("%w: yaml: %w", errors.ErrDecodeValue, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown encoding %q", errors.ErrDecodeValue, encoding)
+	}
+
+	v.Set(ptr.Elem())
+
+	if isDefault {
+		v.IsDefault = true
+	} else {
+		v.IsSet = true
+	}
+
+	return nil
+}
+
+// walkDecoded walks a struct unmarshaled from a single variable, so that
+// the variables of its fields override the decoded values, e.g.
+// APP_CONFIG_PORT for APP_CONFIG. Defaults only fill the fields left empty.
+func (w *Walker) walkDecoded(v *Value) error {
+	keep := w.KeepNonZeroDefaults
+	w.KeepNonZeroDefaults = true
+
+	defer func() { w.KeepNonZeroDefaults = keep }()
+
+	return w.walkStruct(v)
+}
+
+func decodeBase64(value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+func decodeHex(value string) (string, error) {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+// decompressGzip decompresses gzip data, which is binary and therefore
+// encoded itself, e.g. gzip+base64.
+func decompressGzip(value string) (string, error) {
+	r, err := gzip.NewReader(strings.NewReader(value))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decompressed), nil
+}
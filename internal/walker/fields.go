@@ -0,0 +1,150 @@
+package walker
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/tag"
+)
+
+// Fields statically analyzes the struct type of v and returns the path
+// of every field that can be populated from a single environment variable.
+// No environment variables are consulted.
+func (w *Walker) Fields(v any) ([][]tag.TagMap, error) {
+	rt := reflect.TypeOf(v)
+	if rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: expected a struct or a pointer to a struct, got %T", errors.ErrNotAPointer, v)
+	}
+
+	return w.fields(rt, []tag.TagMap{}), nil
+}
+
+func (w *Walker) fields(rt reflect.Type, path []tag.TagMap) [][]tag.TagMap {
+	var fields [][]tag.TagMap
+
+	for i := 0; i < rt.NumField(); i++ {
+		rf := rt.Field(i)
+
+		if !rf.IsExported() || w.remain(rf) {
+			continue
+		}
+
+		fieldPath := append(append([]tag.TagMap{}, path...), tag.ParseTags(rf))
+
+		if w.ignore(fieldPath) {
+			continue
+		}
+
+		ft := rf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Struct && !w.unmarshaled(fieldPath) && !w.hasParserOrSetter(&Value{Value: reflect.New(ft).Elem()}) {
+			// the fields of recursive types are only listed once.
+			if !recursive(path, ft) && ft != rt {
+				fields = append(fields, w.fields(ft, fieldPath)...)
+			}
+			continue
+		}
+
+		fields = append(fields, fieldPath)
+	}
+
+	return fields
+}
+
+// keyMatcher is implemented by matchers that can list the environment
+// variables a path may be matched to, such as the default matcher.
+type keyMatcher interface {
+	Keys(path []tag.TagMap) ([]string, bool)
+}
+
+// Keys statically analyzes the struct type of v and returns every environment
+// variable that may populate it, so that sources can load just these. ok is
+// false when the variables can't be listed up front, because fields are
+// matched by prefix, such as maps, slices and remain fields, or the matcher
+// doesn't support listing them.
+func (w *Walker) Keys(v any) ([]string, bool) {
+	km, ok := w.Matcher.(keyMatcher)
+	if !ok {
+		return nil, false
+	}
+
+	rt := reflect.TypeOf(v)
+	if rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	keys := []string{}
+	if !w.keys(km, rt, []tag.TagMap{}, &keys) {
+		return nil, false
+	}
+
+	slices.Sort(keys)
+
+	return slices.Compact(keys), true
+}
+
+func (w *Walker) keys(km keyMatcher, rt reflect.Type, path []tag.TagMap, keys *[]string) bool {
+	for i := 0; i < rt.NumField(); i++ {
+		rf := rt.Field(i)
+
+		if !rf.IsExported() {
+			continue
+		}
+
+		if w.remain(rf) {
+			return false
+		}
+
+		fieldPath := append(append([]tag.TagMap{}, path...), tag.ParseTags(rf))
+
+		if w.ignore(fieldPath) {
+			continue
+		}
+
+		fieldKeys, ok := km.Keys(fieldPath)
+		if !ok {
+			return false
+		}
+
+		*keys = append(*keys, fieldKeys...)
+
+		ft := rf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if w.unmarshaled(fieldPath) || w.hasParserOrSetter(&Value{Value: reflect.New(ft).Elem()}) {
+			continue
+		}
+
+		switch ft.Kind() {
+		case reflect.Struct:
+			// the variables of recursive types can't be listed.
+			if recursive(path, ft) || ft == rt || !w.keys(km, ft, fieldPath, keys) {
+				return false
+			}
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return false
+		case reflect.Interface:
+			// the fields of implementations are matched by prefix.
+			if len(w.Implementations[ft]) > 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
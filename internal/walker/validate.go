@@ -0,0 +1,365 @@
+package walker
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/tag"
+)
+
+// rule is a validation rule of the validate tag, e.g. min=1.
+type rule struct {
+	name string
+	arg  string
+}
+
+// rules returns the validation rules of the field,
+// e.g. validate:"min=1,max=10" or validate:"oneof=debug info warn".
+func (w *Walker) rules(path []tag.TagMap) []rule {
+	t, ok := path[len(path)-1].Tags[w.ValidateTag]
+	if !ok || w.ValidateTag == "" {
+		return nil
+	}
+
+	var rules []rule
+
+	if t.Value != "" {
+		name, arg, _ := strings.Cut(t.Value, "=")
+		rules = append(rules, rule{name: name, arg: arg})
+	}
+
+	names := make([]string, 0, len(t.Options))
+	for name := range t.Options {
+		names = append(names, name)
+	}
+
+	// options are unordered, sort them for stable error messages.
+	slices.Sort(names)
+
+	for _, name := range names {
+		rules = append(rules, rule{name: name, arg: t.Options[name]})
+	}
+
+	return rules
+}
+
+// validate checks a parsed field against the rules of its validate tag.
+// Fields that were neither set nor defaulted are not validated.
+func (w *Walker) validate(v *Value) error {
+	if (!v.IsSet && !v.IsDefault) || v.unset {
+		return nil
+	}
+
+	rules := w.rules(v.Path)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	rv := v.Value
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	for _, r := range rules {
+		if err := w.check(v.Path, rv, r); err != nil {
+			return w.redact(v.Path, err, fmt.Sprint(rv.Interface()))
+		}
+	}
+
+	return nil
+}
+
+func (w *Walker) check(path []tag.TagMap, rv reflect.Value, r rule) error {
+	name := w.memberName(path)
+
+	if fn, ok := w.Validators[r.name]; ok {
+		if err := fn(rv.Interface()); err != nil {
+			return fmt.Errorf("%w: %s: %w", errors.ErrValidation, name, err)
+		}
+
+		return nil
+	}
+
+	switch r.name {
+	case "min", "max":
+		return w.checkBound(name, rv, r)
+	case "len":
+		n, err := strconv.Atoi(r.arg)
+		if err != nil || !hasLen(rv) {
+			return fmt.Errorf("%w: %s: %s=%s", errors.ErrInvalidRule, name, r.name, r.arg)
+		}
+
+		if rv.Len() != n {
+			return fmt.Errorf("%w: %s must have a length of %d", errors.ErrValidation, name, n)
+		}
+
+		return nil
+	}
+
+	format, ok := formats[r.name]
+	if !ok {
+		return fmt.Errorf("%w: %s: unknown rule %q", errors.ErrInvalidRule, name, r.name)
+	}
+
+	if r.name == "pattern" {
+		if _, err := regexp.Compile(r.arg); err != nil {
+			return fmt.Errorf("%w: %s: %w", errors.ErrInvalidRule, name, err)
+		}
+	}
+
+	// format rules apply to each element of slices and arrays.
+	values := []reflect.Value{rv}
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+		values = values[:0]
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, rv.Index(i))
+		}
+	}
+
+	for _, ev := range values {
+		s := fmt.Sprint(ev.Interface())
+		if !format.valid(s, r.arg) {
+			err := fmt.Errorf("%w: %s must be %s, got %q", errors.ErrValidation, name, format.describe(r.arg), s)
+			return w.redact(path, err, s)
+		}
+	}
+
+	return nil
+}
+
+// checkBound compares numbers with the bound, parsed like the field itself
+// so that e.g. durations can be compared with min=1s, and strings,
+// slices and maps by their length.
+func (w *Walker) checkBound(name string, rv reflect.Value, r rule) error {
+	invalid := fmt.Errorf("%w: %s: %s=%s", errors.ErrInvalidRule, name, r.name, r.arg)
+
+	if hasLen(rv) {
+		n, err := strconv.Atoi(r.arg)
+		if err != nil {
+			return invalid
+		}
+
+		if r.name == "min" && rv.Len() < n {
+			return fmt.Errorf("%w: %s must have a length of at least %d", errors.ErrValidation, name, n)
+		}
+
+		if r.name == "max" && rv.Len() > n {
+			return fmt.Errorf("%w: %s must have a length of at most %d", errors.ErrValidation, name, n)
+		}
+
+		return nil
+	}
+
+	bound := &Value{Value: reflect.New(rv.Type()).Elem()}
+	if err := w.parse(bound, r.arg, false); err != nil || !bound.IsSet {
+		return invalid
+	}
+
+	cmp, ok := compare(rv, bound.Value)
+	if !ok {
+		return invalid
+	}
+
+	if r.name == "min" && cmp < 0 {
+		return fmt.Errorf("%w: %s must be at least %s", errors.ErrValidation, name, r.arg)
+	}
+
+	if r.name == "max" && cmp > 0 {
+		return fmt.Errorf("%w: %s must be at most %s", errors.ErrValidation, name, r.arg)
+	}
+
+	return nil
+}
+
+func hasLen(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+
+	return false
+}
+
+// compare returns -1, 0 or 1 as a is less than, equal to or greater than b.
+func compare(a, b reflect.Value) (int, bool) {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmpOrdered(a.Int(), b.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmpOrdered(a.Uint(), b.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return cmpOrdered(a.Float(), b.Float()), true
+	}
+
+	return 0, false
+}
+
+func cmpOrdered[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// validator is implemented by structs with cross-field validation.
+type validator interface {
+	Validate() error
+}
+
+// callValidate calls the Validate method of v and every struct reachable
+// from it through exported fields, pointers, slices, arrays and maps,
+// nested structs first.
+func (w *Walker) callValidate(v reflect.Value) error {
+	if w.DisableValidate {
+		return nil
+	}
+
+	return w.callValidateValue(v, nil, map[uintptr]bool{})
+}
+
+func (w *Walker) callValidateValue(v reflect.Value, path []string, seen map[uintptr]bool) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return nil
+		}
+
+		seen[v.Pointer()] = true
+
+		return w.callValidateValue(v.Elem(), path, seen)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.callValidateValue(v.Index(i), append(path, strconv.Itoa(i)), seen); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map values aren't addressable, validate a copy.
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+
+			if err := w.callValidateValue(elem, append(path, fmt.Sprint(iter.Key().Interface())), seen); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		rf := v.Type().Field(i)
+		if !rf.IsExported() {
+			continue
+		}
+
+		if err := w.callValidateValue(v.Field(i), append(path, rf.Name), seen); err != nil {
+			return err
+		}
+	}
+
+	if !v.CanAddr() {
+		return nil
+	}
+
+	val, ok := v.Addr().Interface().(validator)
+	if !ok {
+		return nil
+	}
+
+	if err := val.Validate(); err != nil {
+		err = validateError(path, err)
+		if !w.collectErrors() {
+			return err
+		}
+
+		w.addError(err)
+	}
+
+	return nil
+}
+
+func validateError(path []string, err error) error {
+	if len(path) == 0 {
+		return fmt.Errorf("%w: %w", errors.ErrValidation, err)
+	}
+
+	return fmt.Errorf("%w: %s: %w", errors.ErrValidation, strings.Join(path, "."), err)
+}
+
+type format struct {
+	valid    func(s, arg string) bool
+	describe func(arg string) string
+}
+
+func describe(s string) func(string) string {
+	return func(string) string { return s }
+}
+
+var formats = map[string]format{
+	"oneof": {
+		valid: func(s, arg string) bool {
+			return slices.Contains(strings.Fields(arg), s)
+		},
+		describe: func(arg string) string {
+			return "one of " + strings.Join(strings.Fields(arg), ", ")
+		},
+	},
+	"pattern": {
+		valid: func(s, arg string) bool {
+			return regexp.MustCompile(arg).MatchString(s)
+		},
+		describe: func(arg string) string {
+			return "matching " + arg
+		},
+	},
+	"url": {
+		valid: func(s, _ string) bool {
+			u, err := url.Parse(s)
+			return err == nil && u.Scheme != "" && u.Host != ""
+		},
+		describe: describe("a URL"),
+	},
+	"email": {
+		valid: func(s, _ string) bool {
+			addr, err := mail.ParseAddress(s)
+			return err == nil && addr.Address == s
+		},
+		describe: describe("an email address"),
+	},
+	"ipv4": {
+		valid: func(s, _ string) bool {
+			ip := net.ParseIP(s)
+			return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+		},
+		describe: describe("an IPv4 address"),
+	},
+	"port": {
+		valid: func(s, _ string) bool {
+			port, err := strconv.Atoi(s)
+			return err == nil && port > 0 && port <= 65535
+		},
+		describe: describe("a port between 1 and 65535"),
+	},
+}
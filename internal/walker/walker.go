@@ -1,15 +1,18 @@
 package walker
 
 import (
+	stderrors "errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/sethpollack/envcfg/errors"
 	"github.com/sethpollack/envcfg/internal/decoder"
 	"github.com/sethpollack/envcfg/internal/matcher"
 	"github.com/sethpollack/envcfg/internal/parser"
-	"github.com/sethpollack/envcfg/internal/tag"
+	"github.com/sethpollack/envcfg/tag"
 )
 
 type Value struct {
@@ -17,8 +20,35 @@ type Value struct {
 	IsSet     bool
 	IsDefault bool
 	Path      []tag.TagMap
+
+	// unset is true when the value was zeroed by the UnsetToken.
+	unset bool
 }
 
+// SliceGaps decides how indexed slice elements after a missing index,
+// e.g. SERVERS_2 without SERVERS_1, are handled.
+type SliceGaps int
+
+const (
+	// SliceGapsStop stops at the first missing index.
+	SliceGapsStop SliceGaps = iota
+	// SliceGapsSkip skips missing indexes, compacting the elements.
+	SliceGapsSkip
+	// SliceGapsError fails on missing indexes.
+	SliceGapsError
+)
+
+// MapMode decides how variables are applied to a map that already has
+// entries before parsing.
+type MapMode int
+
+const (
+	// MapMerge sets the entries of the variables, keeping the other entries.
+	MapMerge MapMode = iota
+	// MapReplace replaces the map with the entries of the variables.
+	MapReplace
+)
+
 type InitMode int
 
 const (
@@ -28,6 +58,39 @@ const (
 	InitNever
 )
 
+// Matcher resolves the environment variable values of field paths.
+type Matcher interface {
+	// GetValue returns the value of the path, whether it was set
+	// by an environment variable, and whether it is a default value.
+	GetValue(path []tag.TagMap) (string, bool, bool, error)
+	// HasPrefix reports whether any environment variable starts with the path.
+	HasPrefix(path []tag.TagMap) bool
+	// GetMapKeys returns the map keys found for the path of a map field.
+	GetMapKeys(path []tag.TagMap) []string
+}
+
+// RemainMatcher is implemented by matchers that support remain fields.
+type RemainMatcher interface {
+	// Remain returns the variables under the prefix of the struct path
+	// that were not matched by any field, keyed by the name after the prefix.
+	// When claim is true, the returned variables are marked as matched.
+	Remain(path []tag.TagMap, claim bool) map[string]string
+}
+
+// IndexMatcher is implemented by matchers that list the indexes of the
+// elements of a slice, which is required to skip or report missing indexes.
+type IndexMatcher interface {
+	// SliceIndexes returns the sorted indexes of the elements of the slice path.
+	SliceIndexes(path []tag.TagMap) []int
+}
+
+// Parser converts environment variable values to Go values.
+type Parser interface {
+	ParseType(rt reflect.Type, value string) (any, bool, error)
+	ParseKind(k reflect.Kind, value string) (any, bool, error)
+	HasParser(rt reflect.Type) bool
+}
+
 type Walker struct {
 	TagName        string
 	DelimTag       string
@@ -36,26 +99,129 @@ type Walker struct {
 	DefaultSep     string
 	InitTag        string
 	InitMode       InitMode
+	MapModeTag     string
+	MapMode        MapMode
 	IgnoreTag      string
 	DecodeUnsetTag string
 	DecodeUnset    bool
-
-	Parser  *parser.Parser
-	Matcher *matcher.Matcher
+	SkipUnlessTag  string
+	// SubDelimTag is the delimiter of the elements of nested slices in
+	// delimited values, e.g. 1;2,3;4 with the defaults is [[1 2] [3 4]].
+	SubDelimTag     string
+	DefaultSubDelim string
+	// RemainTag marks a map[string]string field that collects the variables
+	// under the struct prefix that no other field matched.
+	RemainTag string
+	// GroupTag names the group of a field, GroupRequiredTag requires that
+	// at least one field of the group is set, e.g. group:"auth" grouprequired:"one".
+	GroupTag         string
+	GroupRequiredTag string
+	// RequiredIfTag requires a field when a sibling field has a value,
+	// e.g. required_if:"TLSEnabled true", RequiredWithTag when any of the
+	// sibling fields is non-zero, e.g. required_with:"CertFile KeyFile".
+	RequiredIfTag   string
+	RequiredWithTag string
+	// ValidateTag holds the validation rules of a field, checked after
+	// it is parsed, e.g. validate:"min=1,max=10".
+	ValidateTag string
+	// Validators are custom validation rules, referenced by name in the
+	// validate tag, e.g. validate:"region". They take precedence over
+	// the built-in rules of the same name.
+	Validators map[string]func(any) error
+	// DisableValidate prevents calling the Validate() error method of the
+	// walked structs.
+	DisableValidate bool
+	// RequireExplicitTags rejects fields without an explicit env tag name.
+	RequireExplicitTags bool
+	// TrimSpace trims surrounding whitespace from values before they are
+	// parsed into non-string types, such as numbers and bools.
+	TrimSpace bool
+	// Mask replaces the values of sensitive fields in errors.
+	Mask string
+	// KeepNonZeroDefaults prevents default values from overwriting
+	// values that are already set.
+	KeepNonZeroDefaults bool
+	// Implementations are the concrete types of interface fields, by
+	// interface type and name, see walkInterface.
+	Implementations map[reflect.Type]map[string]func() any
+	// EncodingTag names the encodings of a value, which is decoded before
+	// it is parsed, e.g. encoding:"base64", or unmarshaled into the field,
+	// e.g. encoding:"json". Base64Tag is short for encoding:"base64".
+	EncodingTag string
+	Base64Tag   string
+	// MaxDepth, when not 0, is the deepest field path that is walked,
+	// deeper fields fail with errors.ErrMaxDepth.
+	MaxDepth int
+	// NoOverride leaves fields that have a non-zero value before walking
+	// untouched, KeepTag does so for a single field, e.g. keep:"true".
+	NoOverride bool
+	KeepTag    string
+	// MaxErrors enables collecting field errors instead of failing fast,
+	// errors after the first MaxErrors are only counted.
+	MaxErrors int
+	// CollectErrors collects all field errors, without a limit.
+	CollectErrors bool
+	// SliceGaps decides how missing indexes of indexed slices are handled,
+	// MaxSliceIndex, when not 0, is the highest index that is looked up.
+	SliceGaps     SliceGaps
+	MaxSliceIndex int
+	// UnsetToken is a value that zeroes a field, suppressing its default,
+	// e.g. "__UNSET__". Unset fields are set, but not validated.
+	UnsetToken string
+
+	errs    []error
+	dropped int
+
+	groups     map[string]*group
+	groupOrder []string
+
+	remains []remainField
+
+	Parser  Parser
+	Matcher Matcher
 	Decoder *decoder.Decoder
 }
 
+// remainField is a remain map that is filled once all other fields are matched.
+type remainField struct {
+	m    reflect.Value
+	path []tag.TagMap
+}
+
+type group struct {
+	name     string
+	members  []string
+	required bool
+	set      bool
+}
+
 func New() *Walker {
 	return &Walker{
-		TagName:        "env",
-		DelimTag:       "delim",
-		DefaultDelim:   ",",
-		SepTag:         "sep",
-		DefaultSep:     ":",
-		InitTag:        "init",
-		IgnoreTag:      "ignore",
-		DecodeUnsetTag: "decodeunset",
-		InitMode:       InitVars,
+		TagName:          "env",
+		DelimTag:         "delim",
+		DefaultDelim:     ",",
+		SubDelimTag:      "subdelim",
+		DefaultSubDelim:  ";",
+		SepTag:           "sep",
+		DefaultSep:       ":",
+		InitTag:          "init",
+		MapModeTag:       "mapmode",
+		KeepTag:          "keep",
+		EncodingTag:      "encoding",
+		Base64Tag:        "base64",
+		IgnoreTag:        "ignore",
+		DecodeUnsetTag:   "decodeunset",
+		SkipUnlessTag:    "skipUnless",
+		RemainTag:        "remain",
+		GroupTag:         "group",
+		GroupRequiredTag: "grouprequired",
+		RequiredIfTag:    "required_if",
+		RequiredWithTag:  "required_with",
+		ValidateTag:      "validate",
+		InitMode:         InitVars,
+		Mask:             "******",
+		Validators:       map[string]func(any) error{},
+		Implementations:  map[reflect.Type]map[string]func() any{},
 
 		Parser:  parser.New(),
 		Matcher: matcher.New(),
@@ -66,24 +232,95 @@ func New() *Walker {
 func (w *Walker) Walk(v any) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr {
-		return fmt.Errorf("%w: expected a pointer to a struct, got %T", errors.ErrNotAPointer, v)
+		return fmt.Errorf("%w: expected a pointer to a struct, slice or map, got %T", errors.ErrNotAPointer, v)
 	}
 
+	w.errs, w.dropped = nil, 0
+	w.groups, w.groupOrder = nil, nil
+	w.remains = nil
+
 	elem := rv.Elem()
-	if elem.Kind() != reflect.Struct {
-		return fmt.Errorf("%w: expected a pointer to a struct, got %T", errors.ErrNotAPointer, v)
+
+	switch elem.Kind() {
+	case reflect.Struct:
+		if err := w.walkStruct(&Value{
+			Value: elem,
+			Path:  []tag.TagMap{},
+		}); err != nil {
+			return err
+		}
+	case reflect.Slice, reflect.Map:
+		// top level slices and maps are rooted at an unnamed
+		// element so that their keys are matched without a prefix.
+		root := tag.TagMap{
+			Type: elem.Type(),
+			Tags: map[string]tag.Tag{w.TagName: {Name: w.TagName}},
+		}
+
+		if err := w.visit(&Value{
+			Value: elem,
+			Path:  []tag.TagMap{root},
+		}); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: expected a pointer to a struct, slice or map, got %T", errors.ErrNotAPointer, v)
 	}
 
-	return w.walkStruct(&Value{
-		Value: elem,
-		Path:  []tag.TagMap{},
-	})
+	w.fillRemains()
+
+	if err := w.checkGroups(); err != nil {
+		return err
+	}
+
+	if err := w.callValidate(elem); err != nil {
+		return err
+	}
+
+	return w.joinErrors()
+}
+
+func (w *Walker) collectErrors() bool {
+	return w.CollectErrors || w.MaxErrors > 0
 }
 
-func (w *Walker) visit(v *Value) error {
+// addError collects err, errors after the first MaxErrors are only counted.
+func (w *Walker) addError(err error) {
+	if w.MaxErrors > 0 && len(w.errs) >= w.MaxErrors {
+		w.dropped++
+		return
+	}
+
+	w.errs = append(w.errs, err)
+}
+
+func (w *Walker) joinErrors() error {
+	if len(w.errs) == 0 {
+		return nil
+	}
+
+	if w.dropped > 0 {
+		errs := append(w.errs, fmt.Errorf("%w: ... and %d more", errors.ErrTooManyErrors, w.dropped))
+		return stderrors.Join(errs...)
+	}
+
+	return stderrors.Join(w.errs...)
+}
+
+func (w *Walker) visit(v *Value) (err error) {
+	if w.MaxDepth > 0 && len(v.Path) > w.MaxDepth {
+		return fmt.Errorf("%w: %d at %s", errors.ErrMaxDepth, w.MaxDepth, pathName(v.Path))
+	}
+
 	if isNilPtr(v) {
 		initMode := w.initMode(v.Path)
 
+		// pointers to the type of a parent, e.g. the *Node fields of a
+		// Node, are only followed while variables have their prefix.
+		if recursive(v.Path[:len(v.Path)-1], v.Type().Elem()) && !w.Matcher.HasPrefix(v.Path) {
+			return nil
+		}
+
 		tmp := &Value{
 			Value: reflect.New(v.Type().Elem()).Elem(),
 			Path:  v.Path,
@@ -97,6 +334,12 @@ func (w *Walker) visit(v *Value) error {
 			return err
 		}
 
+		// an unset pointer stays nil.
+		if tmp.unset {
+			v.IsSet, v.unset = true, true
+			return nil
+		}
+
 		if tmp.Kind() == reflect.Struct {
 			if initMode == InitVars && !tmp.IsSet {
 				return nil
@@ -118,11 +361,74 @@ func (w *Walker) visit(v *Value) error {
 		return nil
 	}
 
+	// values behind pointers that are already set, e.g. by a config
+	// variable, are walked in place so that variables still override them.
+	// Maps are always walked in place, since their entries are merged.
+	if isPtr(v) && !w.hasParserOrSetter(v) && (w.KeepNonZeroDefaults || v.Type().Elem().Kind() == reflect.Map) {
+		elem := &Value{Value: v.Elem(), Path: v.Path}
+		err := w.visit(elem)
+
+		v.IsSet = elem.IsSet
+		v.IsDefault = elem.IsDefault
+
+		if elem.unset {
+			v.Set(reflect.Zero(v.Type()))
+			v.unset = true
+		}
+
+		return err
+	}
+
+	if w.keep(v) {
+		// the variables of kept fields are still looked up, so that
+		// they are not reported as unused.
+		_, _, _, _ = w.Matcher.GetValue(v.Path)
+		return nil
+	}
+
 	value, isSet, isDefault, err := w.Matcher.GetValue(v.Path)
 	if err != nil {
+		// values that are already set, e.g. decoded from a config
+		// variable, satisfy required fields.
+		if stderrors.Is(err, errors.ErrRequired) && w.KeepNonZeroDefaults && !v.IsZero() {
+			return nil
+		}
+
 		return err
 	}
 
+	defer func() { err = w.redact(v.Path, err, value) }()
+
+	if isSet && w.UnsetToken != "" && value == w.UnsetToken {
+		v.Set(reflect.Zero(v.Type()))
+		v.IsSet, v.unset = true, true
+
+		return nil
+	}
+
+	if isDefault && w.KeepNonZeroDefaults && !v.IsZero() {
+		return nil
+	}
+
+	if encodings := w.encodings(v.Path); len(encodings) > 0 && (isSet || isDefault) {
+		var encoding string
+		if value, encoding, err = decodeValue(encodings, value); err != nil {
+			return err
+		}
+
+		if encoding != "" || w.isBytes(v) {
+			if err := w.unmarshal(v, encoding, value, isDefault); err != nil {
+				return err
+			}
+
+			if encoding != "" && v.Kind() == reflect.Struct {
+				return w.walkDecoded(v)
+			}
+
+			return nil
+		}
+	}
+
 	if w.hasParserOrSetter(v) {
 		if (!isSet && !isDefault) && !w.decodeUnset(v.Path) {
 			return nil
@@ -144,61 +450,258 @@ func (w *Walker) visit(v *Value) error {
 	case reflect.Struct:
 		return w.walkStruct(v)
 	case reflect.Slice:
-		return w.walkSlice(v)
+		if err := w.walkSlice(v); err != nil {
+			return err
+		}
+
+		w.initEmpty(v)
 	case reflect.Map:
-		return w.walkMap(v)
+		if err := w.walkMap(v); err != nil {
+			return err
+		}
+
+		w.initEmpty(v)
+	case reflect.Interface:
+		return w.walkInterface(v, value, isSet, isDefault)
 	}
 
 	return nil
 }
 
+// walkInterface sets an interface field to one of the registered
+// implementations of its type, named by the value of the field, e.g.
+// STORAGE=s3, and walks it under the field prefix, e.g. STORAGE_BUCKET.
+// Without a name, a single implementation is used when any of its
+// fields are set.
+func (w *Walker) walkInterface(v *Value, name string, isSet, isDefault bool) error {
+	impls := w.Implementations[v.Type()]
+	if len(impls) == 0 {
+		return nil
+	}
+
+	newImpl, ok := impls[name]
+	if name == "" && len(impls) == 1 {
+		for _, fn := range impls {
+			newImpl, ok = fn, true
+		}
+	}
+
+	if !ok {
+		if name == "" {
+			return nil
+		}
+
+		return fmt.Errorf("%w: %q for %s", errors.ErrUnknownImplementation, name, v.Type())
+	}
+
+	impl := reflect.ValueOf(newImpl())
+	if !impl.IsValid() || !impl.Type().Implements(v.Type()) {
+		return fmt.Errorf("%w: %q for %s: %s does not implement it", errors.ErrUnknownImplementation, name, v.Type(), impl.Kind())
+	}
+
+	if impl.Kind() == reflect.Ptr && impl.IsNil() {
+		impl = reflect.New(impl.Type().Elem())
+	}
+
+	// values are copied so that their fields can be set.
+	if impl.Kind() != reflect.Ptr {
+		addressable := reflect.New(impl.Type()).Elem()
+		addressable.Set(impl)
+		impl = addressable
+	}
+
+	elem := &Value{Value: impl, Path: v.Path}
+	if impl.Kind() == reflect.Ptr {
+		elem.Value = impl.Elem()
+	}
+
+	if err := w.visit(elem); err != nil {
+		return err
+	}
+
+	if name == "" && !elem.IsSet {
+		return nil
+	}
+
+	v.Set(impl)
+	v.IsSet = isSet || elem.IsSet
+	v.IsDefault = !v.IsSet && (isDefault || elem.IsDefault)
+
+	return nil
+}
+
 func (w *Walker) walkStruct(v *Value) error {
 	rt := v.Type()
+
+	// fields with a skip or required condition are visited last so
+	// that the sibling fields they depend on are already populated.
+	var conditional []int
+
+	// remain fields are visited after all others to collect
+	// the variables their siblings did not match.
+	var remain []int
+
 	// Iterate over each field in the struct.
 	for i := 0; i < rt.NumField(); i++ {
-		rf := v.Field(i)
+		if w.remain(rt.Field(i)) {
+			remain = append(remain, i)
+			continue
+		}
 
-		if !rf.CanSet() {
-			continue // Skip unexported fields that cannot be set.
+		if w.conditional(rt.Field(i)) {
+			conditional = append(conditional, i)
+			continue
 		}
 
-		fieldPath := append(v.Path, tag.ParseTags(rt.Field(i)))
+		if err := w.walkField(v, i); err != nil {
+			return err
+		}
+	}
 
-		if w.ignore(fieldPath) {
+	for _, i := range conditional {
+		if cond, ok := w.skipUnless(rt.Field(i)); ok && !conditionMet(v, cond) {
 			continue
 		}
 
-		child := &Value{Value: rf, Path: fieldPath}
+		if err := w.walkField(v, i); err != nil {
+			return err
+		}
+	}
 
-		err := w.visit(child)
-		if err != nil {
+	for _, i := range remain {
+		if err := w.walkRemain(v, i); err != nil {
 			return err
 		}
+	}
 
-		if child.IsSet {
-			v.IsSet = true
-			v.IsDefault = false
-		} else if child.IsDefault && !v.IsSet {
-			v.IsDefault = true
+	return nil
+}
+
+// walkRemain sets a remain field to an empty map when variables may remain
+// under the struct prefix, the map is filled by fillRemains once the whole
+// tree is matched so that fields walked later can still claim variables.
+func (w *Walker) walkRemain(v *Value, i int) error {
+	rf := v.Field(i)
+
+	if !rf.CanSet() {
+		return nil
+	}
+
+	rt := rf.Type()
+	if rt.Kind() != reflect.Map || rt.Key().Kind() != reflect.String || rt.Elem().Kind() != reflect.String {
+		return fmt.Errorf("%w: %s must be a map[string]string, got %s", errors.ErrInvalidRemain, v.Type().Field(i).Name, rt)
+	}
+
+	rm, ok := w.Matcher.(RemainMatcher)
+	if !ok {
+		return fmt.Errorf("%w: %s: the matcher does not support remain fields", errors.ErrInvalidRemain, v.Type().Field(i).Name)
+	}
+
+	if len(rm.Remain(v.Path, false)) == 0 {
+		return nil
+	}
+
+	// maps are references, so copies of the struct made while
+	// walking, e.g. into slices or pointers, share the filled map.
+	m := reflect.MakeMap(rt)
+	rf.Set(m)
+
+	w.remains = append(w.remains, remainField{m: m, path: v.Path})
+
+	v.IsSet = true
+	v.IsDefault = false
+
+	return nil
+}
+
+// fillRemains fills the remain maps, innermost structs first.
+func (w *Walker) fillRemains() {
+	for _, r := range w.remains {
+		values := w.Matcher.(RemainMatcher).Remain(r.path, true)
+
+		for key, value := range values {
+			r.m.SetMapIndex(reflect.ValueOf(key).Convert(r.m.Type().Key()), reflect.ValueOf(value).Convert(r.m.Type().Elem()))
 		}
 	}
+}
+
+func (w *Walker) walkField(v *Value, i int) error {
+	rf := v.Field(i)
+
+	if !rf.CanSet() {
+		return nil // Skip unexported fields that cannot be set.
+	}
+
+	fieldPath := append(v.Path, tag.ParseTags(v.Type().Field(i)))
+
+	if w.ignore(fieldPath) {
+		return nil
+	}
+
+	child := &Value{Value: rf, Path: fieldPath}
+
+	err := w.checkExplicitTag(fieldPath)
+	if err == nil {
+		err = w.visit(child)
+	}
+
+	if err == nil {
+		err = w.checkRequiredIf(v, child)
+	}
+
+	if err == nil {
+		err = w.validate(child)
+	}
+
+	if err != nil {
+		if !w.collectErrors() {
+			return err
+		}
+
+		w.addError(err)
+
+		return nil
+	}
+
+	w.trackGroup(child)
+
+	if child.IsSet {
+		v.IsSet = true
+		v.IsDefault = false
+	} else if child.IsDefault && !v.IsSet {
+		v.IsDefault = true
+	}
 
 	return nil
 }
 
 func (w *Walker) walkDelimitedSlice(v *Value, value string, isDefault bool) error {
-	delim := w.delimiter(v.Path)
+	return w.splitSlice(v, value, w.delimiter(v.Path), w.subDelimiter(v.Path), isDefault)
+}
 
+// splitSlice sets the elements of a slice from a value split by delim,
+// elements that are slices themselves are split by subdelim.
+func (w *Walker) splitSlice(v *Value, value, delim, subdelim string, isDefault bool) error {
 	elemType := v.Type().Elem()
 
+	// variables replace the elements of values that are already set,
+	// e.g. by a config variable, instead of appending to them.
+	if w.KeepNonZeroDefaults {
+		v.Set(reflect.Zero(v.Type()))
+	}
+
 	for _, part := range strings.Split(value, delim) {
 		elemValue := &Value{
 			Value: reflect.New(elemType).Elem(),
 			Path:  v.Path,
 		}
 
-		if err := w.parse(elemValue, part, isDefault); err != nil {
-			return err
+		if subdelim != "" && elemType.Kind() == reflect.Slice && !w.hasParserOrSetter(elemValue) {
+			if err := w.splitSlice(elemValue, part, subdelim, "", isDefault); err != nil {
+				return err
+			}
+		} else if err := w.parse(elemValue, part, isDefault); err != nil {
+			return w.redact(v.Path, err, part)
 		}
 
 		appendSlice(v, elemValue)
@@ -208,21 +711,22 @@ func (w *Walker) walkDelimitedSlice(v *Value, value string, isDefault bool) erro
 }
 
 func (w *Walker) walkSlice(v *Value) error {
-	for i := 0; ; i++ {
-		elemPath := append(v.Path, tag.TagMap{
-			FieldName: fmt.Sprintf("%d", i),
-			Tags: map[string]tag.Tag{
-				w.TagName: {Value: fmt.Sprintf("%d", i)},
-			},
-		})
+	// indexed variables replace the elements of values that are already
+	// set, e.g. by a config variable, instead of appending to them.
+	elems := v
+	if w.KeepNonZeroDefaults {
+		elems = &Value{Value: reflect.New(v.Type()).Elem(), Path: v.Path}
+	}
 
-		if !w.Matcher.HasPrefix(elemPath) {
-			return nil
-		}
+	indexes, err := w.sliceIndexes(v.Path)
+	if err != nil {
+		return err
+	}
 
+	for _, i := range indexes {
 		elemValue := &Value{
 			Value: reflect.New(v.Type().Elem()).Elem(),
-			Path:  elemPath,
+			Path:  w.elemPath(v.Path, i),
 		}
 
 		err := w.visit(elemValue)
@@ -230,11 +734,69 @@ func (w *Walker) walkSlice(v *Value) error {
 			return err
 		}
 
-		appendSlice(v, elemValue)
+		// defaults apply to the fields of elements constructed from
+		// variables, elements with only default values are skipped.
+		if !elemValue.IsSet {
+			continue
+		}
+
+		appendSlice(elems, elemValue)
+	}
+
+	if elems != v && elems.Len() > 0 {
+		v.Set(elems.Value)
+		v.IsSet = elems.IsSet
+		v.IsDefault = elems.IsDefault
+	}
+
+	return nil
+}
+
+// sliceIndexes returns the indexes of the elements of the slice path,
+// according to the SliceGaps policy.
+func (w *Walker) sliceIndexes(path []tag.TagMap) ([]int, error) {
+	im, ok := w.Matcher.(IndexMatcher)
+	if w.SliceGaps == SliceGapsStop || !ok {
+		var indexes []int
+		for i := 0; w.MaxSliceIndex == 0 || i <= w.MaxSliceIndex; i++ {
+			if !w.Matcher.HasPrefix(w.elemPath(path, i)) {
+				break
+			}
+
+			indexes = append(indexes, i)
+		}
+
+		return indexes, nil
+	}
+
+	indexes := im.SliceIndexes(path)
+	if w.MaxSliceIndex > 0 {
+		indexes = slices.DeleteFunc(indexes, func(i int) bool { return i > w.MaxSliceIndex })
+	}
+
+	if w.SliceGaps == SliceGapsError {
+		for want, i := range indexes {
+			if i != want {
+				return nil, fmt.Errorf("%w: %s: missing index %d", errors.ErrSliceGap, w.memberName(path), want)
+			}
+		}
 	}
+
+	return indexes, nil
+}
+
+// elemPath returns the path of the element of the slice path at index i.
+func (w *Walker) elemPath(path []tag.TagMap, i int) []tag.TagMap {
+	return append(path[:len(path):len(path)], tag.TagMap{
+		FieldName: strconv.Itoa(i),
+		Tags: map[string]tag.Tag{
+			w.TagName: {Value: strconv.Itoa(i)},
+		},
+	})
 }
 
 func (w *Walker) walkDelimitedMap(v *Value, value string, isDefault bool) error {
+	target := w.mapTarget(v)
 	mapType := v.Type()
 	elemType := mapType.Elem()
 	keyType := mapType.Key()
@@ -247,7 +809,7 @@ func (w *Walker) walkDelimitedMap(v *Value, value string, isDefault bool) error
 	for _, part := range parts {
 		kv := strings.SplitN(part, sep, 2)
 		if len(kv) != 2 {
-			return fmt.Errorf("%w: expected key and value to be separated by %q, got %q", errors.ErrInvalidMapValue, sep, part)
+			return w.redact(v.Path, fmt.Errorf("%w: expected key and value to be separated by %q, got %q", errors.ErrInvalidMapValue, sep, part), part)
 		}
 
 		keyValue := &Value{
@@ -256,7 +818,7 @@ func (w *Walker) walkDelimitedMap(v *Value, value string, isDefault bool) error
 		}
 
 		if err := w.parse(keyValue, kv[0], isDefault); err != nil {
-			return err
+			return w.redact(v.Path, fmt.Errorf("%w: %q: %w", errors.ErrInvalidMapKey, kv[0], err), kv[0])
 		}
 
 		elemValue := &Value{
@@ -265,12 +827,14 @@ func (w *Walker) walkDelimitedMap(v *Value, value string, isDefault bool) error
 		}
 
 		if err := w.parse(elemValue, kv[1], isDefault); err != nil {
-			return err
+			return w.redact(v.Path, err, kv[1])
 		}
 
-		setMapIndex(v, keyValue, elemValue)
+		setMapIndex(target, keyValue, elemValue)
 	}
 
+	replaceMap(v, target)
+
 	return nil
 }
 
@@ -283,6 +847,8 @@ func (w *Walker) walkMap(v *Value) error {
 		return nil
 	}
 
+	target := w.mapTarget(v)
+
 	for _, key := range keys {
 		newKey := &Value{
 			Value: reflect.New(keyType).Elem(),
@@ -290,11 +856,12 @@ func (w *Walker) walkMap(v *Value) error {
 		}
 
 		if err := w.parse(newKey, key, false); err != nil {
-			return err
+			return fmt.Errorf("%w: %q: %w", errors.ErrInvalidMapKey, key, err)
 		}
 
 		valuePath := append(v.Path, tag.TagMap{
 			FieldName: key,
+			Type:      elemType,
 			Tags:      map[string]tag.Tag{w.TagName: {Value: key}},
 		})
 
@@ -307,12 +874,35 @@ func (w *Walker) walkMap(v *Value) error {
 			return err
 		}
 
-		setMapIndex(v, newKey, newValue)
+		setMapIndex(target, newKey, newValue)
 	}
 
+	replaceMap(v, target)
+
 	return nil
 }
 
+// mapTarget returns the value the entries of a map are set on, v itself
+// when merging, or a new map that replaces v when replacing.
+func (w *Walker) mapTarget(v *Value) *Value {
+	if w.mapMode(v.Path) == MapReplace {
+		return &Value{Value: reflect.New(v.Type()).Elem(), Path: v.Path}
+	}
+
+	return v
+}
+
+// replaceMap replaces v with the map of mapTarget, if it has entries.
+func replaceMap(v, target *Value) {
+	if target == v || target.Len() == 0 {
+		return
+	}
+
+	v.Set(target.Value)
+	v.IsSet = target.IsSet
+	v.IsDefault = target.IsDefault
+}
+
 func (w *Walker) hasParserOrSetter(v *Value) bool {
 	if dec := w.Decoder.ToDecoder(reflect.New(v.Type()).Elem()); dec != nil {
 		return true
@@ -348,6 +938,10 @@ func (w *Walker) parse(v *Value, value string, isDefault bool) error {
 		nv = nv.Elem()
 	}
 
+	if w.TrimSpace && typ.Kind() != reflect.String {
+		value = strings.TrimSpace(value)
+	}
+
 	if newValue, found, err := w.Parser.ParseType(typ, value); found {
 		if err != nil {
 			return err
@@ -403,6 +997,21 @@ func (w *Walker) initTag(path []tag.TagMap) string {
 	return ""
 }
 
+// initEmpty sets nil slices and maps to empty ones with init:"always", so
+// that they are not encoded as null.
+func (w *Walker) initEmpty(v *Value) {
+	if !v.IsNil() || w.initMode(v.Path) != InitAlways {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+}
+
 func (w *Walker) initMode(path []tag.TagMap) InitMode {
 	switch w.initTag(path) {
 	case "always":
@@ -416,6 +1025,26 @@ func (w *Walker) initMode(path []tag.TagMap) InitMode {
 	}
 }
 
+func (w *Walker) mapMode(path []tag.TagMap) MapMode {
+	current := path[len(path)-1]
+
+	mode, ok := current.Tags[w.MapModeTag]
+	if !ok {
+		if tagName, ok := current.Tags[w.TagName]; ok {
+			mode.Value = tagName.Options[w.MapModeTag]
+		}
+	}
+
+	switch mode.Value {
+	case "merge":
+		return MapMerge
+	case "replace":
+		return MapReplace
+	default:
+		return w.MapMode
+	}
+}
+
 func (w *Walker) ignore(path []tag.TagMap) bool {
 	current := path[len(path)-1]
 
@@ -452,6 +1081,307 @@ func (w *Walker) decodeUnset(path []tag.TagMap) bool {
 	return w.DecodeUnset
 }
 
+// keep reports whether the value of a field is kept, because it is not
+// zero and overriding is disabled. Structs are not kept as a whole, their
+// fields are, and empty slices and maps are not kept.
+func (w *Walker) keep(v *Value) bool {
+	if !w.NoOverride && !w.keepTag(v.Path) {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return false
+	case reflect.Slice, reflect.Map:
+		return v.Len() > 0
+	default:
+		return !v.IsZero()
+	}
+}
+
+func (w *Walker) keepTag(path []tag.TagMap) bool {
+	current := path[len(path)-1]
+
+	if t, ok := current.Tags[w.KeepTag]; ok {
+		b, err := strconv.ParseBool(t.Value)
+		return t.Value == "" || (err == nil && b)
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		if _, ok := tagName.Options[w.KeepTag]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *Walker) checkExplicitTag(path []tag.TagMap) error {
+	if !w.RequireExplicitTags {
+		return nil
+	}
+
+	if t, ok := path[len(path)-1].Tags[w.TagName]; ok && t.Value != "" {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errors.ErrMissingTag, pathName(path))
+}
+
+// trackGroup records the field as a member of its group, if any.
+func (w *Walker) trackGroup(v *Value) {
+	current := v.Path[len(v.Path)-1]
+
+	name, required := "", false
+	if t, ok := current.Tags[w.GroupTag]; ok {
+		name = t.Value
+	}
+
+	if _, ok := current.Tags[w.GroupRequiredTag]; ok {
+		required = true
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		if value, ok := tagName.Options[w.GroupTag]; ok {
+			name = value
+		}
+
+		if _, ok := tagName.Options[w.GroupRequiredTag]; ok {
+			required = true
+		}
+	}
+
+	if name == "" {
+		return
+	}
+
+	if w.groups == nil {
+		w.groups = map[string]*group{}
+	}
+
+	g, ok := w.groups[name]
+	if !ok {
+		g = &group{name: name}
+		w.groups[name] = g
+		w.groupOrder = append(w.groupOrder, name)
+	}
+
+	g.members = append(g.members, w.memberName(v.Path))
+	g.required = g.required || required
+	g.set = g.set || v.IsSet
+}
+
+// checkGroups returns an error for every required group without a set member.
+func (w *Walker) checkGroups() error {
+	for _, name := range w.groupOrder {
+		g := w.groups[name]
+		if !g.required || g.set {
+			continue
+		}
+
+		err := fmt.Errorf("%w: %s requires one of %s", errors.ErrGroupRequired, g.name, strings.Join(g.members, ", "))
+		if !w.collectErrors() {
+			return err
+		}
+
+		w.addError(err)
+	}
+
+	return nil
+}
+
+// specMatcher is implemented by matchers that can name the primary
+// environment variable of a path, such as the default matcher.
+type specMatcher interface {
+	Spec(path []tag.TagMap) matcher.Spec
+}
+
+// sensitive reports whether the values of the path must not appear in
+// errors, custom matchers have no sensitive fields.
+func (w *Walker) sensitive(path []tag.TagMap) bool {
+	if sm, ok := w.Matcher.(specMatcher); ok {
+		return sm.Spec(path).Sensitive
+	}
+
+	return false
+}
+
+// redact replaces value in the message of err with the mask,
+// when the path is sensitive.
+func (w *Walker) redact(path []tag.TagMap, err error, value string) error {
+	if err == nil || value == "" || !w.sensitive(path) {
+		return err
+	}
+
+	return &redactedError{err: err, value: value, mask: w.Mask}
+}
+
+// redactedError masks a sensitive value in the message of the wrapped error.
+type redactedError struct {
+	err   error
+	value string
+	mask  string
+}
+
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.value, e.mask)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// memberName returns the environment variable of a group member,
+// falling back to the field path for custom matchers.
+func (w *Walker) memberName(path []tag.TagMap) string {
+	if sm, ok := w.Matcher.(specMatcher); ok {
+		return sm.Spec(path).Key
+	}
+
+	return pathName(path)
+}
+
+// recursive reports whether a type is the type of a path element, so that
+// walking it could recurse forever.
+func recursive(path []tag.TagMap, rt reflect.Type) bool {
+	for _, tm := range path {
+		t := tm.Type
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t == rt {
+			return true
+		}
+	}
+
+	return false
+}
+
+func pathName(path []tag.TagMap) string {
+	names := make([]string, 0, len(path))
+	for _, tm := range path {
+		names = append(names, tm.FieldName)
+	}
+
+	return strings.Join(names, ".")
+}
+
+// remain reports whether the field collects unmatched variables.
+func (w *Walker) remain(rf reflect.StructField) bool {
+	current := tag.ParseTags(rf)
+
+	if _, ok := current.Tags[w.RemainTag]; ok {
+		return true
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		if _, ok := tagName.Options[w.RemainTag]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipUnless returns the skip condition of the field, e.g. "TLSEnabled=true".
+func (w *Walker) skipUnless(rf reflect.StructField) (string, bool) {
+	current := tag.ParseTags(rf)
+
+	if t, ok := current.Tags[w.SkipUnlessTag]; ok {
+		return t.Value, true
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		if cond, ok := tagName.Options[w.SkipUnlessTag]; ok {
+			return cond, true
+		}
+	}
+
+	return "", false
+}
+
+// conditional reports whether the field depends on its sibling fields.
+func (w *Walker) conditional(rf reflect.StructField) bool {
+	if _, ok := w.skipUnless(rf); ok {
+		return true
+	}
+
+	current := tag.ParseTags(rf)
+
+	return w.tagValue(current, w.RequiredIfTag) != "" || w.tagValue(current, w.RequiredWithTag) != ""
+}
+
+// tagValue returns the value of the tag, or of the env tag option.
+func (w *Walker) tagValue(current tag.TagMap, name string) string {
+	if t, ok := current.Tags[name]; ok {
+		return t.Value
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		return tagName.Options[name]
+	}
+
+	return ""
+}
+
+// checkRequiredIf returns an error when the field is not set while its
+// required_if condition is met or any of its required_with fields is set.
+func (w *Walker) checkRequiredIf(parent, v *Value) error {
+	if v.IsSet || v.IsDefault {
+		return nil
+	}
+
+	current := v.Path[len(v.Path)-1]
+
+	if cond := w.tagValue(current, w.RequiredIfTag); cond != "" {
+		name, expected, hasExpected := strings.Cut(cond, " ")
+		if hasExpected {
+			cond = name + "=" + expected
+		}
+
+		if conditionMet(parent, cond) {
+			if hasExpected {
+				return fmt.Errorf("%w: %s is required when %s is %s", errors.ErrRequired, w.memberName(v.Path), name, expected)
+			}
+
+			return fmt.Errorf("%w: %s is required when %s is set", errors.ErrRequired, w.memberName(v.Path), name)
+		}
+	}
+
+	for _, name := range strings.Fields(w.tagValue(current, w.RequiredWithTag)) {
+		if conditionMet(parent, name) {
+			return fmt.Errorf("%w: %s is required with %s", errors.ErrRequired, w.memberName(v.Path), name)
+		}
+	}
+
+	return nil
+}
+
+// conditionMet reports whether the sibling field named in cond has the
+// expected value. Without an expected value, the field must be non-zero.
+func conditionMet(v *Value, cond string) bool {
+	name, expected, hasExpected := strings.Cut(cond, "=")
+
+	field := v.FieldByName(name)
+	if !field.IsValid() {
+		return false
+	}
+
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return false
+		}
+		field = field.Elem()
+	}
+
+	if !hasExpected {
+		return !field.IsZero()
+	}
+
+	return fmt.Sprint(field.Interface()) == expected
+}
+
 func (w *Walker) delimiter(path []tag.TagMap) string {
 	current := path[len(path)-1]
 
@@ -468,6 +1398,22 @@ func (w *Walker) delimiter(path []tag.TagMap) string {
 	return w.DefaultDelim
 }
 
+func (w *Walker) subDelimiter(path []tag.TagMap) string {
+	current := path[len(path)-1]
+
+	if d, ok := current.Tags[w.SubDelimTag]; ok {
+		return d.Value
+	}
+
+	if tagName, ok := current.Tags[w.TagName]; ok {
+		if delim, ok := tagName.Options[w.SubDelimTag]; ok {
+			return delim
+		}
+	}
+
+	return w.DefaultSubDelim
+}
+
 func (w *Walker) separator(path []tag.TagMap) string {
 	current := path[len(path)-1]
 
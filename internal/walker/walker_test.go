@@ -4,10 +4,12 @@ import (
 	"errors"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/internal/matcher"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,6 +40,48 @@ func TestWalk(t *testing.T) {
 			cfg:         new(string),
 			expectedErr: errs.ErrNotAPointer,
 		},
+		"error on pointer to unsupported kind": {
+			cfg:         new(int),
+			expectedErr: errs.ErrNotAPointer,
+		},
+		"top level map": {
+			env: map[string]string{
+				"PRIMARY":   "host1",
+				"SECONDARY": "host2",
+			},
+			expected: map[string]string{
+				"primary":   "host1",
+				"secondary": "host2",
+			},
+		},
+		"top level map of structs": {
+			env: map[string]string{
+				"PRIMARY_HOST":   "host1",
+				"PRIMARY_PORT":   "8080",
+				"SECONDARY_HOST": "host2",
+			},
+			expected: map[string]struct {
+				Host string
+				Port int
+			}{
+				"primary":   {Host: "host1", Port: 8080},
+				"secondary": {Host: "host2"},
+			},
+		},
+		"top level slice": {
+			env: map[string]string{
+				"0": "host1",
+				"1": "host2",
+			},
+			expected: []string{"host1", "host2"},
+		},
+		"top level slice of structs": {
+			env: map[string]string{
+				"0_HOST": "host1",
+				"1_HOST": "host2",
+			},
+			expected: []struct{ Host string }{{Host: "host1"}, {Host: "host2"}},
+		},
 		"skip unexported fields": {
 			env: map[string]string{
 				"VALUE": "value",
@@ -141,9 +185,93 @@ func TestWalk(t *testing.T) {
 				Field: &map[string]string{"key1": "value1"},
 			},
 		},
+		"map of slices of structs": {
+			env: map[string]string{
+				"CLUSTERS_EU_0_HOST":      "a",
+				"CLUSTERS_EU_0_PORT":      "8080",
+				"CLUSTERS_EU_1_HOST":      "b",
+				"CLUSTERS_US_WEST_0_HOST": "c",
+			},
+			expected: struct {
+				Clusters map[string][]*struct {
+					Host string
+					Port int `default:"80"`
+				}
+			}{
+				Clusters: map[string][]*struct {
+					Host string
+					Port int `default:"80"`
+				}{
+					"eu":      {{Host: "a", Port: 8080}, {Host: "b", Port: 80}},
+					"us_west": {{Host: "c", Port: 80}},
+				},
+			},
+		},
+		"map of slices": {
+			env: map[string]string{
+				"TAGS_EU":   "a,b",
+				"TAGS_US_0": "c",
+				"TAGS_US_1": "d",
+			},
+			expected: struct {
+				Tags map[string][]string
+			}{
+				Tags: map[string][]string{"eu": {"a", "b"}, "us": {"c", "d"}},
+			},
+		},
+		"map of maps": {
+			env: map[string]string{
+				"LIMITS_TENANTA_READS":  "10",
+				"LIMITS_TENANTA_WRITES": "5",
+				"LIMITS_TENANTB":        "reads:1",
+			},
+			expected: struct {
+				Limits map[string]map[string]int
+			}{
+				Limits: map[string]map[string]int{
+					"tenanta": {"reads": 10, "writes": 5},
+					"tenantb": {"reads": 1},
+				},
+			},
+		},
+		"map of structs with slices of structs": {
+			env: map[string]string{
+				"REGIONS_EU_SERVERS_0_HOST": "a",
+				"REGIONS_EU_SERVERS_1_HOST": "b",
+			},
+			expected: struct {
+				Regions map[string]struct {
+					Servers []struct{ Host string }
+				}
+			}{
+				Regions: map[string]struct {
+					Servers []struct{ Host string }
+				}{
+					"eu": {Servers: []struct{ Host string }{{Host: "a"}, {Host: "b"}}},
+				},
+			},
+		},
+		"map with text unmarshaler keys": {
+			env: map[string]string{
+				"REGIONS_EU_HOST": "a",
+				"WEIGHTS":         "us:1,eu:2",
+			},
+			expected: struct {
+				Regions map[unmarshaler]struct{ Host string }
+				Weights map[unmarshaler]int
+			}{
+				Regions: map[unmarshaler]struct{ Host string }{{Value: "eu"}: {Host: "a"}},
+				Weights: map[unmarshaler]int{{Value: "us"}: 1, {Value: "eu"}: 2},
+			},
+		},
+		"map with invalid text unmarshaler key": {
+			env: map[string]string{
+				"REGIONS_EU": "a",
+			},
+			cfg:         &struct{ Regions map[unmarshalError]string }{},
+			expectedErr: errs.ErrInvalidMapKey,
+		},
 		"map of structs nil pointer": {
-			skip:       true,
-			skipReason: "TODO: fix this",
 			env: map[string]string{
 				"FIELD_KEY1_VALUE": "value1",
 			},
@@ -155,6 +283,43 @@ func TestWalk(t *testing.T) {
 				},
 			},
 		},
+		"map of struct pointers nil pointer": {
+			env: map[string]string{
+				"SERVERS_PRIMARY_HOST": "host1",
+				"SERVERS_PRIMARY_PORT": "8080",
+				"SERVERS_BACKUP_HOST":  "host2",
+			},
+			expected: struct {
+				Servers *map[string]*struct {
+					Host string
+					Port int `default:"80"`
+				}
+			}{
+				Servers: &map[string]*struct {
+					Host string
+					Port int `default:"80"`
+				}{
+					"primary": {Host: "host1", Port: 8080},
+					"backup":  {Host: "host2", Port: 80},
+				},
+			},
+		},
+		"map of structs non-nil pointer": {
+			env: map[string]string{
+				"FIELD_KEY1_VALUE": "value1",
+			},
+			cfg: &struct {
+				Field *map[string]struct{ Value string }
+			}{Field: &map[string]struct{ Value string }{"key2": {Value: "value2"}}},
+			expected: struct {
+				Field *map[string]struct{ Value string }
+			}{
+				Field: &map[string]struct{ Value string }{
+					"key1": {Value: "value1"},
+					"key2": {Value: "value2"},
+				},
+			},
+		},
 		"delimited slice nil pointer": {
 			env: map[string]string{
 				"FIELD": "a,b,c",
@@ -266,6 +431,210 @@ func TestWalk(t *testing.T) {
 			cfg:         &struct{ Slice []int }{},
 			expectedErr: strconv.ErrSyntax,
 		},
+		"indexed slice of slices": {
+			env: map[string]string{
+				"MATRIX_0_0": "1",
+				"MATRIX_0_1": "2",
+				"MATRIX_1_0": "3",
+			},
+			expected: struct{ Matrix [][]int }{Matrix: [][]int{{1, 2}, {3}}},
+		},
+		"delimited slice of slices": {
+			env: map[string]string{
+				"MATRIX": "1;2,3",
+			},
+			expected: struct{ Matrix [][]int }{Matrix: [][]int{{1, 2}, {3}}},
+		},
+		"delimited slice of slices with subdelim tag": {
+			env: map[string]string{
+				"MATRIX": "1 2|3",
+			},
+			expected: struct {
+				Matrix [][]int `delim:"|" subdelim:" "`
+			}{Matrix: [][]int{{1, 2}, {3}}},
+		},
+		"delimited slice of slices with invalid value": {
+			env: map[string]string{
+				"MATRIX": "1;a,3",
+			},
+			cfg:         &struct{ Matrix [][]int }{},
+			expectedErr: strconv.ErrSyntax,
+		},
+		"json encoded struct": {
+			env: map[string]string{
+				"DB": `{"Host": "localhost", "Ports": [5432, 5433]}`,
+			},
+			expected: struct {
+				DB struct {
+					Host  string
+					Ports []int
+				} `encoding:"json"`
+			}{
+				DB: struct {
+					Host  string
+					Ports []int
+				}{Host: "localhost", Ports: []int{5432, 5433}},
+			},
+		},
+		"json encoded map with env option": {
+			env: map[string]string{
+				"LIMITS": `{"reads": 10, "writes": 5}`,
+			},
+			expected: struct {
+				Limits map[string]int `env:",encoding=json"`
+			}{
+				Limits: map[string]int{"reads": 10, "writes": 5},
+			},
+		},
+		"json encoded default": {
+			expected: struct {
+				Hosts []string `encoding:"json" default:"[\"a\"]"`
+			}{
+				Hosts: []string{"a"},
+			},
+		},
+		"json encoded nil pointer": {
+			env: map[string]string{
+				"DB": `{"Host": "localhost"}`,
+			},
+			expected: struct {
+				DB *struct{ Host string } `encoding:"json"`
+			}{
+				DB: &struct{ Host string }{Host: "localhost"},
+			},
+		},
+		"yaml encoded struct": {
+			env: map[string]string{
+				"DB": "host: localhost\nports:\n  - 5432\n  - 5433\n",
+			},
+			expected: struct {
+				DB struct {
+					Host  string
+					Ports []int
+				} `encoding:"yaml"`
+			}{
+				DB: struct {
+					Host  string
+					Ports []int
+				}{Host: "localhost", Ports: []int{5432, 5433}},
+			},
+		},
+		"invalid yaml": {
+			env: map[string]string{
+				"HOSTS": "a: [",
+			},
+			cfg: &struct {
+				Hosts []string `encoding:"yaml"`
+			}{},
+			expectedErr: errs.ErrDecodeValue,
+		},
+		"base64 tag": {
+			env: map[string]string{
+				"CERT": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0t",
+				"KEY":  "c2VjcmV0",
+			},
+			expected: struct {
+				Cert string `base64:"true"`
+				Key  []byte `env:",base64"`
+			}{
+				Cert: "-----BEGIN CERTIFICATE-----",
+				Key:  []byte("secret"),
+			},
+		},
+		"base64 encoded value": {
+			env: map[string]string{
+				"HOSTS": "YSxi",
+			},
+			expected: struct {
+				Hosts []string `encoding:"base64"`
+			}{
+				Hosts: []string{"a", "b"},
+			},
+		},
+		"base64 encoded json": {
+			env: map[string]string{
+				"LIMITS": "eyJyZWFkcyI6IDEwfQ==",
+			},
+			expected: struct {
+				Limits map[string]int `encoding:"json+base64"`
+			}{
+				Limits: map[string]int{"reads": 10},
+			},
+		},
+		"hex encoded value": {
+			env: map[string]string{
+				"KEY": "deadbeef",
+			},
+			expected: struct {
+				Key []byte `encoding:"hex"`
+			}{
+				Key: []byte{0xde, 0xad, 0xbe, 0xef},
+			},
+		},
+		"gzip compressed json": {
+			env: map[string]string{
+				"LIMITS": "H4sIAAAAAAACA6tWKkpNTClWslIwNKgFACUKZiANAAAA",
+			},
+			expected: struct {
+				Limits map[string]int `encoding:"json+gzip+base64"`
+			}{
+				Limits: map[string]int{"reads": 10},
+			},
+		},
+		"invalid hex": {
+			env: map[string]string{
+				"KEY": "xyz",
+			},
+			cfg: &struct {
+				Key []byte `encoding:"hex"`
+			}{},
+			expectedErr: errs.ErrDecodeValue,
+		},
+		"invalid gzip": {
+			env: map[string]string{
+				"LIMITS": "bm90IGd6aXA=",
+			},
+			cfg: &struct {
+				Limits string `encoding:"gzip+base64"`
+			}{},
+			expectedErr: errs.ErrDecodeValue,
+		},
+		"invalid base64": {
+			env: map[string]string{
+				"PORT": "not base64!",
+			},
+			cfg: &struct {
+				Port int `base64:"true"`
+			}{},
+			expectedErr: errs.ErrDecodeValue,
+		},
+		"unknown inner encoding": {
+			env: map[string]string{
+				"LIMITS": "eyJyZWFkcyI6IDEwfQ==",
+			},
+			cfg: &struct {
+				Limits map[string]int `encoding:"base64+toml"`
+			}{},
+			expectedErr: errs.ErrDecodeValue,
+		},
+		"invalid json": {
+			env: map[string]string{
+				"HOSTS": `["a"`,
+			},
+			cfg: &struct {
+				Hosts []string `encoding:"json"`
+			}{},
+			expectedErr: errs.ErrDecodeValue,
+		},
+		"unknown encoding": {
+			env: map[string]string{
+				"HOSTS": `["a"]`,
+			},
+			cfg: &struct {
+				Hosts []string `encoding:"toml"`
+			}{},
+			expectedErr: errs.ErrDecodeValue,
+		},
 		"delimited map": {
 			env: map[string]string{
 				"MAP": "a:b,c:d",
@@ -336,8 +705,6 @@ func TestWalk(t *testing.T) {
 			expected: struct{ Slice []struct{ Value string } }{Slice: []struct{ Value string }{{Value: "value1"}, {Value: "value2"}}},
 		},
 		"slice of structs only default values": {
-			skip:       true,
-			skipReason: "TODO: fix this",
 			env: map[string]string{
 				"SLICE_0_FOO": "", // force traversal, but no matching keys
 			},
@@ -347,6 +714,24 @@ func TestWalk(t *testing.T) {
 				}
 			}{},
 		},
+		"slice of structs with default values": {
+			env: map[string]string{
+				"SLICE_0_NAME": "a",
+				"SLICE_1_NAME": "b",
+				"SLICE_1_PORT": "8080",
+			},
+			expected: struct {
+				Slice []struct {
+					Name string
+					Port int `default:"80"`
+				}
+			}{
+				Slice: []struct {
+					Name string
+					Port int `default:"80"`
+				}{{Name: "a", Port: 80}, {Name: "b", Port: 8080}},
+			},
+		},
 		"nil struct with slice of structs with only default values": {
 			env: map[string]string{
 				"FIELD_SLICE_0_FOO": "", // force traversal, but no matching keys
@@ -455,6 +840,55 @@ func TestWalk(t *testing.T) {
 				},
 			},
 		},
+		"skip unless condition met": {
+			env: map[string]string{
+				"TLS_ENABLED":   "true",
+				"TLS_CERT_FILE": "cert.pem",
+			},
+			expected: struct {
+				TLS struct {
+					CertFile string `required:"true"`
+				} `skipUnless:"TLSEnabled=true"`
+				TLSEnabled bool
+			}{
+				TLS: struct {
+					CertFile string `required:"true"`
+				}{CertFile: "cert.pem"},
+				TLSEnabled: true,
+			},
+		},
+		"skip unless condition not met": {
+			env: map[string]string{
+				"TLS_ENABLED": "false",
+			},
+			expected: struct {
+				TLSEnabled bool
+				TLS        struct {
+					CertFile string `required:"true"`
+				} `env:",skipUnless=TLSEnabled=true"`
+			}{},
+		},
+		"skip unless non-zero": {
+			env: map[string]string{},
+			expected: struct {
+				CacheURL *string
+				Redis    struct {
+					Host string `required:"true"`
+				} `skipUnless:"CacheURL"`
+			}{},
+		},
+		"skip unless required error": {
+			env: map[string]string{
+				"TLS_ENABLED": "true",
+			},
+			expected: struct {
+				TLSEnabled bool
+				TLS        struct {
+					CertFile string `required:"true"`
+				} `skipUnless:"TLSEnabled=true"`
+			}{},
+			expectedErr: errs.ErrRequired,
+		},
 		"decodeunset": {
 			env: map[string]string{},
 			expected: struct {
@@ -473,9 +907,7 @@ func TestWalk(t *testing.T) {
 				t.Skip(tc.skipReason)
 			}
 
-			w := New()
-
-			w.Matcher.EnvVars = tc.env
+			w := newWalker(tc.env)
 
 			cfg := tc.cfg
 			if cfg == nil {
@@ -498,6 +930,16 @@ func TestWalk(t *testing.T) {
 	}
 }
 
+func newWalker(env map[string]string) *Walker {
+	m := matcher.New()
+	m.EnvVars = env
+
+	w := New()
+	w.Matcher = m
+
+	return w
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
@@ -529,3 +971,1256 @@ type unmarshalError struct {
 func (d *unmarshalError) UnmarshalText(text []byte) error {
 	return unmarshalErr
 }
+
+func TestWalkMaxErrors(t *testing.T) {
+	type Config struct {
+		A      int
+		B      int `required:"true"`
+		Nested struct {
+			C int
+			D int
+		}
+		E string
+	}
+
+	env := map[string]string{
+		"A":        "a",
+		"NESTED_C": "c",
+		"NESTED_D": "d",
+		"E":        "value",
+	}
+
+	t.Run("collects errors", func(t *testing.T) {
+		w := newWalker(env)
+		w.MaxErrors = 10
+
+		cfg := Config{}
+		err := w.Walk(&cfg)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, strconv.ErrSyntax)
+		assert.ErrorIs(t, err, errs.ErrRequired)
+		assert.NotErrorIs(t, err, errs.ErrTooManyErrors)
+		assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 4)
+		assert.Equal(t, "value", cfg.E)
+	})
+
+	t.Run("collects all errors", func(t *testing.T) {
+		w := newWalker(env)
+		w.CollectErrors = true
+
+		err := w.Walk(&Config{})
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errs.ErrTooManyErrors)
+		assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 4)
+	})
+
+	t.Run("caps errors", func(t *testing.T) {
+		w := newWalker(env)
+		w.MaxErrors = 2
+
+		err := w.Walk(&Config{})
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errs.ErrTooManyErrors)
+		assert.ErrorContains(t, err, "... and 2 more")
+		assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 3)
+	})
+}
+
+func TestWalkRequireExplicitTags(t *testing.T) {
+	tt := map[string]struct {
+		cfg         any
+		expectedErr error
+	}{
+		"explicit tags": {
+			cfg: &struct {
+				Redis struct {
+					Host string `env:"HOST"`
+				} `env:"REDIS"`
+				Ignored string `env:"-"`
+			}{},
+		},
+		"missing tag": {
+			cfg: &struct {
+				Host string `json:"host"`
+			}{},
+			expectedErr: errs.ErrMissingTag,
+		},
+		"missing tag name": {
+			cfg: &struct {
+				Host string `env:",required"`
+			}{},
+			expectedErr: errs.ErrMissingTag,
+		},
+		"missing nested tag": {
+			cfg: &struct {
+				Redis struct {
+					Host string
+				} `env:"REDIS"`
+			}{},
+			expectedErr: errs.ErrMissingTag,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := New()
+			w.RequireExplicitTags = true
+
+			err := w.Walk(tc.cfg)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWalkTrimSpace(t *testing.T) {
+	type Config struct {
+		Port    int
+		Debug   bool
+		Timeout *time.Duration
+		Name    string
+	}
+
+	env := map[string]string{"PORT": " 8080 ", "DEBUG": "true\n", "TIMEOUT": " 5s", "NAME": " name "}
+
+	t.Run("disabled", func(t *testing.T) {
+		w := newWalker(env)
+
+		assert.ErrorIs(t, w.Walk(&Config{}), strconv.ErrSyntax)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		w := newWalker(env)
+		w.TrimSpace = true
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, Config{Port: 8080, Debug: true, Timeout: ptr(5 * time.Second), Name: " name "}, cfg)
+	})
+}
+
+func TestWalkSliceGaps(t *testing.T) {
+	type Config struct {
+		Hosts   []string
+		Servers []struct{ Host string }
+	}
+
+	env := map[string]string{
+		"HOSTS_0":        "a",
+		"HOSTS_2":        "c",
+		"SERVERS_1_HOST": "b",
+		"SERVERS_X_HOST": "x",
+		"SERVERS_3_HOST": "d",
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, newWalker(env).Walk(&cfg))
+
+		assert.Equal(t, []string{"a"}, cfg.Hosts)
+		assert.Nil(t, cfg.Servers)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		w := newWalker(env)
+		w.SliceGaps = SliceGapsSkip
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, []string{"a", "c"}, cfg.Hosts)
+		assert.Equal(t, []struct{ Host string }{{Host: "b"}, {Host: "d"}}, cfg.Servers)
+	})
+
+	t.Run("max index", func(t *testing.T) {
+		w := newWalker(map[string]string{"HOSTS_0": "a", "HOSTS_2": "c", "HOSTS_10": "k"})
+		w.SliceGaps = SliceGapsSkip
+		w.MaxSliceIndex = 9
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, []string{"a", "c"}, cfg.Hosts)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		w := newWalker(env)
+		w.SliceGaps = SliceGapsError
+
+		err := w.Walk(&Config{})
+
+		assert.ErrorIs(t, err, errs.ErrSliceGap)
+		assert.EqualError(t, err, "missing slice index: HOSTS: missing index 1")
+	})
+}
+
+func TestWalkPrefixTag(t *testing.T) {
+	type DB struct {
+		Host   string
+		Labels map[string]string
+		Extra  map[string]string `env:",remain"`
+	}
+
+	type Config struct {
+		Primary DB `envPrefix:"PRIMARY_DB_"`
+		Replica DB `env:"REPLICA_" envPrefix:"RO"`
+		Backup  DB `env:"BACKUP_"`
+	}
+
+	w := newWalker(map[string]string{
+		"PRIMARY_DB_HOST":        "primary",
+		"PRIMARY_DB_LABELS_ZONE": "a",
+		"PRIMARY_DB_SSL":         "disable",
+		"PRIMARY_HOST":           "ignored",
+		"RO_HOST":                "replica",
+		"BACKUP_HOST":            "backup",
+	})
+
+	var cfg Config
+	require.NoError(t, w.Walk(&cfg))
+
+	assert.Equal(t, Config{
+		Primary: DB{
+			Host:   "primary",
+			Labels: map[string]string{"zone": "a"},
+			Extra:  map[string]string{"SSL": "disable"},
+		},
+		Replica: DB{Host: "replica"},
+		Backup:  DB{Host: "backup"},
+	}, cfg)
+}
+
+type Base struct {
+	Name string
+}
+
+type Server struct {
+	Host string
+}
+
+func TestWalkSquash(t *testing.T) {
+	type Config struct {
+		Base
+		Server `env:",squash"`
+		DB     struct {
+			Port int
+		} `squash:"true"`
+	}
+
+	type Prefixed struct {
+		Base `squash:"false"`
+		Server
+	}
+
+	env := map[string]string{
+		"NAME":        "squashed",
+		"BASE_NAME":   "prefixed",
+		"HOST":        "host",
+		"SERVER_HOST": "ignored",
+		"PORT":        "5432",
+	}
+
+	t.Run("tags", func(t *testing.T) {
+		w := newWalker(env)
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, "prefixed", cfg.Name)
+		assert.Equal(t, "host", cfg.Host)
+		assert.Equal(t, 5432, cfg.DB.Port)
+	})
+
+	t.Run("squash embedded", func(t *testing.T) {
+		w := newWalker(env)
+		w.Matcher.(*matcher.Matcher).SquashEmbedded = true
+
+		var cfg Prefixed
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, Prefixed{Base: Base{Name: "prefixed"}, Server: Server{Host: "host"}}, cfg)
+	})
+}
+
+func TestWalkKeySeparator(t *testing.T) {
+	type Config struct {
+		Foo struct {
+			BarBaz string
+		}
+		FooBar struct {
+			Baz string
+		}
+		Servers []struct {
+			Host string
+		}
+		DBs map[string]struct {
+			Host string
+		}
+		Labels map[string]string
+	}
+
+	m := matcher.New()
+	m.KeySeparator = "__"
+	m.SetEnvVars(map[string]string{
+		"FOO__BAR_BAZ":       "foo",
+		"FOO_BAR__BAZ":       "foobar",
+		"SERVERS__0__HOST":   "server",
+		"DBS__MAIN_DB__HOST": "db",
+		"LABELS__TEAM_NAME":  "team",
+	})
+
+	w := New()
+	w.Matcher = m
+
+	var cfg Config
+	require.NoError(t, w.Walk(&cfg))
+
+	assert.Equal(t, "foo", cfg.Foo.BarBaz)
+	assert.Equal(t, "foobar", cfg.FooBar.Baz)
+	require.Len(t, cfg.Servers, 1)
+	assert.Equal(t, "server", cfg.Servers[0].Host)
+	assert.Equal(t, "db", cfg.DBs["main_db"].Host)
+	assert.Equal(t, map[string]string{"team_name": "team"}, cfg.Labels)
+}
+
+func TestWalkNamingStrategy(t *testing.T) {
+	type Config struct {
+		DB struct {
+			MaxConns int
+			Host     string `env:"HOSTNAME"`
+		}
+		Tags []string
+	}
+
+	m := matcher.New()
+	m.NamingStrategy = func(path []string) []string {
+		name := strings.ToUpper(path[len(path)-1])
+		if len(path) == 1 {
+			return []string{"MYAPP_" + name}
+		}
+
+		return []string{name}
+	}
+	m.EnvVars = map[string]string{
+		"MYAPP_DB_MAXCONNS": "10",
+		"MYAPP_DB_HOSTNAME": "db",
+		"MYAPP_TAGS_0":      "a",
+		"DB_MAX_CONNS":      "20",
+	}
+
+	w := New()
+	w.Matcher = m
+
+	var cfg Config
+	require.NoError(t, w.Walk(&cfg))
+
+	assert.Equal(t, 10, cfg.DB.MaxConns)
+	assert.Equal(t, "db", cfg.DB.Host)
+	assert.Equal(t, []string{"a"}, cfg.Tags)
+}
+
+func TestWalkKeepNonZeroDefaults(t *testing.T) {
+	type Config struct {
+		Host string `default:"localhost"`
+		Port int    `default:"8080"`
+		Name string `default:"name"`
+	}
+
+	w := newWalker(map[string]string{"PORT": "9090"})
+	w.KeepNonZeroDefaults = true
+
+	cfg := Config{Host: "example.com", Port: 80}
+	require.NoError(t, w.Walk(&cfg))
+
+	assert.Equal(t, Config{Host: "example.com", Port: 9090, Name: "name"}, cfg)
+}
+
+func TestWalkNoOverride(t *testing.T) {
+	type DB struct {
+		Host string
+		Port int `default:"5432"`
+	}
+
+	type Config struct {
+		Host  string `default:"localhost"`
+		Port  int    `default:"8080"`
+		Name  string `default:"name"`
+		Tags  []string
+		Label *string
+		DB    DB
+	}
+
+	env := map[string]string{
+		"HOST":    "env.example.com",
+		"PORT":    "9090",
+		"TAGS":    "a,b",
+		"LABEL":   "label",
+		"DB_HOST": "db",
+	}
+
+	t.Run("all fields", func(t *testing.T) {
+		w := newWalker(env)
+		w.NoOverride = true
+
+		cfg := Config{Port: 80, Tags: []string{}, DB: DB{Port: 6543}}
+		require.NoError(t, w.Walk(&cfg))
+
+		label := "label"
+		assert.Equal(t, Config{
+			Host:  "env.example.com",
+			Port:  80,
+			Name:  "name",
+			Tags:  []string{"a", "b"},
+			Label: &label,
+			DB:    DB{Host: "db", Port: 6543},
+		}, cfg)
+	})
+
+	t.Run("keep tag", func(t *testing.T) {
+		type Config struct {
+			Host string `keep:"true"`
+			Port int    `env:",keep"`
+			Name string
+		}
+
+		w := newWalker(map[string]string{"HOST": "env", "PORT": "9090", "NAME": "env"})
+
+		cfg := Config{Host: "code", Name: "code"}
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, Config{Host: "code", Port: 9090, Name: "env"}, cfg)
+	})
+}
+
+func TestWalkRemain(t *testing.T) {
+	type DB struct {
+		Host  string
+		Extra map[string]string `env:",remain"`
+	}
+
+	type Config struct {
+		Port  int
+		DB    DB
+		Extra map[string]string `env:",remain"`
+	}
+
+	w := newWalker(map[string]string{
+		"PORT":        "8080",
+		"DB_HOST":     "localhost",
+		"DB_SSL_MODE": "disable",
+		"OTHER":       "value",
+	})
+
+	var cfg Config
+	require.NoError(t, w.Walk(&cfg))
+
+	assert.Equal(t, Config{
+		Port: 8080,
+		DB: DB{
+			Host:  "localhost",
+			Extra: map[string]string{"SSL_MODE": "disable"},
+		},
+		Extra: map[string]string{"OTHER": "value"},
+	}, cfg)
+}
+
+func TestWalkRemainSiblings(t *testing.T) {
+	type DB struct {
+		Host  string
+		Extra map[string]string `env:",remain"`
+	}
+
+	type Config struct {
+		DB       DB
+		DBPool   int
+		Replicas []DB
+	}
+
+	w := newWalker(map[string]string{
+		"DB_HOST":             "localhost",
+		"DB_OTHER":            "x",
+		"DB_POOL":             "5",
+		"REPLICAS_0_HOST":     "replica",
+		"REPLICAS_0_SSL_MODE": "disable",
+	})
+
+	var cfg Config
+	require.NoError(t, w.Walk(&cfg))
+
+	assert.Equal(t, Config{
+		DB: DB{
+			Host:  "localhost",
+			Extra: map[string]string{"OTHER": "x"},
+		},
+		DBPool: 5,
+		Replicas: []DB{{
+			Host:  "replica",
+			Extra: map[string]string{"SSL_MODE": "disable"},
+		}},
+	}, cfg)
+}
+
+func TestWalkRemainInvalidType(t *testing.T) {
+	type Config struct {
+		Extra map[string]int `env:",remain"`
+	}
+
+	w := newWalker(map[string]string{"OTHER": "1"})
+
+	var cfg Config
+	assert.ErrorIs(t, w.Walk(&cfg), errs.ErrInvalidRemain)
+}
+
+func TestWalkGroupRequired(t *testing.T) {
+	type API struct {
+		Token   string `group:"auth" grouprequired:"one"`
+		KeyFile string `env:",group=auth"`
+	}
+
+	type Config struct {
+		API API
+	}
+
+	t.Run("member set", func(t *testing.T) {
+		w := newWalker(map[string]string{"API_KEY_FILE": "key"})
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+		assert.Equal(t, "key", cfg.API.KeyFile)
+	})
+
+	t.Run("no member set", func(t *testing.T) {
+		w := newWalker(map[string]string{"AUTH": "ignored"})
+
+		var cfg Config
+		err := w.Walk(&cfg)
+		assert.ErrorIs(t, err, errs.ErrGroupRequired)
+		assert.ErrorContains(t, err, "auth requires one of API_TOKEN, API_KEY_FILE")
+		assert.Empty(t, cfg.API.Token)
+	})
+}
+
+func TestFields(t *testing.T) {
+	type Nested struct {
+		Host string
+	}
+
+	type Config struct {
+		Name     string
+		Timeout  time.Duration
+		Redis    Nested
+		Cache    *Nested
+		Tags     []string
+		Ignored  string `env:"-"`
+		internal string
+	}
+
+	w := New()
+
+	fields, err := w.Fields(&Config{})
+	require.NoError(t, err)
+
+	var names []string
+	for _, path := range fields {
+		var name string
+		for _, tm := range path {
+			name += "." + tm.FieldName
+		}
+		names = append(names, name)
+	}
+
+	assert.Equal(t, []string{".Name", ".Timeout", ".Redis.Host", ".Cache.Host", ".Tags"}, names)
+
+	_, err = w.Fields("not a struct")
+	assert.ErrorIs(t, err, errs.ErrNotAPointer)
+
+	t.Run("recursive", func(t *testing.T) {
+		fields, err := w.Fields(&struct{ Root node }{})
+		require.NoError(t, err)
+		require.Len(t, fields, 2)
+		assert.Equal(t, "Value", fields[0][1].FieldName)
+		assert.Equal(t, "Children", fields[1][1].FieldName)
+	})
+}
+
+func TestKeys(t *testing.T) {
+	type Nested struct {
+		Host string
+	}
+
+	type Config struct {
+		Name    string `env:"APP_NAME"`
+		Timeout time.Duration
+		Redis   Nested
+		Cache   *Nested `required:"true"`
+		Ignored string  `env:"-"`
+	}
+
+	keys, ok := newWalker(nil).Keys(&Config{})
+	require.True(t, ok)
+	assert.Equal(t, []string{"APP_NAME", "CACHE", "CACHE_HOST", "NAME", "REDIS", "REDIS_HOST", "TIMEOUT"}, keys)
+
+	t.Run("prefixed fields", func(t *testing.T) {
+		_, ok := newWalker(nil).Keys(&struct{ Tags []string }{})
+		assert.False(t, ok)
+
+		_, ok = newWalker(nil).Keys(&struct{ Labels map[string]string }{})
+		assert.False(t, ok)
+
+		_, ok = newWalker(nil).Keys(&struct {
+			Rest map[string]string `remain:"true"`
+		}{})
+		assert.False(t, ok)
+
+		_, ok = newWalker(nil).Keys(&struct {
+			URL string `expand:"true"`
+		}{})
+		assert.False(t, ok)
+
+		_, ok = newWalker(nil).Keys(&node{})
+		assert.False(t, ok)
+	})
+
+	t.Run("custom matcher", func(t *testing.T) {
+		w := New()
+		w.Matcher = struct{ Matcher }{matcher.New()}
+
+		_, ok := w.Keys(&Config{})
+		assert.False(t, ok)
+	})
+}
+
+type node struct {
+	Value    int
+	Next     *node
+	Children []node
+}
+
+func TestWalkRecursive(t *testing.T) {
+	t.Run("pointers", func(t *testing.T) {
+		w := newWalker(map[string]string{"VALUE": "1", "NEXT_NEXT_VALUE": "3"})
+
+		var n node
+		require.NoError(t, w.Walk(&n))
+
+		assert.Equal(t, node{Value: 1, Next: &node{Next: &node{Value: 3}}}, n)
+	})
+
+	t.Run("init always", func(t *testing.T) {
+		w := newWalker(map[string]string{"ROOT_VALUE": "1"})
+		w.InitMode = InitAlways
+
+		var cfg struct{ Root *node }
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, &node{Value: 1, Children: []node{}}, cfg.Root)
+	})
+
+	t.Run("slices", func(t *testing.T) {
+		w := newWalker(map[string]string{"CHILDREN_0_VALUE": "1", "CHILDREN_0_CHILDREN_0_VALUE": "2"})
+
+		var n node
+		require.NoError(t, w.Walk(&n))
+
+		assert.Equal(t, node{Children: []node{{Value: 1, Children: []node{{Value: 2}}}}}, n)
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		w := newWalker(map[string]string{"NEXT_NEXT_NEXT_VALUE": "4"})
+		w.MaxDepth = 3
+
+		var n node
+		assert.ErrorIs(t, w.Walk(&n), errs.ErrMaxDepth)
+	})
+}
+
+func TestWalkExistingValues(t *testing.T) {
+	type DB struct {
+		Host string
+		Port int
+	}
+
+	type Config struct {
+		DB    *DB
+		Hosts []string
+	}
+
+	tt := map[string]struct {
+		env      map[string]string
+		keep     bool
+		expected Config
+	}{
+		"pointer kept": {
+			env:      map[string]string{"DB_HOST": "override"},
+			expected: Config{DB: &DB{Host: "h", Port: 5432}, Hosts: []string{"x", "y"}},
+		},
+		"indexed slice appended": {
+			env:      map[string]string{"HOSTS_0": "z"},
+			expected: Config{DB: &DB{Host: "h", Port: 5432}, Hosts: []string{"x", "y", "z"}},
+		},
+		"delimited slice appended": {
+			env:      map[string]string{"HOSTS": "a,b"},
+			expected: Config{DB: &DB{Host: "h", Port: 5432}, Hosts: []string{"x", "y", "a", "b"}},
+		},
+		"pointer walked in place with keep non-zero defaults": {
+			env:      map[string]string{"DB_HOST": "override"},
+			keep:     true,
+			expected: Config{DB: &DB{Host: "override", Port: 5432}, Hosts: []string{"x", "y"}},
+		},
+		"indexed slice replaced with keep non-zero defaults": {
+			env:      map[string]string{"HOSTS_0": "z"},
+			keep:     true,
+			expected: Config{DB: &DB{Host: "h", Port: 5432}, Hosts: []string{"z"}},
+		},
+		"delimited slice replaced with keep non-zero defaults": {
+			env:      map[string]string{"HOSTS": "a,b"},
+			keep:     true,
+			expected: Config{DB: &DB{Host: "h", Port: 5432}, Hosts: []string{"a", "b"}},
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(tc.env)
+			w.KeepNonZeroDefaults = tc.keep
+
+			cfg := Config{DB: &DB{Host: "h", Port: 5432}, Hosts: []string{"x", "y"}}
+			require.NoError(t, w.Walk(&cfg))
+
+			assert.Equal(t, tc.expected, cfg)
+		})
+	}
+}
+
+func TestWalkDecodedStruct(t *testing.T) {
+	type DB struct {
+		Host    string `json:"host" required:"true"`
+		Port    int    `json:"port" default:"5432"`
+		Timeout string `json:"timeout" default:"30s"`
+	}
+
+	type Cache struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port" default:"6379"`
+	}
+
+	type Config struct {
+		DB    DB     `env:"APP_DB,encoding=json"`
+		Cache *Cache `encoding:"yaml"`
+	}
+
+	tt := map[string]struct {
+		env         map[string]string
+		expected    Config
+		expectedErr error
+	}{
+		"decoded": {
+			env:      map[string]string{"APP_DB": `{"host": "db", "port": 6432}`},
+			expected: Config{DB: DB{Host: "db", Port: 6432, Timeout: "30s"}},
+		},
+		"leaf overrides": {
+			env: map[string]string{
+				"APP_DB":      `{"host": "db", "port": 6432}`,
+				"APP_DB_HOST": "override",
+				"CACHE":       "host: cache\nport: 6380",
+				"CACHE_HOST":  "override",
+			},
+			expected: Config{
+				DB:    DB{Host: "override", Port: 6432, Timeout: "30s"},
+				Cache: &Cache{Host: "override", Port: 6380},
+			},
+		},
+		"required leaf missing": {
+			env:         map[string]string{"APP_DB": `{"port": 6432}`},
+			expectedErr: errs.ErrRequired,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(tc.env)
+
+			var cfg Config
+			err := w.Walk(&cfg)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg)
+			assert.False(t, w.KeepNonZeroDefaults)
+		})
+	}
+}
+
+func TestWalkInitEmpty(t *testing.T) {
+	type Config struct {
+		Tags    []string          `init:"always"`
+		Labels  map[string]string `env:",init=always"`
+		Ports   *[]int            `init:"always"`
+		Hosts   []string
+		Servers []struct{ Host string } `init:"always"`
+	}
+
+	t.Run("no variables", func(t *testing.T) {
+		w := newWalker(map[string]string{})
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, Config{
+			Tags:    []string{},
+			Labels:  map[string]string{},
+			Ports:   &[]int{},
+			Servers: []struct{ Host string }{},
+		}, cfg)
+	})
+
+	t.Run("variables", func(t *testing.T) {
+		w := newWalker(map[string]string{"TAGS": "a", "LABELS_A": "b"})
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, []string{"a"}, cfg.Tags)
+		assert.Equal(t, map[string]string{"a": "b"}, cfg.Labels)
+	})
+
+	t.Run("init always", func(t *testing.T) {
+		w := newWalker(map[string]string{})
+		w.InitMode = InitAlways
+
+		var cfg Config
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, []string{}, cfg.Hosts)
+	})
+}
+
+func TestWalkMapMode(t *testing.T) {
+	type Config struct {
+		Labels   map[string]string
+		Limits   map[string]int `mapmode:"replace"`
+		Settings map[string]int `env:",mapmode=merge"`
+	}
+
+	env := map[string]string{
+		"LABELS":          "a:override",
+		"LIMITS_READS":    "10",
+		"SETTINGS_WRITES": "5",
+	}
+
+	existing := func() Config {
+		return Config{
+			Labels:   map[string]string{"a": "x", "b": "y"},
+			Limits:   map[string]int{"reads": 1, "writes": 2},
+			Settings: map[string]int{"reads": 1, "writes": 2},
+		}
+	}
+
+	t.Run("merge", func(t *testing.T) {
+		w := newWalker(env)
+
+		cfg := existing()
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, Config{
+			Labels:   map[string]string{"a": "override", "b": "y"},
+			Limits:   map[string]int{"reads": 10},
+			Settings: map[string]int{"reads": 1, "writes": 5},
+		}, cfg)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		w := newWalker(env)
+		w.MapMode = MapReplace
+
+		cfg := existing()
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, Config{
+			Labels:   map[string]string{"a": "override"},
+			Limits:   map[string]int{"reads": 10},
+			Settings: map[string]int{"reads": 1, "writes": 5},
+		}, cfg)
+	})
+
+	t.Run("no variables", func(t *testing.T) {
+		w := newWalker(map[string]string{})
+		w.MapMode = MapReplace
+
+		cfg := existing()
+		require.NoError(t, w.Walk(&cfg))
+
+		assert.Equal(t, existing(), cfg)
+	})
+}
+
+func TestWalkValidate(t *testing.T) {
+	type Config struct {
+		Port    int           `validate:"min=1,max=65535"`
+		Timeout time.Duration `validate:"min=1s"`
+		Name    string        `validate:"len=3"`
+		Tags    []string      `validate:"max=2"`
+		Level   string        `validate:"oneof=debug info" default:"info"`
+		Slug    string        `validate:"pattern=^[a-z]+$"`
+		URL     string        `validate:"url"`
+		Email   string        `validate:"email"`
+		IP      *string       `validate:"ipv4"`
+		Ports   []string      `validate:"port"`
+		Unset   int           `validate:"min=1"`
+	}
+
+	tt := map[string]struct {
+		env         map[string]string
+		expectedErr error
+		contains    string
+	}{
+		"valid": {
+			env: map[string]string{
+				"PORT":    "8080",
+				"TIMEOUT": "5s",
+				"NAME":    "abc",
+				"TAGS":    "a,b",
+				"SLUG":    "slug",
+				"URL":     "https://example.com/path",
+				"EMAIL":   "user@example.com",
+				"IP":      "10.0.0.1",
+				"PORTS":   "80,443",
+			},
+		},
+		"min":         {env: map[string]string{"PORT": "0"}, expectedErr: errs.ErrValidation, contains: "PORT must be at least 1"},
+		"max":         {env: map[string]string{"PORT": "70000"}, expectedErr: errs.ErrValidation, contains: "PORT must be at most 65535"},
+		"duration":    {env: map[string]string{"TIMEOUT": "10ms"}, expectedErr: errs.ErrValidation, contains: "TIMEOUT must be at least 1s"},
+		"len":         {env: map[string]string{"NAME": "abcd"}, expectedErr: errs.ErrValidation, contains: "NAME must have a length of 3"},
+		"slice max":   {env: map[string]string{"TAGS": "a,b,c"}, expectedErr: errs.ErrValidation, contains: "TAGS must have a length of at most 2"},
+		"oneof":       {env: map[string]string{"LEVEL": "trace"}, expectedErr: errs.ErrValidation, contains: `LEVEL must be one of debug, info, got "trace"`},
+		"pattern":     {env: map[string]string{"SLUG": "Slug"}, expectedErr: errs.ErrValidation},
+		"url":         {env: map[string]string{"URL": "example.com"}, expectedErr: errs.ErrValidation},
+		"email":       {env: map[string]string{"EMAIL": "user"}, expectedErr: errs.ErrValidation},
+		"ipv4":        {env: map[string]string{"IP": "::1"}, expectedErr: errs.ErrValidation},
+		"slice ports": {env: map[string]string{"PORTS": "80,0"}, expectedErr: errs.ErrValidation, contains: `PORTS must be a port between 1 and 65535, got "0"`},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(tc.env)
+
+			err := w.Walk(&Config{})
+
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.ErrorContains(t, err, tc.contains)
+		})
+	}
+}
+
+func TestWalkValidateInvalidRule(t *testing.T) {
+	tt := map[string]any{
+		"unknown": &struct {
+			A string `validate:"even"`
+		}{},
+		"invalid bound": &struct {
+			A int `validate:"min=one"`
+		}{},
+		"invalid len": &struct {
+			A int `validate:"len=1"`
+		}{},
+		"invalid regex": &struct {
+			A string `validate:"pattern=["`
+		}{},
+	}
+
+	for name, cfg := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(map[string]string{"A": "1"})
+
+			assert.ErrorIs(t, w.Walk(cfg), errs.ErrInvalidRule)
+		})
+	}
+}
+
+type validatedDB struct {
+	Host string
+	Port int
+}
+
+func (db validatedDB) Validate() error {
+	if db.Host != "" && db.Port == 0 {
+		return errors.New("port is required with host")
+	}
+
+	return nil
+}
+
+type validatedConfig struct {
+	Primary  validatedDB
+	Replicas []*validatedDB
+	Min      int
+	Max      int
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Min > c.Max {
+		return errors.New("min must not exceed max")
+	}
+
+	return nil
+}
+
+func TestWalkCallValidate(t *testing.T) {
+	tt := map[string]struct {
+		env      map[string]string
+		contains string
+	}{
+		"valid": {
+			env: map[string]string{"PRIMARY_HOST": "db", "PRIMARY_PORT": "5432", "MIN": "1", "MAX": "2"},
+		},
+		"root": {
+			env:      map[string]string{"MIN": "3", "MAX": "2"},
+			contains: "validation failed: min must not exceed max",
+		},
+		"nested": {
+			env:      map[string]string{"PRIMARY_HOST": "db"},
+			contains: "validation failed: Primary: port is required with host",
+		},
+		"slice": {
+			env:      map[string]string{"REPLICAS_0_HOST": "db", "REPLICAS_0_PORT": "5432", "REPLICAS_1_HOST": "db"},
+			contains: "validation failed: Replicas.1: port is required with host",
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(tc.env)
+
+			err := w.Walk(&validatedConfig{})
+
+			if tc.contains == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, errs.ErrValidation)
+			assert.EqualError(t, err, tc.contains)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		w := newWalker(map[string]string{"MIN": "3", "MAX": "2"})
+		w.DisableValidate = true
+
+		assert.NoError(t, w.Walk(&validatedConfig{}))
+	})
+
+	t.Run("collects errors", func(t *testing.T) {
+		w := newWalker(map[string]string{"PRIMARY_HOST": "db", "MIN": "3", "MAX": "2"})
+		w.MaxErrors = 10
+
+		err := w.Walk(&validatedConfig{})
+
+		require.Error(t, err)
+		assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2)
+	})
+}
+
+func TestWalkRequiredIf(t *testing.T) {
+	type Config struct {
+		TLSEnabled bool
+		CertFile   string `required_if:"TLSEnabled true"`
+		KeyFile    string `env:",required_with=CertFile"`
+		Mode       string
+		Token      string `required_if:"Mode"`
+	}
+
+	tt := map[string]struct {
+		env      map[string]string
+		contains string
+	}{
+		"not required": {
+			env: map[string]string{"TLS_ENABLED": "false"},
+		},
+		"required and set": {
+			env: map[string]string{"TLS_ENABLED": "true", "CERT_FILE": "cert", "KEY_FILE": "key"},
+		},
+		"required_if value": {
+			env:      map[string]string{"TLS_ENABLED": "true"},
+			contains: "CERT_FILE is required when TLSEnabled is true",
+		},
+		"required_with": {
+			env:      map[string]string{"CERT_FILE": "cert"},
+			contains: "KEY_FILE is required with CertFile",
+		},
+		"required_if set": {
+			env:      map[string]string{"MODE": "token"},
+			contains: "TOKEN is required when Mode is set",
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(tc.env)
+
+			err := w.Walk(&Config{})
+
+			if tc.contains == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, errs.ErrRequired)
+			assert.ErrorContains(t, err, tc.contains)
+		})
+	}
+}
+
+func TestWalkSensitiveErrors(t *testing.T) {
+	type Config struct {
+		Port   int      `sensitive:"true"`
+		Tokens []int    `env:",sensitive"`
+		Key    string   `sensitive:"true" validate:"pattern=^[a-z]+$"`
+		Keys   []string `sensitive:"true" validate:"oneof=a b"`
+		Other  int
+	}
+
+	tt := map[string]struct {
+		env      map[string]string
+		secret   string
+		expected error
+	}{
+		"parse":              {env: map[string]string{"PORT": "s3cret"}, secret: "s3cret", expected: strconv.ErrSyntax},
+		"delimited":          {env: map[string]string{"TOKENS": "1,s3cret"}, secret: "s3cret", expected: strconv.ErrSyntax},
+		"validation":         {env: map[string]string{"KEY": "s3cr3t"}, secret: "s3cr3t", expected: errs.ErrValidation},
+		"element validation": {env: map[string]string{"KEYS": "a,s3cret"}, secret: "s3cret", expected: errs.ErrValidation},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(tc.env)
+			w.Mask = "<redacted>"
+
+			err := w.Walk(&Config{})
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tc.expected)
+			assert.NotContains(t, err.Error(), tc.secret)
+			assert.Contains(t, err.Error(), "<redacted>")
+		})
+	}
+
+	t.Run("not sensitive", func(t *testing.T) {
+		w := newWalker(map[string]string{"OTHER": "visible"})
+
+		assert.ErrorContains(t, w.Walk(&Config{}), "visible")
+	})
+}
+
+type storage interface{ Kind() string }
+
+type s3Storage struct {
+	Bucket string
+	Region string `default:"us-east-1"`
+}
+
+func (s *s3Storage) Kind() string { return "s3" }
+
+type localStorage struct{ Path string }
+
+func (s localStorage) Kind() string { return "local" }
+
+func TestWalkInterface(t *testing.T) {
+	type Config struct {
+		Storage storage
+	}
+
+	storageType := reflect.TypeOf((*storage)(nil)).Elem()
+
+	newWalker := func(env map[string]string, impls map[string]func() any) *Walker {
+		w := newWalker(env)
+		w.Implementations[storageType] = impls
+		return w
+	}
+
+	impls := map[string]func() any{
+		"s3":    func() any { return &s3Storage{} },
+		"local": func() any { return localStorage{} },
+	}
+
+	tt := map[string]struct {
+		env         map[string]string
+		impls       map[string]func() any
+		expected    Config
+		expectedErr error
+	}{
+		"pointer implementation": {
+			env:      map[string]string{"STORAGE": "s3", "STORAGE_BUCKET": "bucket"},
+			impls:    impls,
+			expected: Config{Storage: &s3Storage{Bucket: "bucket", Region: "us-east-1"}},
+		},
+		"value implementation": {
+			env:      map[string]string{"STORAGE": "local", "STORAGE_PATH": "/data"},
+			impls:    impls,
+			expected: Config{Storage: localStorage{Path: "/data"}},
+		},
+		"no name": {
+			env:      map[string]string{"STORAGE_BUCKET": "bucket"},
+			impls:    impls,
+			expected: Config{},
+		},
+		"single implementation without name": {
+			env:      map[string]string{"STORAGE_BUCKET": "bucket"},
+			impls:    map[string]func() any{"s3": impls["s3"]},
+			expected: Config{Storage: &s3Storage{Bucket: "bucket", Region: "us-east-1"}},
+		},
+		"single implementation without variables": {
+			env:      map[string]string{},
+			impls:    map[string]func() any{"s3": impls["s3"]},
+			expected: Config{},
+		},
+		"unknown implementation": {
+			env:         map[string]string{"STORAGE": "gcs"},
+			impls:       impls,
+			expectedErr: errs.ErrUnknownImplementation,
+		},
+		"not an implementation": {
+			env:         map[string]string{"STORAGE": "s3"},
+			impls:       map[string]func() any{"s3": func() any { return s3Storage{} }},
+			expectedErr: errs.ErrUnknownImplementation,
+		},
+		"no implementations": {
+			env:      map[string]string{"STORAGE": "s3"},
+			expected: Config{},
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			w := newWalker(tc.env, tc.impls)
+
+			var cfg Config
+			err := w.Walk(&cfg)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package envcfg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KubernetesEnv returns the env: fragment of a Kubernetes container spec
+// for the variables the provided struct can be configured with.
+// Values are set to the example value, falling back to the default value.
+// Sensitive values reference the key of the named Secret instead.
+func KubernetesEnv(cfg any, secret string, opts ...Option) (string, error) {
+	specs, err := fieldSpecs(cfg, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("env:\n")
+	for _, spec := range specs {
+		writeComment(&sb, spec.Description)
+
+		fmt.Fprintf(&sb, "  - name: %s\n", spec.Key)
+		if spec.Sensitive {
+			fmt.Fprintf(&sb, "    valueFrom:\n      secretKeyRef:\n        name: %s\n        key: %s\n", secret, spec.Key)
+			continue
+		}
+
+		fmt.Fprintf(&sb, "    value: %s\n", strconv.Quote(exampleValue(spec)))
+	}
+
+	return sb.String(), nil
+}
+
+// KubernetesEnvFrom returns the envFrom: fragment of a Kubernetes container spec
+// referencing the named ConfigMap and Secret. Empty names are omitted.
+func KubernetesEnvFrom(configMap, secret string) string {
+	var sb strings.Builder
+
+	sb.WriteString("envFrom:\n")
+	if configMap != "" {
+		fmt.Fprintf(&sb, "  - configMapRef:\n      name: %s\n", configMap)
+	}
+
+	if secret != "" {
+		fmt.Fprintf(&sb, "  - secretRef:\n      name: %s\n", secret)
+	}
+
+	return sb.String()
+}
+
+// KubernetesConfigMap returns a ConfigMap skeleton with an entry for every
+// non-sensitive variable the provided struct can be configured with.
+func KubernetesConfigMap(cfg any, name string, opts ...Option) (string, error) {
+	return kubernetesResource("ConfigMap", "data", false, cfg, name, opts...)
+}
+
+// KubernetesSecret returns a Secret skeleton with an entry for every
+// sensitive variable the provided struct can be configured with.
+func KubernetesSecret(cfg any, name string, opts ...Option) (string, error) {
+	return kubernetesResource("Secret", "stringData", true, cfg, name, opts...)
+}
+
+func kubernetesResource(kind, dataKey string, sensitive bool, cfg any, name string, opts ...Option) (string, error) {
+	specs, err := fieldSpecs(cfg, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "apiVersion: v1\nkind: %s\nmetadata:\n  name: %s\n%s:\n", kind, name, dataKey)
+	for _, spec := range specs {
+		if spec.Sensitive != sensitive {
+			continue
+		}
+
+		writeComment(&sb, spec.Description)
+		fmt.Fprintf(&sb, "  %s: %s\n", spec.Key, strconv.Quote(exampleValue(spec)))
+	}
+
+	return sb.String(), nil
+}
+
+// writeComment writes the description as YAML comments, one per line.
+func writeComment(sb *strings.Builder, desc string) {
+	if desc == "" {
+		return
+	}
+
+	for _, line := range strings.Split(desc, "\n") {
+		fmt.Fprintf(sb, "  # %s\n", line)
+	}
+}
@@ -0,0 +1,125 @@
+package envcfg_test
+
+import (
+	"testing"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kubernetesConfig struct {
+	Port     int    `desc:"Port to listen on\nDefaults to 8080" default:"8080"`
+	Password string `env:",sensitive" desc:"Database password"`
+}
+
+func TestKubernetesEnv(t *testing.T) {
+	env, err := envcfg.KubernetesEnv(&usageConfig{}, "app-secrets")
+
+	require.NoError(t, err)
+	assert.Equal(t, `env:
+  # Port to listen on
+  - name: PORT
+    value: "8080"
+  # Request timeout
+  - name: TIMEOUT
+    value: "30s"
+  - name: REDIS_HOST
+    value: ""
+`, env)
+
+	_, err = envcfg.KubernetesEnv("not a struct", "app-secrets")
+	assert.ErrorIs(t, err, errs.ErrNotAPointer)
+}
+
+func TestKubernetesEnvSensitive(t *testing.T) {
+	env, err := envcfg.KubernetesEnv(&kubernetesConfig{}, "app-secrets")
+
+	require.NoError(t, err)
+	assert.Equal(t, `env:
+  # Port to listen on
+  # Defaults to 8080
+  - name: PORT
+    value: "8080"
+  # Database password
+  - name: PASSWORD
+    valueFrom:
+      secretKeyRef:
+        name: app-secrets
+        key: PASSWORD
+`, env)
+}
+
+func TestKubernetesEnvFrom(t *testing.T) {
+	assert.Equal(t, `envFrom:
+  - configMapRef:
+      name: app-config
+  - secretRef:
+      name: app-secrets
+`, envcfg.KubernetesEnvFrom("app-config", "app-secrets"))
+
+	assert.Equal(t, `envFrom:
+  - secretRef:
+      name: app-secrets
+`, envcfg.KubernetesEnvFrom("", "app-secrets"))
+}
+
+func TestKubernetesConfigMap(t *testing.T) {
+	cm, err := envcfg.KubernetesConfigMap(&usageConfig{}, "app-config")
+
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  # Port to listen on
+  PORT: "8080"
+  # Request timeout
+  TIMEOUT: "30s"
+  REDIS_HOST: ""
+`, cm)
+}
+
+func TestKubernetesSensitiveSplit(t *testing.T) {
+	cm, err := envcfg.KubernetesConfigMap(&kubernetesConfig{}, "app-config")
+
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  # Port to listen on
+  # Defaults to 8080
+  PORT: "8080"
+`, cm)
+
+	secret, err := envcfg.KubernetesSecret(&kubernetesConfig{}, "app-secrets")
+
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secrets
+stringData:
+  # Database password
+  PASSWORD: ""
+`, secret)
+}
+
+func TestKubernetesSecret(t *testing.T) {
+	secret, err := envcfg.KubernetesSecret(&usageConfig{}, "app-secrets")
+
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secrets
+stringData:
+`, secret)
+
+	_, err = envcfg.KubernetesSecret("not a struct", "app-secrets")
+	assert.ErrorIs(t, err, errs.ErrNotAPointer)
+}
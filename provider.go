@@ -0,0 +1,58 @@
+package envcfg
+
+import (
+	"errors"
+
+	"github.com/sethpollack/envcfg/internal/loader"
+	"github.com/sethpollack/envcfg/sources/osenv"
+)
+
+// Provider exposes an envcfg loader, with its sources, filters and transforms,
+// as a config provider for other configuration libraries.
+//
+// It satisfies the koanf Provider interface:
+//
+//	k.Load(envcfg.NewProvider(envcfg.WithPrefix("APP_")), nil)
+//
+// and can be merged into viper:
+//
+//	m, err := envcfg.NewProvider(envcfg.WithPrefix("APP_")).Read()
+//	v.MergeConfigMap(m)
+type Provider struct {
+	loader *loader.Loader
+}
+
+// NewProvider creates a Provider from the given loader options.
+// If no sources are provided, the OS environment is used unless
+// WithNoDefaultSource is given.
+func NewProvider(opts ...LoaderOption) *Provider {
+	l := &loader.Loader{DefaultSource: osenv.New()}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	l.Wrap()
+
+	return &Provider{loader: l}
+}
+
+// Read returns the loaded environment variables as a flat map.
+func (p *Provider) Read() (map[string]any, error) {
+	envs, err := p.loader.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]any, len(envs))
+	for k, v := range envs {
+		m[k] = v
+	}
+
+	return m, nil
+}
+
+// ReadBytes is not supported by the Provider.
+func (p *Provider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("envcfg provider does not support this method")
+}
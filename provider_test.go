@@ -0,0 +1,69 @@
+package envcfg_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider(t *testing.T) {
+	t.Run("read", func(t *testing.T) {
+		p := envcfg.NewProvider(
+			envcfg.WithMapEnvSource(map[string]string{"APP_PORT": "8080", "OTHER": "value"}),
+			envcfg.WithPrefix("APP_"),
+		)
+
+		m, err := p.Read()
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"PORT": "8080"}, m)
+	})
+
+	t.Run("os env by default", func(t *testing.T) {
+		t.Setenv("PROVIDER_FIELD", "value")
+
+		m, err := envcfg.NewProvider().Read()
+
+		require.NoError(t, err)
+		assert.Equal(t, "value", m["PROVIDER_FIELD"])
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		upper := func(s sources.Source) sources.Source {
+			return sources.Func(func(ctx context.Context) (map[string]string, error) {
+				envs, err := sources.LoadContext(ctx, s)
+				for k, v := range envs {
+					envs[k] = strings.ToUpper(v)
+				}
+
+				return envs, err
+			})
+		}
+
+		m, err := envcfg.NewProvider(
+			envcfg.WithMapEnvSource(map[string]string{"PORT": "value"}),
+			envcfg.WithMiddleware(upper),
+		).Read()
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"PORT": "VALUE"}, m)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		_, err := envcfg.NewProvider(envcfg.WithSource(&customSource{})).Read()
+
+		assert.ErrorIs(t, err, errs.ErrLoadEnv)
+	})
+
+	t.Run("read bytes", func(t *testing.T) {
+		_, err := envcfg.NewProvider().ReadBytes()
+
+		assert.Error(t, err)
+	})
+}
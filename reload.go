@@ -0,0 +1,212 @@
+package envcfg
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sethpollack/envcfg/internal/loader"
+)
+
+// Value holds a configuration that can be replaced while it is being read,
+// so that readers always get a consistent snapshot of the latest one.
+type Value[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// Load returns the current configuration, or nil before one is stored.
+// The returned value must not be modified.
+func (v *Value[T]) Load() *T {
+	return v.p.Load()
+}
+
+// Store replaces the configuration.
+func (v *Value[T]) Store(cfg *T) {
+	v.p.Store(cfg)
+}
+
+// Reloader holds a parsed configuration that can be re-parsed and swapped
+// atomically while it is being read.
+type Reloader[T any] struct {
+	opts []Option
+	cfg  Value[T]
+
+	// reloadMu serializes reloads, so that changes are computed against
+	// the configuration they replace.
+	reloadMu sync.Mutex
+
+	mu       sync.Mutex
+	files    []string
+	onChange []func([]Change)
+}
+
+// NewReloader parses the initial configuration into a new Reloader.
+func NewReloader[T any](opts ...Option) (*Reloader[T], error) {
+	return NewReloaderContext[T](context.Background(), opts...)
+}
+
+// NewReloaderContext is like NewReloader, but passes ctx to sources
+// implementing sources.ContextSource while parsing the initial configuration.
+func NewReloaderContext[T any](ctx context.Context, opts ...Option) (*Reloader[T], error) {
+	r := &Reloader[T]{opts: opts}
+
+	if err := r.ReloadContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Get returns the current configuration. The returned value must not be
+// modified.
+func (r *Reloader[T]) Get() *T {
+	return r.cfg.Load()
+}
+
+// Reload re-parses the configuration and swaps it in. The current
+// configuration is kept if parsing fails.
+func (r *Reloader[T]) Reload() error {
+	return r.ReloadContext(context.Background())
+}
+
+// ReloadContext is like Reload, but passes ctx to sources implementing
+// sources.ContextSource. Concurrent reloads run one at a time.
+func (r *Reloader[T]) ReloadContext(ctx context.Context) error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	var cfg T
+
+	o, err := parse(ctx, &cfg, r.opts...)
+	if err != nil {
+		return err
+	}
+
+	files := append(loader.Files(o.Loader), o.Matcher.Files()...)
+	slices.Sort(files)
+
+	r.mu.Lock()
+	r.files = slices.Compact(files)
+	onChange := slices.Clone(r.onChange)
+	r.mu.Unlock()
+
+	old := r.cfg.Load()
+	r.cfg.Store(&cfg)
+
+	if old == nil || len(onChange) == 0 {
+		return nil
+	}
+
+	changes, err := Diff(old, &cfg, r.opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		for _, fn := range onChange {
+			fn(changes)
+		}
+	}
+
+	return nil
+}
+
+// OnChange registers a function called with the changed fields after a
+// reload changes the configuration, see Diff. It is called while the reload
+// is in progress and must not reload the configuration itself.
+func (r *Reloader[T]) OnChange(fn func(changes []Change)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onChange = append(r.onChange, fn)
+}
+
+// Value returns the holder of the current configuration, which reloads
+// update. Pass it to request handlers to read the latest configuration.
+func (r *Reloader[T]) Value() *Value[T] {
+	return &r.cfg
+}
+
+// Files returns the OS files and directories the current configuration was
+// read from: those of sources implementing sources.FileSource, such as dotenv
+// files and mounted secret directories, and those of file tags. Watch them
+// to reload the configuration when they change.
+func (r *Reloader[T]) Files() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.files)
+}
+
+// ReloadOn reloads the configuration every time trigger receives, until ctx
+// is done or trigger is closed, e.g. with the events of a file watcher.
+// Sources are loaded with ctx and reload errors are passed to onError, which
+// may be nil.
+func (r *Reloader[T]) ReloadOn(ctx context.Context, trigger <-chan struct{}, onError func(error)) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-trigger:
+				if !ok {
+					return
+				}
+
+				if err := r.ReloadContext(ctx); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+}
+
+// ReloadOnSignal reloads the configuration every time one of the given
+// signals is received, until ctx is done. SIGHUP and SIGUSR2 are used on unix
+// systems when no signals are given. Sources are loaded with ctx and reload
+// errors are passed to onError, which may be nil.
+func (r *Reloader[T]) ReloadOnSignal(ctx context.Context, onError func(error), signals ...os.Signal) {
+	r.ReloadOn(ctx, ReloadSignals(ctx, signals...), onError)
+}
+
+// ReloadSignals returns a channel that receives every time one of the given
+// signals is received, for ReloadOn, until ctx is done and the channel is
+// closed. SIGHUP and SIGUSR2 are used on unix systems when no signals are
+// given, on other systems the channel is closed right away.
+func ReloadSignals(ctx context.Context, signals ...os.Signal) <-chan struct{} {
+	if len(signals) == 0 {
+		signals = reloadSignals
+	}
+
+	trigger := make(chan struct{}, 1)
+
+	if len(signals) == 0 {
+		close(trigger)
+		return trigger
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		defer close(trigger)
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return trigger
+}
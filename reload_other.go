@@ -0,0 +1,7 @@
+//go:build !unix
+
+package envcfg
+
+import "os"
+
+var reloadSignals []os.Signal
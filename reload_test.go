@@ -0,0 +1,229 @@
+package envcfg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg"
+	"github.com/sethpollack/envcfg/sources/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader(t *testing.T) {
+	type Config struct {
+		Port int `required:"true"`
+	}
+
+	errLoad := errors.New("load failed")
+
+	src := mock.New(
+		mock.Response{Values: map[string]string{"PORT": "8080"}},
+		mock.Response{Values: map[string]string{"PORT": "9090"}},
+		mock.Response{Err: errLoad},
+	)
+
+	r, err := envcfg.NewReloader[Config](
+		envcfg.WithLoader(envcfg.WithSource(src)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 8080, r.Get().Port)
+
+	require.NoError(t, r.Reload())
+	assert.Equal(t, 9090, r.Get().Port)
+
+	assert.ErrorIs(t, r.Reload(), errLoad)
+	assert.Equal(t, 9090, r.Get().Port)
+}
+
+func TestReloadContext(t *testing.T) {
+	type Config struct {
+		Port int `required:"true"`
+	}
+
+	src := mock.New(
+		mock.Response{Values: map[string]string{"PORT": "8080"}},
+		mock.Response{Values: map[string]string{"PORT": "9090"}, Delay: time.Minute},
+	)
+
+	r, err := envcfg.NewReloaderContext[Config](context.Background(),
+		envcfg.WithLoader(envcfg.WithSource(src)),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, r.ReloadContext(ctx), context.Canceled)
+	assert.Equal(t, 8080, r.Get().Port)
+}
+
+func TestValue(t *testing.T) {
+	type Config struct {
+		Port int
+	}
+
+	var v envcfg.Value[Config]
+	assert.Nil(t, v.Load())
+
+	v.Store(&Config{Port: 8080})
+	assert.Equal(t, 8080, v.Load().Port)
+
+	src := mock.New(
+		mock.Response{Values: map[string]string{"PORT": "8080"}},
+		mock.Response{Values: map[string]string{"PORT": "9090"}},
+	)
+
+	r, err := envcfg.NewReloader[Config](envcfg.WithLoader(envcfg.WithSource(src)))
+	require.NoError(t, err)
+
+	value := r.Value()
+	assert.Equal(t, 8080, value.Load().Port)
+
+	require.NoError(t, r.Reload())
+	assert.Equal(t, 9090, value.Load().Port)
+	assert.Same(t, r.Get(), value.Load())
+}
+
+func TestReloaderOnChange(t *testing.T) {
+	type Config struct {
+		Port  int
+		Token string `sensitive:"true"`
+	}
+
+	src := mock.New(
+		mock.Response{Values: map[string]string{"PORT": "8080", "TOKEN": "a"}},
+		mock.Response{Values: map[string]string{"PORT": "8080", "TOKEN": "b"}},
+		mock.Response{Values: map[string]string{"PORT": "8080", "TOKEN": "b"}},
+	)
+
+	r, err := envcfg.NewReloader[Config](envcfg.WithLoader(envcfg.WithSource(src)))
+	require.NoError(t, err)
+
+	var changes [][]envcfg.Change
+	r.OnChange(func(c []envcfg.Change) {
+		changes = append(changes, c)
+	})
+
+	require.NoError(t, r.Reload())
+	require.NoError(t, r.Reload())
+
+	assert.Equal(t, [][]envcfg.Change{
+		{{Field: "Token", Key: "TOKEN", Old: envcfg.Mask, New: envcfg.Mask}},
+	}, changes)
+}
+
+func TestReloaderConcurrentReloads(t *testing.T) {
+	type Config struct {
+		Port int
+	}
+
+	const reloads = 10
+
+	src := mock.New(mock.Response{Values: map[string]string{"PORT": "0"}})
+	for i := 1; i <= reloads; i++ {
+		// earlier loads are slower, so that unserialized reloads would
+		// store them after later ones.
+		src.Push(mock.Response{
+			Values: map[string]string{"PORT": strconv.Itoa(i)},
+			Delay:  time.Duration(reloads-i) * time.Millisecond,
+		})
+	}
+
+	r, err := envcfg.NewReloader[Config](envcfg.WithLoader(envcfg.WithSource(src)))
+	require.NoError(t, err)
+
+	var changes []envcfg.Change
+	r.OnChange(func(c []envcfg.Change) {
+		changes = append(changes, c...)
+	})
+
+	var wg sync.WaitGroup
+	for range reloads {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, r.Reload())
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, reloads, r.Get().Port)
+	require.Len(t, changes, reloads)
+
+	for i, c := range changes {
+		assert.Equal(t, i, c.Old)
+		assert.Equal(t, i+1, c.New)
+	}
+}
+
+func TestReloaderFiles(t *testing.T) {
+	type Config struct {
+		Port     int
+		Password string `file:"true"`
+	}
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	passwordFile := filepath.Join(dir, "password")
+
+	require.NoError(t, os.WriteFile(envFile, []byte("PORT=8080\nPASSWORD="+passwordFile), 0o600))
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret"), 0o600))
+
+	r, err := envcfg.NewReloader[Config](
+		envcfg.WithLoader(
+			envcfg.WithDotEnvSource(envFile),
+			envcfg.WithMapEnvSource(map[string]string{}),
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", r.Get().Password)
+	assert.Equal(t, []string{envFile, passwordFile}, r.Files())
+}
+
+func TestReloadOn(t *testing.T) {
+	type Config struct {
+		Port int `required:"true"`
+	}
+
+	src := mock.New(
+		mock.Response{Values: map[string]string{"PORT": "8080"}},
+		mock.Response{Values: map[string]string{"PORT": "9090"}},
+	)
+
+	r, err := envcfg.NewReloader[Config](
+		envcfg.WithLoader(envcfg.WithSource(src)),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	trigger := make(chan struct{})
+	r.ReloadOn(ctx, trigger, nil)
+
+	trigger <- struct{}{}
+
+	assert.Eventually(t, func() bool {
+		return r.Get().Port == 9090
+	}, time.Second, time.Millisecond)
+}
+
+func TestNewReloaderError(t *testing.T) {
+	type Config struct {
+		Port int `required:"true"`
+	}
+
+	_, err := envcfg.NewReloader[Config](
+		envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{})),
+	)
+	assert.Error(t, err)
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package envcfg
+
+import (
+	"os"
+	"syscall"
+)
+
+var reloadSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR2}
@@ -0,0 +1,63 @@
+//go:build unix
+
+package envcfg_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg"
+	"github.com/sethpollack/envcfg/sources/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadOnSignal(t *testing.T) {
+	type Config struct {
+		Port int
+	}
+
+	src := mock.New(
+		mock.Response{Values: map[string]string{"PORT": "8080"}},
+		mock.Response{Values: map[string]string{"PORT": "9090"}},
+	)
+
+	r, err := envcfg.NewReloader[Config](
+		envcfg.WithLoader(envcfg.WithSource(src)),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r.ReloadOnSignal(ctx, nil, syscall.SIGUSR2)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+
+	assert.Eventually(t, func() bool {
+		return r.Get().Port == 9090
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReloadSignals(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	trigger := envcfg.ReloadSignals(ctx, syscall.SIGUSR1)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case <-trigger:
+	case <-time.After(time.Second):
+		t.Fatal("no reload triggered")
+	}
+
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		_, ok := <-trigger
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
-	"github.com/sethpollack/envcfg/internal/loader"
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/sethpollack/envcfg/sources/cache"
 )
 
-var _ loader.Source = (*source)(nil)
+var _ sources.ContextSource = (*source)(nil)
 
 type Client interface {
 	GetSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
@@ -32,10 +34,34 @@ func WithRegion(region string) Option {
 	}
 }
 
-// WithSecretID sets the ID or ARN of the secret to load
-func WithSecretID(id string) Option {
+// WithSecretID adds the ID or ARN of a secret to load. It can be given
+// multiple times, later secrets take precedence.
+func WithSecretID(id string, opts ...SecretOption) Option {
 	return func(s *source) {
-		s.secretID = id
+		sec := secret{id: id}
+
+		for _, opt := range opts {
+			opt(&sec)
+		}
+
+		s.secrets = append(s.secrets, sec)
+	}
+}
+
+type SecretOption func(*secret)
+
+// WithKey loads the whole secret value into the given key, for plain
+// (non-JSON) string and binary secrets.
+func WithKey(key string) SecretOption {
+	return func(s *secret) {
+		s.key = key
+	}
+}
+
+// WithPrefix prefixes the keys loaded from the secret.
+func WithPrefix(prefix string) SecretOption {
+	return func(s *secret) {
+		s.prefix = prefix
 	}
 }
 
@@ -46,11 +72,31 @@ func WithProfile(profile string) Option {
 	}
 }
 
+// WithCache caches the loaded secrets for ttl, so that repeated Parse calls
+// or reloads don't call Secrets Manager every time.
+// cache.WithStaleWhileRevalidate serves expired secrets while they are refreshed.
+func WithCache(ttl time.Duration, opts ...cache.Option) Option {
+	return func(s *source) {
+		s.cacheTTL = ttl
+		s.cacheOpts = opts
+	}
+}
+
+type secret struct {
+	id     string
+	key    string
+	prefix string
+}
+
 type source struct {
-	client   Client
-	region   string
-	secretID string
-	profile  string
+	client  Client
+	region  string
+	profile string
+	secrets []secret
+
+	cacheTTL  time.Duration
+	cacheOpts []cache.Option
+	cached    sources.ContextSource
 }
 
 func New(opts ...Option) *source {
@@ -60,10 +106,39 @@ func New(opts ...Option) *source {
 		opt(s)
 	}
 
+	if s.cacheTTL > 0 {
+		s.cached = cache.New(fetcher{s}, s.cacheTTL, s.cacheOpts...)
+	}
+
 	return s
 }
 
 func (s *source) Load() (map[string]string, error) {
+	return s.LoadContext(context.Background())
+}
+
+func (s *source) LoadContext(ctx context.Context) (map[string]string, error) {
+	if s.cached != nil {
+		return s.cached.LoadContext(ctx)
+	}
+
+	return s.fetch(ctx)
+}
+
+// fetcher loads the secrets without the cache.
+type fetcher struct {
+	s *source
+}
+
+func (f fetcher) Load() (map[string]string, error) {
+	return f.s.fetch(context.Background())
+}
+
+func (f fetcher) LoadContext(ctx context.Context) (map[string]string, error) {
+	return f.s.fetch(ctx)
+}
+
+func (s *source) fetch(ctx context.Context) (map[string]string, error) {
 	if s.client == nil {
 		var cfgOpts []func(*config.LoadOptions) error
 
@@ -73,7 +148,7 @@ func (s *source) Load() (map[string]string, error) {
 			cfgOpts = append(cfgOpts, config.WithSharedConfigProfile(s.profile))
 		}
 
-		cfg, err := config.LoadDefaultConfig(context.Background(), cfgOpts...)
+		cfg, err := config.LoadDefaultConfig(ctx, cfgOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load AWS config: %w", err)
 		}
@@ -81,21 +156,48 @@ func (s *source) Load() (map[string]string, error) {
 		s.client = secretsmanager.NewFromConfig(cfg)
 	}
 
+	envs := make(map[string]string)
+
+	for _, sec := range s.secrets {
+		values, err := s.loadSecret(ctx, sec)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range values {
+			envs[sec.prefix+k] = v
+		}
+	}
+
+	return envs, nil
+}
+
+func (s *source) loadSecret(ctx context.Context, sec secret) (map[string]string, error) {
 	input := &secretsmanager.GetSecretValueInput{
-		SecretId: &s.secretID,
+		SecretId: &sec.id,
 	}
 
-	result, err := s.client.GetSecretValue(context.Background(), input)
+	result, err := s.client.GetSecretValue(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secret value: %w", err)
 	}
 
-	if result.SecretString == nil {
-		return nil, fmt.Errorf("secret string is nil")
+	var payload []byte
+	switch {
+	case result.SecretString != nil:
+		payload = []byte(*result.SecretString)
+	case result.SecretBinary != nil:
+		payload = result.SecretBinary
+	default:
+		return nil, fmt.Errorf("secret %s has no value", sec.id)
+	}
+
+	if sec.key != "" {
+		return map[string]string{sec.key: string(payload)}, nil
 	}
 
 	secretData := make(map[string]string)
-	if err := json.Unmarshal([]byte(*result.SecretString), &secretData); err != nil {
+	if err := json.Unmarshal(payload, &secretData); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal secret data: %w", err)
 	}
 
@@ -3,6 +3,7 @@ package awssm
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/stretchr/testify/assert"
@@ -10,11 +11,21 @@ import (
 )
 
 type mockClient struct {
-	secret *string
-	err    error
+	secret  *string
+	err     error
+	outputs map[string]*secretsmanager.GetSecretValueOutput
 }
 
 func (m *mockClient) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	if m.outputs != nil {
+		output, ok := m.outputs[*params.SecretId]
+		if !ok {
+			return nil, assert.AnError
+		}
+
+		return output, nil
+	}
+
 	return &secretsmanager.GetSecretValueOutput{
 		SecretString: m.secret,
 	}, m.err
@@ -32,13 +43,17 @@ func TestNew(t *testing.T) {
 				WithClient(&mockClient{}),
 				WithRegion("us-west-2"),
 				WithSecretID("test-secret"),
+				WithSecretID("other-secret", WithKey("KEY"), WithPrefix("DB_")),
 				WithProfile("test-profile"),
 			},
 			expected: &source{
-				client:   &mockClient{},
-				region:   "us-west-2",
-				secretID: "test-secret",
-				profile:  "test-profile",
+				client: &mockClient{},
+				region: "us-west-2",
+				secrets: []secret{
+					{id: "test-secret"},
+					{id: "other-secret", key: "KEY", prefix: "DB_"},
+				},
+				profile: "test-profile",
 			},
 		},
 		{
@@ -68,6 +83,7 @@ func TestLoad(t *testing.T) {
 		{
 			name: "success",
 			source: source{
+				secrets: []secret{{id: "test-secret"}},
 				client: &mockClient{
 					secret: strPtr(`{"key1":"value1","key2":"value2"}`),
 				},
@@ -80,6 +96,7 @@ func TestLoad(t *testing.T) {
 		{
 			name: "nil secret",
 			source: source{
+				secrets: []secret{{id: "test-secret"}},
 				client: &mockClient{
 					secret: nil,
 				},
@@ -89,6 +106,7 @@ func TestLoad(t *testing.T) {
 		{
 			name: "invalid json",
 			source: source{
+				secrets: []secret{{id: "test-secret"}},
 				client: &mockClient{
 					secret: strPtr(`{"key1":"value1","key2":"value2`),
 				},
@@ -98,6 +116,7 @@ func TestLoad(t *testing.T) {
 		{
 			name: "error",
 			source: source{
+				secrets: []secret{{id: "test-secret"}},
 				client: &mockClient{
 					err: assert.AnError,
 				},
@@ -107,7 +126,8 @@ func TestLoad(t *testing.T) {
 		{
 			name: "nil client",
 			source: source{
-				client: nil,
+				secrets: []secret{{id: "test-secret"}},
+				client:  nil,
 			},
 			expectError: true,
 		},
@@ -126,6 +146,90 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadSecrets(t *testing.T) {
+	client := &mockClient{outputs: map[string]*secretsmanager.GetSecretValueOutput{
+		"json":   {SecretString: strPtr(`{"HOST":"localhost","PORT":"5432"}`)},
+		"plain":  {SecretString: strPtr("plain-secret")},
+		"binary": {SecretBinary: []byte(`{"TOKEN":"binary"}`)},
+		"cert":   {SecretBinary: []byte("-----BEGIN CERTIFICATE-----")},
+		"empty":  {},
+	}}
+
+	tt := []struct {
+		name        string
+		opts        []Option
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name: "multiple secrets with prefixes",
+			opts: []Option{
+				WithSecretID("json", WithPrefix("DB_")),
+				WithSecretID("binary"),
+				WithSecretID("plain", WithKey("API_KEY")),
+				WithSecretID("cert", WithKey("TLS_CERT"), WithPrefix("APP_")),
+			},
+			expected: map[string]string{
+				"DB_HOST":      "localhost",
+				"DB_PORT":      "5432",
+				"TOKEN":        "binary",
+				"API_KEY":      "plain-secret",
+				"APP_TLS_CERT": "-----BEGIN CERTIFICATE-----",
+			},
+		},
+		{
+			name:        "plain secret without key",
+			opts:        []Option{WithSecretID("plain")},
+			expectError: true,
+		},
+		{
+			name:        "secret without value",
+			opts:        []Option{WithSecretID("empty")},
+			expectError: true,
+		},
+		{
+			name:        "missing secret",
+			opts:        []Option{WithSecretID("json"), WithSecretID("missing")},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			envs, err := New(append(tc.opts, WithClient(client))...).Load()
+			if tc.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, envs)
+			}
+		})
+	}
+}
+
 func strPtr(s string) *string {
 	return &s
 }
+
+type countingClient struct {
+	calls int
+}
+
+func (c *countingClient) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	c.calls++
+	return &secretsmanager.GetSecretValueOutput{SecretString: strPtr(`{"KEY":"value"}`)}, nil
+}
+
+func TestLoadCache(t *testing.T) {
+	client := &countingClient{}
+
+	s := New(WithClient(client), WithSecretID("test"), WithCache(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		envs, err := s.Load()
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"KEY": "value"}, envs)
+	}
+
+	assert.Equal(t, 1, client.calls)
+}
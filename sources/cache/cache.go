@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.ContextSource = (*source)(nil)
+
+type Option func(*source)
+
+// WithStaleWhileRevalidate keeps serving expired values for up to d after
+// they expire while they are refreshed in the background.
+func WithStaleWhileRevalidate(d time.Duration) Option {
+	return func(s *source) {
+		s.stale = d
+	}
+}
+
+type source struct {
+	src   sources.Source
+	ttl   time.Duration
+	stale time.Duration
+	now   func() time.Time
+
+	mu         sync.Mutex
+	values     map[string]string
+	fetched    time.Time
+	refreshing bool
+}
+
+// New caches the values of src for ttl, so that repeated loads, such as
+// reloads, don't call a remote source every time. Failed loads are not cached.
+func New(src sources.Source, ttl time.Duration, opts ...Option) *source {
+	s := &source{
+		src: src,
+		ttl: ttl,
+		now: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *source) Load() (map[string]string, error) {
+	return s.LoadContext(context.Background())
+}
+
+func (s *source) LoadContext(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+
+	if s.values != nil {
+		age := s.now().Sub(s.fetched)
+
+		if age < s.ttl {
+			defer s.mu.Unlock()
+			return copyMap(s.values), nil
+		}
+
+		if age < s.ttl+s.stale {
+			defer s.mu.Unlock()
+
+			if !s.refreshing {
+				s.refreshing = true
+				go s.refresh()
+			}
+
+			return copyMap(s.values), nil
+		}
+	}
+
+	s.mu.Unlock()
+
+	values, err := sources.LoadContext(ctx, s.src)
+	if err != nil {
+		return nil, err
+	}
+
+	s.store(values)
+
+	return copyMap(values), nil
+}
+
+// refresh reloads the values in the background, keeping the
+// stale values when the load fails.
+func (s *source) refresh() {
+	values, err := sources.LoadContext(context.Background(), s.src)
+
+	s.mu.Lock()
+	s.refreshing = false
+	s.mu.Unlock()
+
+	if err == nil {
+		s.store(values)
+	}
+}
+
+func (s *source) store(values map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values = values
+	s.fetched = s.now()
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+
+	return c
+}
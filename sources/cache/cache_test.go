@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg/sources/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newCached(src *mock.Source, ttl time.Duration, opts ...Option) (*source, *clock) {
+	c := &clock{now: time.Unix(0, 0)}
+
+	s := New(src, ttl, opts...)
+	s.now = c.Now
+
+	return s, c
+}
+
+func TestLoad(t *testing.T) {
+	src := mock.New(
+		mock.Response{Values: map[string]string{"KEY": "first"}},
+		mock.Response{Values: map[string]string{"KEY": "second"}},
+	)
+
+	s, c := newCached(src, time.Minute)
+
+	envs, err := s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "first"}, envs)
+
+	envs["KEY"] = "modified"
+
+	c.now = c.now.Add(30 * time.Second)
+
+	envs, err = s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "first"}, envs)
+	assert.Equal(t, 1, src.Calls())
+
+	c.now = c.now.Add(time.Minute)
+
+	envs, err = s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "second"}, envs)
+	assert.Equal(t, 2, src.Calls())
+}
+
+func TestLoadErrorNotCached(t *testing.T) {
+	src := mock.New(
+		mock.Response{Err: errors.New("unavailable")},
+		mock.Response{Values: map[string]string{"KEY": "value"}},
+	)
+
+	s, _ := newCached(src, time.Minute)
+
+	_, err := s.Load()
+	require.Error(t, err)
+
+	envs, err := s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, envs)
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	src := mock.New(
+		mock.Response{Values: map[string]string{"KEY": "first"}},
+		mock.Response{Values: map[string]string{"KEY": "second"}},
+	)
+
+	s, c := newCached(src, time.Minute, WithStaleWhileRevalidate(time.Minute))
+
+	_, err := s.Load()
+	require.NoError(t, err)
+
+	c.now = c.now.Add(90 * time.Second)
+
+	envs, err := s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "first"}, envs)
+
+	assert.Eventually(t, func() bool {
+		envs, err := s.Load()
+		return err == nil && envs["KEY"] == "second"
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, 2, src.Calls())
+}
+
+func TestStaleExpired(t *testing.T) {
+	src := mock.New(
+		mock.Response{Values: map[string]string{"KEY": "first"}},
+		mock.Response{Err: errors.New("unavailable")},
+	)
+
+	s, c := newCached(src, time.Minute, WithStaleWhileRevalidate(time.Minute))
+
+	_, err := s.Load()
+	require.NoError(t, err)
+
+	c.now = c.now.Add(3 * time.Minute)
+
+	_, err = s.Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package dockersecrets
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.Source = (*source)(nil)
+var _ sources.FileSource = (*source)(nil)
+
+// DefaultDir is the directory Docker and Swarm mount secrets in.
+const DefaultDir = "/run/secrets"
+
+type Option func(*source)
+
+// WithDir sets the directory to read secrets from.
+func WithDir(dir string) Option {
+	return func(s *source) {
+		s.fsys = os.DirFS(dir)
+		s.dir = dir
+	}
+}
+
+// WithFS reads secrets from the root of the provided filesystem.
+func WithFS(fsys fs.FS) Option {
+	return func(s *source) {
+		s.fsys = fsys
+		s.dir = ""
+	}
+}
+
+// WithAllowlist only loads the named secrets.
+func WithAllowlist(names ...string) Option {
+	return func(s *source) {
+		if s.allow == nil {
+			s.allow = map[string]bool{}
+		}
+
+		for _, name := range names {
+			s.allow[name] = true
+		}
+	}
+}
+
+type source struct {
+	fsys  fs.FS
+	dir   string
+	allow map[string]bool
+}
+
+// New reads the secrets mounted in /run/secrets. Secret file names are
+// upper cased, with dashes and dots replaced by underscores, to form the
+// environment variable names, e.g. db-password becomes DB_PASSWORD.
+func New(opts ...Option) *source {
+	s := &source{
+		fsys: os.DirFS(DefaultDir),
+		dir:  DefaultDir,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Files returns the secrets directory, unless the secrets are read from a
+// filesystem other than the OS one.
+func (s *source) Files() []string {
+	if s.dir == "" {
+		return nil
+	}
+
+	return []string{s.dir}
+}
+
+func (s *source) Load() (map[string]string, error) {
+	files, err := sources.ReadDir(s.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string, len(files))
+	for name, value := range files {
+		if s.allow != nil && !s.allow[name] {
+			continue
+		}
+
+		envs[sources.FileKey(name)] = value
+	}
+
+	return envs, nil
+}
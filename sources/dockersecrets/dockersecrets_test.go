@@ -0,0 +1,59 @@
+package dockersecrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db-password":  {Data: []byte("secret\n")},
+		"api.key":      {Data: []byte("key")},
+		"redis_url":    {Data: []byte("redis://localhost")},
+		"nested/other": {Data: []byte("value")},
+	}
+
+	tt := []struct {
+		name     string
+		opts     []Option
+		expected map[string]string
+	}{
+		{
+			name: "all secrets",
+			expected: map[string]string{
+				"DB_PASSWORD": "secret",
+				"API_KEY":     "key",
+				"REDIS_URL":   "redis://localhost",
+			},
+		},
+		{
+			name: "allowlist",
+			opts: []Option{WithAllowlist("db-password", "missing")},
+			expected: map[string]string{
+				"DB_PASSWORD": "secret",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			envs, err := New(append([]Option{WithFS(fsys)}, tc.opts...)...).Load()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, envs)
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("value"), 0o600))
+
+	envs, err := New(WithDir(dir)).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TOKEN": "value"}, envs)
+}
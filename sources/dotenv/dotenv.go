@@ -1,30 +1,247 @@
 package dotenv
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
-	"github.com/sethpollack/envcfg/internal/loader"
 	"github.com/sethpollack/envcfg/sources"
 )
 
-var _ loader.Source = (*source)(nil)
+var _ sources.Source = (*source)(nil)
+var _ sources.ProfileSource = (*source)(nil)
+var _ sources.Source = (*files)(nil)
+var _ sources.FileSource = (*source)(nil)
+var _ sources.FileSource = (*files)(nil)
+
+type Option func(*source)
+
+// WithInterpolation replaces ${VAR} references in values with the value of
+// VAR defined earlier in the file, or an empty string.
+func WithInterpolation() Option {
+	return func(s *source) {
+		s.interpolation = true
+	}
+}
+
+// WithOSEnvInterpolation is like WithInterpolation but resolves references
+// that are not defined earlier in the file from the OS environment.
+func WithOSEnvInterpolation() Option {
+	return func(s *source) {
+		s.interpolation = true
+		s.osEnv = true
+	}
+}
 
 type source struct {
+	fsys fs.FS
 	path string
+
+	interpolation bool
+	osEnv         bool
 }
 
-func New(path string) *source {
-	return &source{
+// New reads a dotenv file. Values are used as is
+// unless WithInterpolation is given.
+func New(path string, opts ...Option) *source {
+	s := &source{
 		path: path,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// NewFS reads the dotenv file from the provided filesystem
+// such as an embed.FS or fstest.MapFS.
+func NewFS(fsys fs.FS, path string, opts ...Option) *source {
+	s := New(path, opts...)
+	s.fsys = fsys
+
+	return s
+}
+
+// Files returns the path of the file, unless it is read from a
+// filesystem other than the OS one.
+func (s *source) Files() []string {
+	if s.fsys != nil {
+		return nil
+	}
+
+	return []string{s.path}
 }
 
 func (s *source) Load() (map[string]string, error) {
-	bytes, err := os.ReadFile(s.path)
-	if err != nil {
+	envs := make(map[string]string)
+	if err := s.loadInto(envs); err != nil {
 		return nil, err
 	}
 
-	return sources.ToMap(strings.Split(string(bytes), "\n")), nil
+	return envs, nil
+}
+
+// loadInto adds the variables of the file to envs, references
+// are resolved against the variables already in envs.
+func (s *source) loadInto(envs map[string]string) error {
+	bytes, err := s.readFile()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(bytes), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		if s.interpolation {
+			value = s.interpolate(value, envs)
+		}
+
+		envs[key] = value
+	}
+
+	return nil
+}
+
+var reference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func (s *source) interpolate(value string, envs map[string]string) string {
+	return reference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+
+		if v, ok := envs[name]; ok {
+			return v
+		}
+
+		if s.osEnv {
+			return os.Getenv(name)
+		}
+
+		return ""
+	})
+}
+
+// LoadProfile loads the profile variant of the file, e.g. .env.prod
+// for the prod profile. A missing profile file is not an error.
+func (s *source) LoadProfile(profile string) (map[string]string, error) {
+	profiled := *s
+	profiled.path = s.path + "." + profile
+
+	envs, err := profiled.Load()
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+
+	return envs, err
+}
+
+func (s *source) readFile() ([]byte, error) {
+	if s.fsys != nil {
+		return fs.ReadFile(s.fsys, s.path)
+	}
+
+	return os.ReadFile(s.path)
+}
+
+type files struct {
+	fsys     fs.FS
+	patterns []string
+	opts     []Option
+}
+
+// NewFiles loads multiple dotenv files. Each pattern is a path or a glob
+// pattern, files are loaded in order with later files taking precedence and
+// the matches of a glob pattern loaded in lexical order. Missing files are
+// skipped. The options apply to every file, interpolated references
+// also resolve to variables of earlier files.
+func NewFiles(patterns []string, opts ...Option) *files {
+	return &files{
+		patterns: patterns,
+		opts:     opts,
+	}
+}
+
+// NewFilesFS is like NewFiles but reads from the provided filesystem.
+func NewFilesFS(fsys fs.FS, patterns []string, opts ...Option) *files {
+	return &files{
+		fsys:     fsys,
+		patterns: patterns,
+		opts:     opts,
+	}
+}
+
+// Cascade returns the conventional dotenv file cascade for an environment in
+// order of increasing precedence: .env, .env.local, .env.<env> and
+// .env.<env>.local. As in other dotenv libraries, .env.local is skipped for the
+// test environment so that tests are reproducible.
+func Cascade(env string) []string {
+	paths := []string{".env"}
+
+	if env != "test" {
+		paths = append(paths, ".env.local")
+	}
+
+	if env != "" {
+		paths = append(paths, ".env."+env, ".env."+env+".local")
+	}
+
+	return paths
+}
+
+func (f *files) Load() (map[string]string, error) {
+	envs := make(map[string]string)
+
+	for _, pattern := range f.patterns {
+		paths, err := f.glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			err := NewFS(f.fsys, path, f.opts...).loadInto(envs)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return envs, nil
+}
+
+// Files returns the paths of the file patterns, or their directory for glob
+// patterns. Nothing is returned for a filesystem other than the OS one.
+func (f *files) Files() []string {
+	if f.fsys != nil {
+		return nil
+	}
+
+	paths := make([]string, 0, len(f.patterns))
+	for _, pattern := range f.patterns {
+		if strings.ContainsAny(pattern, "*?[\\") {
+			pattern = filepath.Dir(pattern)
+		}
+
+		paths = append(paths, pattern)
+	}
+
+	return paths
+}
+
+func (f *files) glob(pattern string) ([]string, error) {
+	if f.fsys != nil {
+		return fs.Glob(f.fsys, pattern)
+	}
+
+	return filepath.Glob(pattern)
 }
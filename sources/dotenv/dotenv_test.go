@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -73,3 +74,174 @@ func TestLoad(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/.env": &fstest.MapFile{Data: []byte("KEY1=value1\nKEY2=value2")},
+	}
+
+	t.Run("existing file", func(t *testing.T) {
+		result, err := NewFS(fsys, "config/.env").Load()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"KEY1": "value1", "KEY2": "value2"}, result)
+	})
+
+	t.Run("non-existent file", func(t *testing.T) {
+		_, err := NewFS(fsys, "non-existent-file").Load()
+		require.Error(t, err)
+	})
+}
+
+func TestLoadProfile(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":      &fstest.MapFile{Data: []byte("KEY1=value1\nKEY2=value2")},
+		".env.prod": &fstest.MapFile{Data: []byte("KEY2=prod")},
+	}
+
+	t.Run("existing profile", func(t *testing.T) {
+		result, err := NewFS(fsys, ".env").LoadProfile("prod")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"KEY2": "prod"}, result)
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		result, err := NewFS(fsys, ".env").LoadProfile("dev")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{}, result)
+	})
+}
+
+func TestFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":            &fstest.MapFile{Data: []byte("KEY1=env\nKEY2=env\nKEY3=env\nKEY4=env")},
+		".env.local":      &fstest.MapFile{Data: []byte("KEY2=local")},
+		".env.prod":       &fstest.MapFile{Data: []byte("KEY3=prod")},
+		".env.prod.local": &fstest.MapFile{Data: []byte("KEY4=prod.local")},
+		"conf.d/a.env":    &fstest.MapFile{Data: []byte("KEY1=a\nKEY2=a")},
+		"conf.d/b.env":    &fstest.MapFile{Data: []byte("KEY2=b")},
+	}
+
+	tt := []struct {
+		name     string
+		patterns []string
+		expected map[string]string
+	}{
+		{
+			name:     "cascade",
+			patterns: Cascade("prod"),
+			expected: map[string]string{"KEY1": "env", "KEY2": "local", "KEY3": "prod", "KEY4": "prod.local"},
+		},
+		{
+			name:     "cascade test environment skips .env.local",
+			patterns: Cascade("test"),
+			expected: map[string]string{"KEY1": "env", "KEY2": "env", "KEY3": "env", "KEY4": "env"},
+		},
+		{
+			name:     "missing files are skipped",
+			patterns: Cascade("dev"),
+			expected: map[string]string{"KEY1": "env", "KEY2": "local", "KEY3": "env", "KEY4": "env"},
+		},
+		{
+			name:     "glob",
+			patterns: []string{".env", "conf.d/*.env"},
+			expected: map[string]string{"KEY1": "a", "KEY2": "b", "KEY3": "env", "KEY4": "env"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := NewFilesFS(fsys, tc.patterns).Load()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestWatchedFiles(t *testing.T) {
+	assert.Equal(t, []string{".env"}, New(".env").Files())
+	assert.Nil(t, NewFS(fstest.MapFS{}, ".env").Files())
+	assert.Equal(t, []string{".env", "conf.d"}, NewFiles([]string{".env", "conf.d/*.env"}).Files())
+	assert.Nil(t, NewFilesFS(fstest.MapFS{}, []string{".env"}).Files())
+}
+
+func TestCascade(t *testing.T) {
+	assert.Equal(t, []string{".env", ".env.local"}, Cascade(""))
+	assert.Equal(t, []string{".env", ".env.local", ".env.dev", ".env.dev.local"}, Cascade("dev"))
+	assert.Equal(t, []string{".env", ".env.test", ".env.test.local"}, Cascade("test"))
+}
+
+func TestInterpolation(t *testing.T) {
+	t.Setenv("DOTENV_TEST_HOME", "/home/user")
+
+	fsys := fstest.MapFS{
+		".env": &fstest.MapFile{Data: []byte(
+			"HOST=localhost\nURL=http://${HOST}:${PORT}\nPORT=8080\nDIR=${DOTENV_TEST_HOME}/app\nPASS=pa$$word",
+		)},
+	}
+
+	tt := []struct {
+		name     string
+		opts     []Option
+		expected map[string]string
+	}{
+		{
+			name: "disabled by default",
+			expected: map[string]string{
+				"HOST": "localhost",
+				"URL":  "http://${HOST}:${PORT}",
+				"PORT": "8080",
+				"DIR":  "${DOTENV_TEST_HOME}/app",
+				"PASS": "pa$$word",
+			},
+		},
+		{
+			name: "earlier keys",
+			opts: []Option{WithInterpolation()},
+			expected: map[string]string{
+				"HOST": "localhost",
+				"URL":  "http://localhost:",
+				"PORT": "8080",
+				"DIR":  "/app",
+				"PASS": "pa$$word",
+			},
+		},
+		{
+			name: "os env",
+			opts: []Option{WithOSEnvInterpolation()},
+			expected: map[string]string{
+				"HOST": "localhost",
+				"URL":  "http://localhost:",
+				"PORT": "8080",
+				"DIR":  "/home/user/app",
+				"PASS": "pa$$word",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := NewFS(fsys, ".env", tc.opts...).Load()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestFilesInterpolation(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":       &fstest.MapFile{Data: []byte("HOST=localhost\nURL=http://${HOST}")},
+		".env.local": &fstest.MapFile{Data: []byte("DB_URL=postgres://${HOST}/db")},
+	}
+
+	result, err := NewFilesFS(fsys, Cascade(""), WithInterpolation()).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"HOST":   "localhost",
+		"URL":    "http://localhost",
+		"DB_URL": "postgres://localhost/db",
+	}, result)
+
+	result, err = NewFilesFS(fsys, Cascade("")).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://${HOST}/db", result["DB_URL"])
+}
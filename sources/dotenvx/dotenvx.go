@@ -0,0 +1,166 @@
+package dotenvx
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ecies "github.com/ecies/go/v2"
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/sethpollack/envcfg/sources/dotenv"
+)
+
+var _ sources.Source = (*source)(nil)
+
+const (
+	encryptedPrefix = "encrypted:"
+	// publicKeyPrefix is the prefix of the public key variables dotenvx
+	// writes to encrypted files, e.g. DOTENV_PUBLIC_KEY_PRODUCTION.
+	publicKeyPrefix = "DOTENV_PUBLIC_KEY"
+)
+
+type Option func(*source)
+
+// WithPrivateKey sets the hex encoded private key used to decrypt values.
+func WithPrivateKey(key string) Option {
+	return func(s *source) {
+		s.privateKey = key
+	}
+}
+
+// WithPrivateKeyFile reads the private key from a dotenvx .env.keys file,
+// looking up the key variable of the file, e.g. DOTENV_PRIVATE_KEY_PRODUCTION
+// for .env.production.
+func WithPrivateKeyFile(path string) Option {
+	return func(s *source) {
+		s.privateKeyFile = path
+	}
+}
+
+// WithPrivateKeyEnv reads the private key from the named environment variable.
+func WithPrivateKeyEnv(name string) Option {
+	return func(s *source) {
+		s.privateKeyEnv = name
+	}
+}
+
+type source struct {
+	path           string
+	privateKey     string
+	privateKeyFile string
+	privateKeyEnv  string
+}
+
+// New reads a dotenvx encrypted dotenv file, decrypting "encrypted:" values
+// with the file's private key. By default the key is read from
+// DOTENV_PRIVATE_KEY, suffixed with the environment of the file name as
+// dotenvx does, e.g. DOTENV_PRIVATE_KEY_PRODUCTION for .env.production.
+func New(path string, opts ...Option) *source {
+	s := &source{
+		path:          path,
+		privateKeyEnv: privateKeyEnv(path),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *source) Load() (map[string]string, error) {
+	envs, err := dotenv.New(s.path).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var key *ecies.PrivateKey
+
+	for k, v := range envs {
+		// comments and the public keys of the file are not config variables.
+		if strings.HasPrefix(strings.TrimSpace(k), "#") || strings.HasPrefix(k, publicKeyPrefix) {
+			delete(envs, k)
+			continue
+		}
+
+		v = unquote(v)
+		envs[k] = v
+
+		if !strings.HasPrefix(v, encryptedPrefix) {
+			continue
+		}
+
+		if key == nil {
+			if key, err = s.loadPrivateKey(); err != nil {
+				return nil, err
+			}
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, encryptedPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", k, err)
+		}
+
+		plaintext, err := ecies.Decrypt(key, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", k, err)
+		}
+
+		envs[k] = string(plaintext)
+	}
+
+	return envs, nil
+}
+
+func (s *source) loadPrivateKey() (*ecies.PrivateKey, error) {
+	key := s.privateKey
+
+	if key == "" && s.privateKeyFile != "" {
+		keys, err := dotenv.New(s.privateKeyFile).Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+
+		key = unquote(keys[privateKeyEnv(s.path)])
+	}
+
+	if key == "" && s.privateKeyEnv != "" {
+		key = os.Getenv(s.privateKeyEnv)
+	}
+
+	if key = strings.TrimSpace(key); key == "" {
+		return nil, fmt.Errorf("no private key found for %s", s.path)
+	}
+
+	privateKey, err := ecies.NewPrivateKeyFromHex(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	return privateKey, nil
+}
+
+// privateKeyEnv returns the dotenvx private key variable of a file,
+// e.g. DOTENV_PRIVATE_KEY_PRODUCTION for .env.production.
+func privateKeyEnv(path string) string {
+	name := "DOTENV_PRIVATE_KEY"
+
+	env := strings.TrimPrefix(filepath.Base(path), ".env")
+	if env = strings.TrimPrefix(env, "."); env != "" {
+		name += "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(env))
+	}
+
+	return name
+}
+
+func unquote(v string) string {
+	v = strings.TrimSpace(v)
+
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+
+	return v
+}
@@ -0,0 +1,109 @@
+package dotenvx
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ecies "github.com/ecies/go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encrypt(t *testing.T, key *ecies.PrivateKey, value string) string {
+	t.Helper()
+
+	ciphertext, err := ecies.Encrypt(key.PublicKey, []byte(value))
+	require.NoError(t, err)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	key, err := ecies.GenerateKey()
+	require.NoError(t, err)
+
+	content := "#/----[DOTENV_PUBLIC_KEY]----/\n" +
+		"DOTENV_PUBLIC_KEY=\"" + key.PublicKey.Hex(true) + "\"\n" +
+		"HOST=localhost\n" +
+		"PASSWORD=\"" + encrypt(t, key, "secret") + "\"\n"
+
+	t.Run("private key", func(t *testing.T) {
+		path := writeFile(t, ".env", content)
+
+		envs, err := New(path, WithPrivateKey(key.Hex())).Load()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"HOST": "localhost", "PASSWORD": "secret"}, envs)
+	})
+
+	t.Run("private key env", func(t *testing.T) {
+		path := writeFile(t, ".env.production", content)
+		t.Setenv("DOTENV_PRIVATE_KEY_PRODUCTION", key.Hex())
+
+		envs, err := New(path).Load()
+		require.NoError(t, err)
+		assert.Equal(t, "secret", envs["PASSWORD"])
+	})
+
+	t.Run("private key file", func(t *testing.T) {
+		other, err := ecies.GenerateKey()
+		require.NoError(t, err)
+
+		path := writeFile(t, ".env.production", content)
+		keyFile := writeFile(t, ".env.keys", "#/------------------!DOTENV_PRIVATE_KEYS!-------------------/\n"+
+			"#/ private decryption keys. DO NOT commit to source control /\n"+
+			"#/     [how it works](https://dotenvx.com/encryption)       /\n"+
+			"#/----------------------------------------------------------/\n"+
+			"\n"+
+			"# .env\n"+
+			"DOTENV_PRIVATE_KEY=\""+other.Hex()+"\"\n"+
+			"\n"+
+			"# .env.production\n"+
+			"DOTENV_PRIVATE_KEY_PRODUCTION=\""+key.Hex()+"\"\n")
+
+		envs, err := New(path, WithPrivateKeyFile(keyFile)).Load()
+		require.NoError(t, err)
+		assert.Equal(t, "secret", envs["PASSWORD"])
+	})
+
+	t.Run("private key file without key", func(t *testing.T) {
+		path := writeFile(t, ".env.staging", content)
+		keyFile := writeFile(t, ".env.keys", "DOTENV_PRIVATE_KEY_PRODUCTION=\""+key.Hex()+"\"\n")
+
+		_, err := New(path, WithPrivateKeyFile(keyFile), WithPrivateKeyEnv("DOTENVX_TEST_MISSING")).Load()
+		assert.ErrorContains(t, err, "no private key")
+	})
+
+	t.Run("missing private key", func(t *testing.T) {
+		path := writeFile(t, ".env", content)
+
+		_, err := New(path, WithPrivateKeyEnv("DOTENVX_TEST_MISSING")).Load()
+		assert.ErrorContains(t, err, "no private key")
+	})
+
+	t.Run("wrong private key", func(t *testing.T) {
+		other, err := ecies.GenerateKey()
+		require.NoError(t, err)
+
+		path := writeFile(t, ".env", content)
+
+		_, err = New(path, WithPrivateKey(other.Hex())).Load()
+		assert.ErrorContains(t, err, "failed to decrypt PASSWORD")
+	})
+}
+
+func TestPrivateKeyEnv(t *testing.T) {
+	assert.Equal(t, "DOTENV_PRIVATE_KEY", privateKeyEnv(".env"))
+	assert.Equal(t, "DOTENV_PRIVATE_KEY_PRODUCTION", privateKeyEnv("config/.env.production"))
+	assert.Equal(t, "DOTENV_PRIVATE_KEY_CI_LOCAL", privateKeyEnv(".env.ci.local"))
+}
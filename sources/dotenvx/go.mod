@@ -0,0 +1,20 @@
+module github.com/sethpollack/envcfg/sources/dotenvx
+
+go 1.22
+
+replace github.com/sethpollack/envcfg => ../../
+
+require (
+	github.com/ecies/go/v2 v2.0.9
+	github.com/sethpollack/envcfg v0.0.0-20241201181600-b026eb186a76
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0 // indirect
+	github.com/ethereum/go-ethereum v1.13.5 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.16.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
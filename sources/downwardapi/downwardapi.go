@@ -0,0 +1,110 @@
+package downwardapi
+
+import (
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.Source = (*source)(nil)
+var _ sources.FileSource = (*source)(nil)
+
+type source struct {
+	fsys fs.FS
+	dir  string
+}
+
+// New reads the files of a Kubernetes Downward API volume. Each file is
+// exposed under its upper cased name, e.g. namespace becomes NAMESPACE.
+// Files of key="value" lines, such as labels and annotations, are expanded
+// into one variable per key, e.g. LABELS_APP_KUBERNETES_IO_NAME for the
+// app.kubernetes.io/name label. Characters other than letters and digits
+// are replaced with underscores.
+func New(dir string) *source {
+	return &source{
+		fsys: os.DirFS(dir),
+		dir:  dir,
+	}
+}
+
+// NewFS reads the Downward API files in the root of the provided filesystem.
+func NewFS(fsys fs.FS) *source {
+	return &source{
+		fsys: fsys,
+	}
+}
+
+// Files returns the directory, unless the files are read from a
+// filesystem other than the OS one.
+func (s *source) Files() []string {
+	if s.dir == "" {
+		return nil
+	}
+
+	return []string{s.dir}
+}
+
+func (s *source) Load() (map[string]string, error) {
+	files, err := sources.ReadDir(s.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string)
+	for name, content := range files {
+		prefix := toKey(name)
+
+		pairs, ok := parsePairs(content)
+		if !ok {
+			envs[prefix] = content
+			continue
+		}
+
+		for k, v := range pairs {
+			envs[prefix+"_"+toKey(k)] = v
+		}
+	}
+
+	return envs, nil
+}
+
+// parsePairs parses the key="value" lines the kubelet writes for labels and
+// annotations. It reports false if any line is not in that format.
+func parsePairs(content string) (map[string]string, bool) {
+	if content == "" {
+		return nil, false
+	}
+
+	pairs := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		k, quoted, ok := strings.Cut(line, "=")
+		if !ok || k == "" {
+			return nil, false
+		}
+
+		v, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, false
+		}
+
+		pairs[k] = v
+	}
+
+	return pairs, true
+}
+
+func toKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
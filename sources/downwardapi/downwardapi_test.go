@@ -0,0 +1,45 @@
+package downwardapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"namespace":    {Data: []byte("default")},
+		"pod-name":     {Data: []byte("web-0\n")},
+		"labels":       {Data: []byte("app=\"web\"\napp.kubernetes.io/name=\"nginx\"")},
+		"annotations":  {Data: []byte("note=\"line1\\nline2\"")},
+		"cpu_limit":    {Data: []byte("2")},
+		"not-a-label":  {Data: []byte("key=value")},
+		"..data/other": {Data: []byte("ignored")},
+	}
+
+	envs, err := NewFS(fsys).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"NAMESPACE":                     "default",
+		"POD_NAME":                      "web-0",
+		"LABELS_APP":                    "web",
+		"LABELS_APP_KUBERNETES_IO_NAME": "nginx",
+		"ANNOTATIONS_NOTE":              "line1\nline2",
+		"CPU_LIMIT":                     "2",
+		"NOT_A_LABEL":                   "key=value",
+	}, envs)
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "namespace"), []byte("prod"), 0o600))
+
+	envs, err := New(dir).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"NAMESPACE": "prod"}, envs)
+}
@@ -0,0 +1,134 @@
+package ejson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Shopify/ejson"
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.Source = (*source)(nil)
+
+const (
+	defaultKeyDir = "/opt/ejson/keys"
+	publicKeyName = "_public_key"
+)
+
+type Option func(*source)
+
+// WithKeyDir sets the directory containing ejson private keys.
+// Defaults to $EJSON_KEYDIR or /opt/ejson/keys.
+func WithKeyDir(dir string) Option {
+	return func(s *source) {
+		s.keyDir = dir
+	}
+}
+
+// WithPrivateKey sets the private key used to decrypt the file,
+// bypassing the key directory lookup.
+func WithPrivateKey(key string) Option {
+	return func(s *source) {
+		s.privateKey = key
+	}
+}
+
+// WithPrivateKeyEnv reads the private key from the named environment variable.
+func WithPrivateKeyEnv(name string) Option {
+	return func(s *source) {
+		s.privateKeyEnv = name
+	}
+}
+
+type source struct {
+	path          string
+	keyDir        string
+	privateKey    string
+	privateKeyEnv string
+}
+
+func New(path string, opts ...Option) *source {
+	s := &source{
+		path: path,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *source) Load() (map[string]string, error) {
+	decrypted, err := ejson.DecryptFile(s.path, s.keyDirOrDefault(), s.privateKeyOrEnv())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt ejson file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(decrypted))
+	dec.UseNumber()
+
+	data := make(map[string]any)
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ejson data: %w", err)
+	}
+
+	delete(data, publicKeyName)
+
+	return sources.Flatten(trimUnencrypted(data)), nil
+}
+
+// trimUnencrypted removes the underscore prefix of the keys ejson leaves
+// unencrypted, at every level, as it is not part of the variable name.
+func trimUnencrypted(data map[string]any) map[string]any {
+	trimmed := make(map[string]any, len(data))
+
+	for k, v := range data {
+		trimmed[strings.TrimPrefix(k, "_")] = trimValue(v)
+	}
+
+	return trimmed
+}
+
+func trimValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return trimUnencrypted(v)
+	case []any:
+		values := make([]any, len(v))
+		for i, e := range v {
+			values[i] = trimValue(e)
+		}
+
+		return values
+	default:
+		return v
+	}
+}
+
+func (s *source) keyDirOrDefault() string {
+	if s.keyDir != "" {
+		return s.keyDir
+	}
+
+	if dir := os.Getenv("EJSON_KEYDIR"); dir != "" {
+		return dir
+	}
+
+	return defaultKeyDir
+}
+
+func (s *source) privateKeyOrEnv() string {
+	if s.privateKey != "" {
+		return s.privateKey
+	}
+
+	if s.privateKeyEnv != "" {
+		return os.Getenv(s.privateKeyEnv)
+	}
+
+	return ""
+}
@@ -0,0 +1,134 @@
+package ejson
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Shopify/ejson"
+	"github.com/Shopify/ejson/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tt := []struct {
+		name     string
+		opts     []Option
+		expected *source
+	}{
+		{
+			name: "with all options",
+			opts: []Option{
+				WithKeyDir("/keys"),
+				WithPrivateKey("private"),
+				WithPrivateKeyEnv("EJSON_PRIVATE_KEY"),
+			},
+			expected: &source{
+				path:          "secrets.ejson",
+				keyDir:        "/keys",
+				privateKey:    "private",
+				privateKeyEnv: "EJSON_PRIVATE_KEY",
+			},
+		},
+		{
+			name:     "with no options",
+			opts:     []Option{},
+			expected: &source{path: "secrets.ejson"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := New("secrets.ejson", tc.opts...)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	kp := crypto.Keypair{}
+	require.NoError(t, kp.Generate())
+
+	keyDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(keyDir, kp.PublicString()), []byte(kp.PrivateString()), 0600)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "secrets.ejson")
+	content := fmt.Sprintf(`{
+		"_public_key": %q,
+		"_environment": "production",
+		"password": "secret",
+		"database": {"host": "localhost", "port": 5432, "_user": "admin"}
+	}`, kp.PublicString())
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	_, err = ejson.EncryptFileInPlace(path)
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"ENVIRONMENT":   "production",
+		"PASSWORD":      "secret",
+		"DATABASE_HOST": "localhost",
+		"DATABASE_PORT": "5432",
+		"DATABASE_USER": "admin",
+	}
+
+	tt := []struct {
+		name        string
+		source      *source
+		env         map[string]string
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name:     "key dir",
+			source:   New(path, WithKeyDir(keyDir)),
+			expected: expected,
+		},
+		{
+			name:     "key dir from env",
+			source:   New(path),
+			env:      map[string]string{"EJSON_KEYDIR": keyDir},
+			expected: expected,
+		},
+		{
+			name:     "private key",
+			source:   New(path, WithPrivateKey(kp.PrivateString())),
+			expected: expected,
+		},
+		{
+			name:     "private key env",
+			source:   New(path, WithPrivateKeyEnv("EJSON_PRIVATE_KEY")),
+			env:      map[string]string{"EJSON_PRIVATE_KEY": kp.PrivateString()},
+			expected: expected,
+		},
+		{
+			name:        "missing key",
+			source:      New(path, WithKeyDir(t.TempDir())),
+			expectError: true,
+		},
+		{
+			name:        "missing file",
+			source:      New("non-existent-file", WithKeyDir(keyDir)),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			envs, err := tc.source.Load()
+			if tc.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, envs)
+			}
+		})
+	}
+}
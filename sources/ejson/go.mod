@@ -0,0 +1,20 @@
+module github.com/sethpollack/envcfg/sources/ejson
+
+go 1.22
+
+replace github.com/sethpollack/envcfg => ../../
+
+require (
+	github.com/Shopify/ejson v1.5.2
+	github.com/sethpollack/envcfg v0.0.0-20241201181600-b026eb186a76
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/dustin/gojson v0.0.0-20160307161227-2e71ec9dd5ad // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.17.0 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,114 @@
+package envrc
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sethpollack/envcfg/sources"
+	"github.com/sethpollack/envcfg/sources/dotenv"
+)
+
+var _ sources.Source = (*source)(nil)
+var _ sources.FileSource = (*source)(nil)
+
+// source reads the subset of a direnv .envrc file made up of
+// `export KEY=value` lines and `dotenv`/`dotenv_if_exists` directives.
+// All other lines are ignored.
+type source struct {
+	path string
+}
+
+func New(path string) *source {
+	return &source{
+		path: path,
+	}
+}
+
+// Files returns the path of the .envrc file and the paths of the dotenv
+// files it loads, including those of dotenv_if_exists that don't exist yet.
+func (s *source) Files() []string {
+	files := []string{s.path}
+
+	bytes, err := os.ReadFile(s.path)
+	if err != nil {
+		return files
+	}
+
+	for _, line := range strings.Split(string(bytes), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && (fields[0] == "dotenv" || fields[0] == "dotenv_if_exists") {
+			files = append(files, s.dotenvPath(fields))
+		}
+	}
+
+	return files
+}
+
+func (s *source) Load() (map[string]string, error) {
+	bytes, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string)
+
+	for _, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimSpace(line)
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "export":
+			key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "export")), "=")
+			if ok {
+				envs[key] = unquote(value)
+			}
+		case "dotenv", "dotenv_if_exists":
+			loaded, err := dotenv.New(s.dotenvPath(fields)).Load()
+			if err != nil {
+				if fields[0] == "dotenv_if_exists" && os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+
+			for k, v := range loaded {
+				envs[k] = v
+			}
+		}
+	}
+
+	return envs, nil
+}
+
+// dotenvPath returns the path of the file loaded by a dotenv directive,
+// relative paths are relative to the .envrc file.
+func (s *source) dotenvPath(fields []string) string {
+	path := ".env"
+	if len(fields) > 1 {
+		path = unquote(fields[1])
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(s.path), path)
+	}
+
+	return path
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+
+	return value
+}
@@ -0,0 +1,109 @@
+package envrc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tt := []struct {
+		name        string
+		content     string
+		dotenv      string
+		expected    map[string]string
+		expectedErr bool
+	}{
+		{
+			name:     "empty file",
+			content:  "",
+			expected: map[string]string{},
+		},
+		{
+			name:    "export lines",
+			content: "export KEY1=value1\nexport KEY2=\"value 2\"\nexport KEY3='value 3'",
+			expected: map[string]string{
+				"KEY1": "value1",
+				"KEY2": "value 2",
+				"KEY3": "value 3",
+			},
+		},
+		{
+			name:    "ignores other lines",
+			content: "# comment\nuse nix\nlayout go\nKEY=value\nexport KEY1=value1",
+			expected: map[string]string{
+				"KEY1": "value1",
+			},
+		},
+		{
+			name:    "dotenv directive",
+			content: "dotenv\nexport KEY2=override",
+			dotenv:  "KEY1=value1\nKEY2=value2",
+			expected: map[string]string{
+				"KEY1": "value1",
+				"KEY2": "override",
+			},
+		},
+		{
+			name:        "dotenv directive missing file",
+			content:     "dotenv missing.env",
+			expectedErr: true,
+		},
+		{
+			name:     "dotenv_if_exists directive missing file",
+			content:  "dotenv_if_exists missing.env",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			tmpFile := filepath.Join(dir, ".envrc")
+			err := os.WriteFile(tmpFile, []byte(tc.content), 0644)
+			require.NoError(t, err)
+
+			if tc.dotenv != "" {
+				err := os.WriteFile(filepath.Join(dir, ".env"), []byte(tc.dotenv), 0644)
+				require.NoError(t, err)
+			}
+
+			src := New(tmpFile)
+			result, err := src.Load()
+
+			if tc.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, result)
+			}
+		})
+	}
+
+	t.Run("non-existent file", func(t *testing.T) {
+		src := New("non-existent-file")
+		_, err := src.Load()
+		require.Error(t, err)
+	})
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	tmpFile := filepath.Join(dir, ".envrc")
+	err := os.WriteFile(tmpFile, []byte("dotenv\ndotenv_if_exists .env.local\ndotenv /etc/app.env\nexport KEY=value"), 0644)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		tmpFile,
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, ".env.local"),
+		"/etc/app.env",
+	}, New(tmpFile).Files())
+
+	assert.Equal(t, []string{"non-existent-file"}, New("non-existent-file").Files())
+}
@@ -0,0 +1,46 @@
+package fsenv
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.Source = (*source)(nil)
+
+type source struct {
+	fsys  fs.FS
+	paths []string
+}
+
+// New reads dotenv or JSON files from the provided filesystem, such as a
+// //go:embed bundle. Files are loaded in order, later files take precedence.
+func New(fsys fs.FS, paths ...string) *source {
+	return &source{
+		fsys:  fsys,
+		paths: paths,
+	}
+}
+
+func (s *source) Load() (map[string]string, error) {
+	envs := make(map[string]string)
+
+	for _, path := range s.paths {
+		data, err := fs.ReadFile(s.fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded, err := sources.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		for k, v := range loaded {
+			envs[k] = v
+		}
+	}
+
+	return envs, nil
+}
@@ -0,0 +1,59 @@
+package fsenv
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.env":   {Data: []byte("HOST=localhost\nPORT=8080\n")},
+		"overrides.json": {Data: []byte(`{"port": 9090, "db": {"name": "app"}}`)},
+		"invalid.json":   {Data: []byte(`{"port": `)},
+	}
+
+	tt := []struct {
+		name        string
+		paths       []string
+		expected    map[string]string
+		expectedErr error
+	}{
+		{
+			name:     "dotenv",
+			paths:    []string{"defaults.env"},
+			expected: map[string]string{"HOST": "localhost", "PORT": "8080"},
+		},
+		{
+			name:     "later files take precedence",
+			paths:    []string{"defaults.env", "overrides.json"},
+			expected: map[string]string{"HOST": "localhost", "PORT": "9090", "DB_NAME": "app"},
+		},
+		{
+			name:        "missing file",
+			paths:       []string{"missing.env"},
+			expectedErr: fs.ErrNotExist,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			envs, err := New(fsys, tc.paths...).Load()
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, envs)
+			}
+		})
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := New(fsys, "invalid.json").Load()
+		assert.ErrorContains(t, err, "invalid.json")
+	})
+}
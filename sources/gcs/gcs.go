@@ -0,0 +1,103 @@
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.ContextSource = (*source)(nil)
+
+// Client reads objects from Google Cloud Storage. A generation of 0
+// reads the latest generation.
+type Client interface {
+	NewReader(ctx context.Context, bucket, object string, generation int64) (io.ReadCloser, error)
+}
+
+type Option func(*source)
+
+// WithClient sets the client used to read the object.
+func WithClient(client Client) Option {
+	return func(s *source) {
+		s.client = client
+	}
+}
+
+// WithGeneration pins the object generation to read.
+func WithGeneration(generation int64) Option {
+	return func(s *source) {
+		s.generation = generation
+	}
+}
+
+type source struct {
+	client     Client
+	bucket     string
+	object     string
+	generation int64
+}
+
+// New loads a dotenv or JSON object from a Google Cloud Storage bucket.
+// Application Default Credentials are used unless a client is provided.
+func New(bucket, object string, opts ...Option) *source {
+	s := &source{
+		bucket: bucket,
+		object: object,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *source) Load() (map[string]string, error) {
+	return s.LoadContext(context.Background())
+}
+
+func (s *source) LoadContext(ctx context.Context) (map[string]string, error) {
+	if s.client == nil {
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create storage client: %w", err)
+		}
+
+		s.client = &storageClient{client: client}
+	}
+
+	r, err := s.client.NewReader(ctx, s.bucket, s.object, s.generation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gs://%s/%s: %w", s.bucket, s.object, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gs://%s/%s: %w", s.bucket, s.object, err)
+	}
+
+	envs, err := sources.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse gs://%s/%s: %w", s.bucket, s.object, err)
+	}
+
+	return envs, nil
+}
+
+type storageClient struct {
+	client *storage.Client
+}
+
+func (c *storageClient) NewReader(ctx context.Context, bucket, object string, generation int64) (io.ReadCloser, error) {
+	obj := c.client.Bucket(bucket).Object(object)
+
+	if generation > 0 {
+		obj = obj.Generation(generation)
+	}
+
+	return obj.NewReader(ctx)
+}
@@ -0,0 +1,78 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockClient struct {
+	objects map[int64]string
+	err     error
+}
+
+func (m *mockClient) NewReader(ctx context.Context, bucket, object string, generation int64) (io.ReadCloser, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	content, ok := m.objects[generation]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func TestLoad(t *testing.T) {
+	client := &mockClient{objects: map[int64]string{
+		0: `{"db": {"host": "latest"}}`,
+		1: "DB_HOST=pinned\n",
+	}}
+
+	tt := []struct {
+		name        string
+		opts        []Option
+		expected    map[string]string
+		expectedErr bool
+	}{
+		{
+			name:     "latest json",
+			opts:     []Option{WithClient(client)},
+			expected: map[string]string{"DB_HOST": "latest"},
+		},
+		{
+			name:     "pinned dotenv generation",
+			opts:     []Option{WithClient(client), WithGeneration(1)},
+			expected: map[string]string{"DB_HOST": "pinned"},
+		},
+		{
+			name:        "missing generation",
+			opts:        []Option{WithClient(client), WithGeneration(2)},
+			expectedErr: true,
+		},
+		{
+			name:        "client error",
+			opts:        []Option{WithClient(&mockClient{err: errors.New("denied")})},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			envs, err := New("bucket", "config.json", tc.opts...).Load()
+
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, envs)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package httpenv
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.ContextSource = (*source)(nil)
+
+// Format is the payload format of the response body.
+type Format int
+
+const (
+	// FormatAuto detects the format from the Content-Type header,
+	// falling back to JSON when the body is an object and dotenv otherwise.
+	FormatAuto Format = iota
+	FormatJSON
+	FormatDotEnv
+)
+
+// DefaultMaxSize is the default limit of the response body size in bytes.
+const DefaultMaxSize = 10 << 20
+
+type Option func(*source)
+
+// WithHeader sets a request header.
+func WithHeader(key, value string) Option {
+	return func(s *source) {
+		s.header.Set(key, value)
+	}
+}
+
+// WithBasicAuth sets the request's basic authentication credentials.
+func WithBasicAuth(username, password string) Option {
+	return func(s *source) {
+		s.username, s.password = username, password
+	}
+}
+
+// WithBearerToken sets the request's bearer token.
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithTimeout sets the request timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *source) {
+		s.timeout = timeout
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default client,
+// e.g. to trust a private CA or present a client certificate.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *source) {
+		s.tlsConfig = cfg
+	}
+}
+
+// WithClient sets the HTTP client, WithTLSConfig is ignored when set.
+func WithClient(client *http.Client) Option {
+	return func(s *source) {
+		s.client = client
+	}
+}
+
+// WithMaxSize limits the size of the response body in bytes, larger
+// responses fail to load. The default is DefaultMaxSize, a size of 0 or less
+// removes the limit.
+func WithMaxSize(size int64) Option {
+	return func(s *source) {
+		s.maxSize = size
+	}
+}
+
+// WithFormat sets the payload format instead of detecting it.
+func WithFormat(format Format) Option {
+	return func(s *source) {
+		s.format = format
+	}
+}
+
+type source struct {
+	url       string
+	header    http.Header
+	username  string
+	password  string
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	client    *http.Client
+	format    Format
+	maxSize   int64
+}
+
+// New fetches a JSON object or dotenv formatted payload from url.
+// Nested JSON objects are flattened, e.g. {"db": {"host": "x"}} becomes DB_HOST=x.
+func New(url string, opts ...Option) *source {
+	s := &source{
+		url:     url,
+		header:  http.Header{},
+		maxSize: DefaultMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *source) Load() (map[string]string, error) {
+	return s.LoadContext(context.Background())
+}
+
+func (s *source) LoadContext(ctx context.Context) (map[string]string, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = s.header.Clone()
+
+	if s.username != "" || s.password != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status fetching %s: %s", s.url, resp.Status)
+	}
+
+	var r io.Reader = resp.Body
+	if s.maxSize > 0 {
+		r = io.LimitReader(r, s.maxSize+1)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.maxSize > 0 && int64(len(body)) > s.maxSize {
+		return nil, fmt.Errorf("response from %s exceeds the maximum size of %d bytes", s.url, s.maxSize)
+	}
+
+	envs, err := s.parse(resp, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", s.url, err)
+	}
+
+	return envs, nil
+}
+
+func (s *source) httpClient() *http.Client {
+	if s.client != nil {
+		return s.client
+	}
+
+	if s.tlsConfig == nil {
+		return http.DefaultClient
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = s.tlsConfig
+
+	return &http.Client{Transport: transport}
+}
+
+// parse decodes the body in the configured format. Without one, a JSON
+// Content-Type selects JSON, otherwise the format is detected from the body.
+func (s *source) parse(resp *http.Response, body []byte) (map[string]string, error) {
+	format := s.format
+	if format == FormatAuto {
+		if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+			if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+				format = FormatJSON
+			}
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		return sources.ParseJSON(body)
+	case FormatDotEnv:
+		return sources.ParseDotEnv(body), nil
+	default:
+		return sources.Parse(body)
+	}
+}
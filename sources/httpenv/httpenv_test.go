@@ -0,0 +1,138 @@
+package httpenv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tt := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []Option
+		expected    map[string]string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"port": 8080, "db": {"host": "localhost"}}`,
+			expected:    map[string]string{"PORT": "8080", "DB_HOST": "localhost"},
+		},
+		{
+			name:     "json without content type",
+			body:     `{"port": "8080"}`,
+			expected: map[string]string{"PORT": "8080"},
+		},
+		{
+			name:        "dotenv",
+			contentType: "text/plain",
+			body:        "PORT=8080\nHOST=localhost\n",
+			expected:    map[string]string{"PORT": "8080", "HOST": "localhost"},
+		},
+		{
+			name:        "forced format",
+			contentType: "application/json",
+			body:        "PORT=8080",
+			opts:        []Option{WithFormat(FormatDotEnv)},
+			expected:    map[string]string{"PORT": "8080"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			envs, err := New(srv.URL, tc.opts...).Load()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, envs)
+		})
+	}
+}
+
+func TestLoadAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" || r.Header.Get("X-Env") != "prod" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("KEY=value"))
+	}))
+	defer srv.Close()
+
+	envs, err := New(srv.URL, WithBasicAuth("user", "pass"), WithHeader("X-Env", "prod")).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, envs)
+
+	_, err = New(srv.URL).Load()
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
+
+func TestLoadBearerToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("AUTH=" + r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	envs, err := New(srv.URL, WithBearerToken("token")).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"AUTH": "Bearer token"}, envs)
+}
+
+func TestLoadTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, WithTimeout(10*time.Millisecond)).Load()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLoadMaxSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("PORT=8080"))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, WithMaxSize(8)).Load()
+	assert.ErrorContains(t, err, "exceeds the maximum size of 8 bytes")
+
+	envs, err := New(srv.URL, WithMaxSize(9)).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PORT": "8080"}, envs)
+
+	envs, err = New(srv.URL, WithMaxSize(0)).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PORT": "8080"}, envs)
+}
+
+func TestLoadTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("KEY=value"))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).Load()
+	assert.Error(t, err)
+
+	envs, err := New(srv.URL, WithTLSConfig(srv.Client().Transport.(*http.Transport).TLSClientConfig)).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, envs)
+}
@@ -0,0 +1,57 @@
+package k8sdir
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.Source = (*source)(nil)
+var _ sources.FileSource = (*source)(nil)
+
+type source struct {
+	fsys fs.FS
+	dir  string
+}
+
+// New reads every file in a mounted Secret or ConfigMap directory,
+// exposing each as FILENAME=contents. File names are upper-cased with
+// - and . replaced by _, so the key db-password becomes DB_PASSWORD.
+func New(dir string) *source {
+	return &source{
+		fsys: os.DirFS(dir),
+		dir:  dir,
+	}
+}
+
+// NewFS reads the files in the root of the provided filesystem.
+func NewFS(fsys fs.FS) *source {
+	return &source{
+		fsys: fsys,
+	}
+}
+
+// Files returns the directory, unless the files are read from a
+// filesystem other than the OS one.
+func (s *source) Files() []string {
+	if s.dir == "" {
+		return nil
+	}
+
+	return []string{s.dir}
+}
+
+func (s *source) Load() (map[string]string, error) {
+	files, err := sources.ReadDir(s.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string, len(files))
+	for name, value := range files {
+		envs[sources.FileKey(name)] = value
+	}
+
+	return envs, nil
+}
@@ -0,0 +1,57 @@
+package k8sdir
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	// mimic the layout of a projected volume, where every key is a
+	// symlink into a timestamped directory through ..data.
+	dir := t.TempDir()
+	data := filepath.Join(dir, "..2024_01_01_00_00_00.000000000")
+	require.NoError(t, os.Mkdir(data, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "DB_PASSWORD"), []byte("secret\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "DB_USER"), []byte("admin"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Base(data), filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "DB_PASSWORD"), filepath.Join(dir, "DB_PASSWORD")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "DB_USER"), filepath.Join(dir, "DB_USER")))
+
+	envs, err := New(dir).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"DB_PASSWORD": "secret",
+		"DB_USER":     "admin",
+	}, envs)
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"API_KEY":        {Data: []byte("key\r\n")},
+		"db-password":    {Data: []byte("secret")},
+		"app.log-level":  {Data: []byte("debug")},
+		"nested/IGNORED": {Data: []byte("value")},
+		".hidden":        {Data: []byte("value")},
+	}
+
+	envs, err := NewFS(fsys).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"API_KEY":       "key",
+		"DB_PASSWORD":   "secret",
+		"APP_LOG_LEVEL": "debug",
+	}, envs)
+}
+
+func TestLoadMissingDir(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing")).Load()
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
@@ -0,0 +1,158 @@
+package lambdaext
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.ContextSource = (*source)(nil)
+
+// DefaultEndpoint is the address of the AWS Parameters and Secrets
+// Lambda extension when PARAMETERS_SECRETS_EXTENSION_HTTP_PORT is unset.
+const DefaultEndpoint = "http://localhost:2773"
+
+type Option func(*source)
+
+// WithSecretID loads the key/value pairs of a Secrets Manager secret
+// whose secret string is a JSON object.
+func WithSecretID(id string) Option {
+	return func(s *source) {
+		s.secretIDs = append(s.secretIDs, id)
+	}
+}
+
+// WithParameter loads an SSM parameter, decrypted, into the given key.
+func WithParameter(name, key string) Option {
+	return func(s *source) {
+		s.parameters = append(s.parameters, parameter{name: name, key: key})
+	}
+}
+
+// WithEndpoint sets the extension endpoint.
+func WithEndpoint(endpoint string) Option {
+	return func(s *source) {
+		s.endpoint = endpoint
+	}
+}
+
+// WithToken sets the token sent to the extension,
+// AWS_SESSION_TOKEN is used by default.
+func WithToken(token string) Option {
+	return func(s *source) {
+		s.token = token
+	}
+}
+
+// WithClient sets the HTTP client.
+func WithClient(client *http.Client) Option {
+	return func(s *source) {
+		s.client = client
+	}
+}
+
+type parameter struct {
+	name string
+	key  string
+}
+
+type source struct {
+	endpoint   string
+	token      string
+	client     *http.Client
+	secretIDs  []string
+	parameters []parameter
+}
+
+// New loads secrets and parameters through the AWS Parameters and Secrets
+// Lambda extension, which caches them for the lifetime of the execution
+// environment.
+func New(opts ...Option) *source {
+	s := &source{
+		endpoint: DefaultEndpoint,
+		token:    os.Getenv("AWS_SESSION_TOKEN"),
+		client:   http.DefaultClient,
+	}
+
+	if port := os.Getenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT"); port != "" {
+		s.endpoint = "http://localhost:" + port
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *source) Load() (map[string]string, error) {
+	return s.LoadContext(context.Background())
+}
+
+func (s *source) LoadContext(ctx context.Context) (map[string]string, error) {
+	envs := make(map[string]string)
+
+	for _, id := range s.secretIDs {
+		var secret struct {
+			SecretString string
+		}
+
+		if err := s.get(ctx, "/secretsmanager/get", url.Values{"secretId": {id}}, &secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", id, err)
+		}
+
+		values := map[string]any{}
+		if err := json.Unmarshal([]byte(secret.SecretString), &values); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal secret %s: %w", id, err)
+		}
+
+		for k, v := range sources.Flatten(values) {
+			envs[k] = v
+		}
+	}
+
+	for _, p := range s.parameters {
+		var param struct {
+			Parameter struct {
+				Value string
+			}
+		}
+
+		query := url.Values{"name": {p.name}, "withDecryption": {"true"}}
+		if err := s.get(ctx, "/systemsmanager/parameters/get", query, &param); err != nil {
+			return nil, fmt.Errorf("failed to get parameter %s: %w", p.name, err)
+		}
+
+		envs[p.key] = param.Parameter.Value
+	}
+
+	return envs, nil
+}
+
+func (s *source) get(ctx context.Context, path string, query url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Aws-Parameters-Secrets-Token", s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,85 @@
+package lambdaext
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Aws-Parameters-Secrets-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/secretsmanager/get":
+			if r.URL.Query().Get("secretId") != "app/config" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"SecretString": `{"DB_USER": "admin", "DB_PASSWORD": "secret"}`,
+			})
+		case "/systemsmanager/parameters/get":
+			if r.URL.Query().Get("withDecryption") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"Parameter": map[string]string{
+					"Name":  r.URL.Query().Get("name"),
+					"Value": "value of " + r.URL.Query().Get("name"),
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestLoad(t *testing.T) {
+	srv := newServer(t)
+
+	envs, err := New(
+		WithEndpoint(srv.URL),
+		WithToken("token"),
+		WithSecretID("app/config"),
+		WithParameter("/app/api-key", "API_KEY"),
+	).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"DB_USER":     "admin",
+		"DB_PASSWORD": "secret",
+		"API_KEY":     "value of /app/api-key",
+	}, envs)
+}
+
+func TestLoadError(t *testing.T) {
+	srv := newServer(t)
+
+	_, err := New(WithEndpoint(srv.URL), WithToken("invalid"), WithSecretID("app/config")).Load()
+	assert.ErrorContains(t, err, "403 Forbidden")
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", "8080")
+
+	s := New()
+	assert.Equal(t, "http://localhost:8080", s.endpoint)
+	assert.Equal(t, "session", s.token)
+}
@@ -1,8 +1,8 @@
 package mapenv
 
-import "github.com/sethpollack/envcfg/internal/loader"
+import "github.com/sethpollack/envcfg/sources"
 
-var _ loader.Source = (*source)(nil)
+var _ sources.Source = (*source)(nil)
 
 type source struct {
 	env map[string]string
@@ -0,0 +1,96 @@
+package mock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.ContextSource = (*Source)(nil)
+
+// Response is the result returned by a single call to Load.
+type Response struct {
+	Values map[string]string
+	Err    error
+	Delay  time.Duration
+}
+
+// Source is a scriptable source for testing. Each call to Load returns the
+// next queued response, the last response is repeated once the queue is
+// exhausted.
+type Source struct {
+	mu        sync.Mutex
+	responses []Response
+	calls     int
+}
+
+func New(responses ...Response) *Source {
+	return &Source{
+		responses: responses,
+	}
+}
+
+// Push queues additional responses.
+func (s *Source) Push(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses = append(s.responses, responses...)
+}
+
+// Calls returns the number of times Load has been called.
+func (s *Source) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+func (s *Source) Load() (map[string]string, error) {
+	return s.LoadContext(context.Background())
+}
+
+// LoadContext is like Load but returns the context error when ctx is done
+// before the response delay has passed.
+func (s *Source) LoadContext(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	resp := s.next()
+	s.calls++
+	s.mu.Unlock()
+
+	if resp.Delay > 0 {
+		timer := time.NewTimer(resp.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+
+	values := make(map[string]string, len(resp.Values))
+	for k, v := range resp.Values {
+		values[k] = v
+	}
+
+	return values, nil
+}
+
+func (s *Source) next() Response {
+	if len(s.responses) == 0 {
+		return Response{}
+	}
+
+	if s.calls < len(s.responses) {
+		return s.responses[s.calls]
+	}
+
+	return s.responses[len(s.responses)-1]
+}
@@ -0,0 +1,65 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	errLoad := errors.New("load failed")
+
+	s := New(
+		Response{Err: errLoad},
+		Response{Values: map[string]string{"KEY": "value"}},
+	)
+
+	_, err := s.Load()
+	assert.ErrorIs(t, err, errLoad)
+
+	env, err := s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, env)
+
+	env, err = s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, env)
+
+	s.Push(Response{Values: map[string]string{"KEY": "other"}})
+
+	env, err = s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "other"}, env)
+
+	assert.Equal(t, 4, s.Calls())
+}
+
+func TestLoadEmpty(t *testing.T) {
+	env, err := New().Load()
+	require.NoError(t, err)
+	assert.Empty(t, env)
+}
+
+func TestLoadDelay(t *testing.T) {
+	s := New(Response{Delay: 20 * time.Millisecond})
+
+	start := time.Now()
+	_, err := s.Load()
+	require.NoError(t, err)
+
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestLoadContext(t *testing.T) {
+	s := New(Response{Delay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := s.LoadContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -1,13 +1,16 @@
 package osenv
 
 import (
+	"context"
 	"os"
 
-	"github.com/sethpollack/envcfg/internal/loader"
 	"github.com/sethpollack/envcfg/sources"
 )
 
-var _ loader.Source = (*source)(nil)
+var (
+	_ sources.Source    = (*source)(nil)
+	_ sources.KeySource = (*source)(nil)
+)
 
 type source struct{}
 
@@ -18,3 +21,15 @@ func New() *source {
 func (s *source) Load() (map[string]string, error) {
 	return sources.ToMap(os.Environ()), nil
 }
+
+// LoadKeys looks up the given keys only.
+func (s *source) LoadKeys(_ context.Context, keys []string) (map[string]string, error) {
+	m := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			m[key] = value
+		}
+	}
+
+	return m, nil
+}
@@ -1,6 +1,7 @@
 package osenv
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,3 +55,13 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadKeys(t *testing.T) {
+	t.Setenv("TEST_KEY1", "value1")
+	t.Setenv("TEST_KEY2", "value2")
+
+	actual, err := New().LoadKeys(context.Background(), []string{"TEST_KEY1", "TEST_MISSING"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TEST_KEY1": "value1"}, actual)
+}
@@ -1,9 +1,67 @@
 package sources
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strconv"
 	"strings"
 )
 
+// Source loads environment variables.
+type Source interface {
+	Load() (map[string]string, error)
+}
+
+// ContextSource is implemented by sources that honor cancellation and
+// deadlines. LoadContext is used in place of Load when available.
+type ContextSource interface {
+	Source
+	LoadContext(ctx context.Context) (map[string]string, error)
+}
+
+// KeySource is implemented by sources that can load selected keys only,
+// such as a parameter store supporting batch lookups. LoadKeys is used in
+// place of Load when every key the configuration may use is known.
+type KeySource interface {
+	LoadKeys(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// FileSource is implemented by sources that read files from the OS
+// filesystem. Files returns the paths of the files, or directories of files,
+// the source reads, so that they can be watched for changes.
+type FileSource interface {
+	Files() []string
+}
+
+// ProfileSource is implemented by sources that have profile specific
+// variants, such as .env.prod for a .env file.
+type ProfileSource interface {
+	LoadProfile(profile string) (map[string]string, error)
+}
+
+// Func adapts a function to a ContextSource, e.g. to implement middleware
+// that wraps another source.
+type Func func(ctx context.Context) (map[string]string, error)
+
+func (f Func) Load() (map[string]string, error) {
+	return f(context.Background())
+}
+
+func (f Func) LoadContext(ctx context.Context) (map[string]string, error) {
+	return f(ctx)
+}
+
+// LoadContext loads s, passing ctx when s implements ContextSource.
+func LoadContext(ctx context.Context, s Source) (map[string]string, error) {
+	if cs, ok := s.(ContextSource); ok {
+		return cs.LoadContext(ctx)
+	}
+
+	return s.Load()
+}
+
 func ToMap(env []string) map[string]string {
 	m := make(map[string]string)
 	for _, e := range env {
@@ -15,3 +73,113 @@ func ToMap(env []string) map[string]string {
 
 	return m
 }
+
+// Parse decodes a JSON object or dotenv formatted payload. JSON objects,
+// detected by a leading "{", are flattened with Flatten.
+func Parse(data []byte) (map[string]string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		return ParseDotEnv(data), nil
+	}
+
+	return ParseJSON(data)
+}
+
+// ParseJSON decodes a JSON object and flattens it with Flatten.
+func ParseJSON(data []byte) (map[string]string, error) {
+	m := map[string]any{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return Flatten(m), nil
+}
+
+// ParseDotEnv decodes KEY=value lines.
+func ParseDotEnv(data []byte) map[string]string {
+	return ToMap(strings.Split(string(data), "\n"))
+}
+
+var keyReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// FileKey converts a file name to an environment variable name by
+// upper-casing it and replacing - and . with _, e.g. db-password
+// becomes DB_PASSWORD.
+func FileKey(name string) string {
+	return strings.ToUpper(keyReplacer.Replace(name))
+}
+
+// ReadDir reads every file in the root of fsys into a map of file name to
+// contents with trailing newlines trimmed, as mounted by Kubernetes Secret and
+// ConfigMap volumes or Docker secrets. Directories and hidden entries, such as
+// the ..data symlinks of projected volumes, are skipped.
+func ReadDir(fsys fs.FS) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		// entries are usually symlinks, stat follows them.
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		bytes, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m[name] = strings.TrimRight(string(bytes), "\r\n")
+	}
+
+	return m, nil
+}
+
+// Flatten converts decoded JSON-like data into environment variable style keys.
+// Nested object keys and slice indexes are joined with an underscore and
+// upper cased, e.g. {"db": {"hosts": ["a"]}} becomes DB_HOSTS_0=a.
+// Null values are skipped.
+func Flatten(data map[string]any) map[string]string {
+	m := make(map[string]string)
+	flatten(m, "", data)
+	return m
+}
+
+func flatten(m map[string]string, prefix string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, e := range v {
+			flatten(m, joinKey(prefix, k), e)
+		}
+	case []any:
+		for i, e := range v {
+			flatten(m, joinKey(prefix, strconv.Itoa(i)), e)
+		}
+	case nil:
+		return
+	case string:
+		m[prefix] = v
+	default:
+		m[prefix] = fmt.Sprint(v)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	key = strings.ToUpper(key)
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "_" + key
+}
@@ -1,9 +1,12 @@
 package sources
 
 import (
+	"context"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestToMap(t *testing.T) {
@@ -68,3 +71,103 @@ func TestToMap(t *testing.T) {
 		})
 	}
 }
+
+func TestFlatten(t *testing.T) {
+	tt := []struct {
+		name     string
+		data     map[string]any
+		expected map[string]string
+	}{
+		{
+			name:     "empty map",
+			data:     map[string]any{},
+			expected: map[string]string{},
+		},
+		{
+			name: "scalar values",
+			data: map[string]any{
+				"name":    "value",
+				"port":    float64(8080),
+				"enabled": true,
+				"missing": nil,
+			},
+			expected: map[string]string{
+				"NAME":    "value",
+				"PORT":    "8080",
+				"ENABLED": "true",
+			},
+		},
+		{
+			name: "nested values",
+			data: map[string]any{
+				"db": map[string]any{
+					"host":  "localhost",
+					"ports": []any{"5432", "5433"},
+				},
+			},
+			expected: map[string]string{
+				"DB_HOST":    "localhost",
+				"DB_PORTS_0": "5432",
+				"DB_PORTS_1": "5433",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Flatten(tc.data)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"KEY":       {Data: []byte("value\n\n")},
+		"dir/OTHER": {Data: []byte("value")},
+		"..data":    {Data: []byte("value")},
+	}
+
+	m, err := ReadDir(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, m)
+}
+
+func TestFileKey(t *testing.T) {
+	assert.Equal(t, "DB_PASSWORD", FileKey("db-password"))
+	assert.Equal(t, "APP_LOG_LEVEL", FileKey("app.log-level"))
+	assert.Equal(t, "API_KEY", FileKey("API_KEY"))
+}
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(`{"db": {"host": "localhost"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "localhost"}, m)
+
+	m, err = Parse([]byte("DB_HOST=localhost\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "localhost"}, m)
+
+	_, err = Parse([]byte(`{"db": `))
+	assert.Error(t, err)
+}
+
+func TestFunc(t *testing.T) {
+	src := Func(func(ctx context.Context) (map[string]string, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return map[string]string{"KEY": "value"}, nil
+	})
+
+	envs, err := LoadContext(context.Background(), src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, envs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = LoadContext(ctx, src)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,210 @@
+package systemd
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/sethpollack/envcfg/sources"
+)
+
+var _ sources.Source = (*source)(nil)
+var _ sources.FileSource = (*source)(nil)
+
+type source struct {
+	fsys     fs.FS
+	path     string
+	optional bool
+}
+
+// New reads a systemd EnvironmentFile. As in unit files, a path prefixed
+// with "-" is optional and a missing file loads nothing.
+func New(path string) *source {
+	s := &source{}
+	s.path, s.optional = strings.CutPrefix(path, "-")
+
+	return s
+}
+
+// NewFS reads the EnvironmentFile from the provided filesystem.
+func NewFS(fsys fs.FS, path string) *source {
+	s := New(path)
+	s.fsys = fsys
+
+	return s
+}
+
+// Files returns the path of the file, unless it is read from a
+// filesystem other than the OS one.
+func (s *source) Files() []string {
+	if s.fsys != nil {
+		return nil
+	}
+
+	return []string{s.path}
+}
+
+func (s *source) Load() (map[string]string, error) {
+	bytes, err := s.readFile()
+	if err != nil {
+		if s.optional && errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+
+		return nil, err
+	}
+
+	return Parse(string(bytes)), nil
+}
+
+func (s *source) readFile() ([]byte, error) {
+	if s.fsys != nil {
+		return fs.ReadFile(s.fsys, s.path)
+	}
+
+	return os.ReadFile(s.path)
+}
+
+type state int
+
+const (
+	preKey state = iota
+	key
+	preValue
+	value
+	valueEscape
+	singleQuoteValue
+	doubleQuoteValue
+	doubleQuoteValueEscape
+	comment
+	commentEscape
+)
+
+// Parse parses EnvironmentFile contents the way systemd does: lines starting
+// with # or ; are comments, values may be single or double quoted and span
+// lines, a trailing backslash continues a line, and in double quotes only
+// \", \\, \` and \$ are escapes.
+func Parse(content string) map[string]string {
+	envs := make(map[string]string)
+
+	var (
+		st       = preKey
+		k        strings.Builder
+		v        []byte
+		valueEnd int
+	)
+
+	emit := func() {
+		name := strings.TrimSpace(k.String())
+		if name != "" {
+			envs[name] = string(v[:valueEnd])
+		}
+
+		k.Reset()
+		v, valueEnd = v[:0], 0
+	}
+
+	// add appends to the value, trailing unquoted whitespace is trimmed.
+	add := func(c byte, keep bool) {
+		v = append(v, c)
+		if keep {
+			valueEnd = len(v)
+		}
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		switch st {
+		case preKey:
+			if c == '#' || c == ';' {
+				st = comment
+			} else if !isSpace(c) {
+				st = key
+				k.WriteByte(c)
+			}
+		case key:
+			if c == '\n' {
+				// a line without "=" is ignored.
+				st = preKey
+				k.Reset()
+			} else if c == '=' {
+				st = preValue
+			} else {
+				k.WriteByte(c)
+			}
+		case preValue:
+			switch {
+			case c == '\n':
+				st = preKey
+				emit()
+			case c == '\'':
+				st = singleQuoteValue
+			case c == '"':
+				st = doubleQuoteValue
+			case c == '\\':
+				st = valueEscape
+			case !isSpace(c):
+				st = value
+				add(c, true)
+			}
+		case value:
+			switch {
+			case c == '\n':
+				st = preKey
+				emit()
+			case c == '\\':
+				st = valueEscape
+			default:
+				add(c, !isSpace(c))
+			}
+		case valueEscape:
+			st = value
+			if c != '\n' {
+				add(c, true)
+			}
+		case singleQuoteValue:
+			if c == '\'' {
+				st = preValue
+			} else {
+				add(c, true)
+			}
+		case doubleQuoteValue:
+			if c == '"' {
+				st = preValue
+			} else if c == '\\' {
+				st = doubleQuoteValueEscape
+			} else {
+				add(c, true)
+			}
+		case doubleQuoteValueEscape:
+			st = doubleQuoteValue
+			if strings.IndexByte("\"\\`$", c) >= 0 {
+				add(c, true)
+			} else if c != '\n' {
+				add('\\', true)
+				add(c, true)
+			}
+		case comment:
+			if c == '\\' {
+				st = commentEscape
+			} else if c == '\n' {
+				st = preKey
+			}
+		case commentEscape:
+			st = comment
+		}
+	}
+
+	switch st {
+	case preValue, value, valueEscape, singleQuoteValue, doubleQuoteValue, doubleQuoteValueEscape:
+		emit()
+	}
+
+	return envs
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
@@ -0,0 +1,121 @@
+package systemd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		name     string
+		content  string
+		expected map[string]string
+	}{
+		{
+			name:     "simple",
+			content:  "KEY=value\nOTHER=other",
+			expected: map[string]string{"KEY": "value", "OTHER": "other"},
+		},
+		{
+			name:     "whitespace",
+			content:  "  KEY = value  \t\n",
+			expected: map[string]string{"KEY": "value"},
+		},
+		{
+			name:     "empty value",
+			content:  "KEY=\n",
+			expected: map[string]string{"KEY": ""},
+		},
+		{
+			name:     "comments",
+			content:  "# comment\n; comment\nKEY=value # not a comment\n",
+			expected: map[string]string{"KEY": "value # not a comment"},
+		},
+		{
+			name:     "comment continuation",
+			content:  "# comment \\\nIGNORED=value\nKEY=value\n",
+			expected: map[string]string{"KEY": "value"},
+		},
+		{
+			name:     "line without equals",
+			content:  "INVALID\nKEY=value\n",
+			expected: map[string]string{"KEY": "value"},
+		},
+		{
+			name:     "single quotes",
+			content:  "KEY='  value \\n $HOME '\n",
+			expected: map[string]string{"KEY": "  value \\n $HOME "},
+		},
+		{
+			name:     "double quotes",
+			content:  `KEY="a \"quoted\" \\ \$ \n value"`,
+			expected: map[string]string{"KEY": `a "quoted" \ $ \n value`},
+		},
+		{
+			name:     "multiline quotes",
+			content:  "KEY=\"line1\nline2\"\nOTHER='a\nb'\n",
+			expected: map[string]string{"KEY": "line1\nline2", "OTHER": "a\nb"},
+		},
+		{
+			name:     "line continuation",
+			content:  "KEY=one \\\ntwo\nOTHER=\"a\\\nb\"\n",
+			expected: map[string]string{"KEY": "one two", "OTHER": "ab"},
+		},
+		{
+			name:     "unquoted escapes",
+			content:  "KEY=a\\ \\\"b\\\\\n",
+			expected: map[string]string{"KEY": `a "b\`},
+		},
+		{
+			name:     "escaped trailing whitespace",
+			content:  "KEY=value\\ \n",
+			expected: map[string]string{"KEY": "value "},
+		},
+		{
+			name:     "quoted concatenation",
+			content:  `KEY="a"'b'c`,
+			expected: map[string]string{"KEY": "abc"},
+		},
+		{
+			name:     "crlf",
+			content:  "KEY=value\r\nOTHER=other\r\n",
+			expected: map[string]string{"KEY": "value", "OTHER": "other"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Parse(tc.content))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.env")
+	require.NoError(t, os.WriteFile(path, []byte("KEY=value\n"), 0o600))
+
+	envs, err := New(path).Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, envs)
+
+	_, err = New(filepath.Join(dir, "missing.env")).Load()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	envs, err = New("-" + filepath.Join(dir, "missing.env")).Load()
+	require.NoError(t, err)
+	assert.Empty(t, envs)
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{"app.env": {Data: []byte("KEY='value'")}}
+
+	envs, err := NewFS(fsys, "app.env").Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"KEY": "value"}, envs)
+}
@@ -7,18 +7,29 @@ import (
 	"unicode"
 )
 
+// Tag is a parsed struct tag, e.g. `env:"NAME,required"`
+// has the value "NAME" and the option "required".
 type Tag struct {
 	Name    string
 	Value   string
 	Options map[string]string
 }
 
+// TagMap holds the parsed tags of a struct field. A field path, from the
+// top level struct down to the current field, is a []TagMap.
 type TagMap struct {
 	FieldName string
 	Type      reflect.Type
 	Tags      map[string]Tag
+	// Anonymous is true for embedded fields.
+	Anonymous bool
+	// Order lists the tag names in the order they are declared,
+	// followed by "struct" and "struct_snake".
+	Order []string
 }
 
+// ParseTags parses all the tags of a struct field. The "struct" and
+// "struct_snake" tags are added with the field name and its snake case form.
 func ParseTags(rfs reflect.StructField) TagMap {
 	rft := rfs.Tag
 
@@ -26,6 +37,7 @@ func ParseTags(rfs reflect.StructField) TagMap {
 		FieldName: rfs.Name,
 		Type:      rfs.Type,
 		Tags:      map[string]Tag{},
+		Anonymous: rfs.Anonymous,
 	}
 	// otherwise parse all tags
 
@@ -69,6 +81,19 @@ func ParseTags(rfs reflect.StructField) TagMap {
 		if err == nil {
 			value, options := parseTag(value)
 
+			switch name {
+			case "protobuf":
+				// generated protobuf fields lead with the wire type,
+				// the proto field name is in the name option.
+				value = options["name"]
+			case "protobuf_key", "protobuf_val":
+				value = ""
+			}
+
+			if _, ok := tm.Tags[name]; !ok {
+				tm.Order = append(tm.Order, name)
+			}
+
 			tm.Tags[name] = Tag{
 				Name:    name,
 				Value:   value,
@@ -89,6 +114,8 @@ func ParseTags(rfs reflect.StructField) TagMap {
 		Options: map[string]string{},
 	}
 
+	tm.Order = append(tm.Order, "struct", "struct_snake")
+
 	return tm
 }
 
@@ -35,6 +35,7 @@ func TestParseTag(t *testing.T) {
 						Options: map[string]string{},
 					},
 				},
+				Order: []string{"env", "struct", "struct_snake"},
 			},
 		},
 		{
@@ -63,6 +64,7 @@ func TestParseTag(t *testing.T) {
 						Options: map[string]string{},
 					},
 				},
+				Order: []string{"env", "struct", "struct_snake"},
 			},
 		},
 		{
@@ -92,6 +94,48 @@ func TestParseTag(t *testing.T) {
 						Options: map[string]string{},
 					},
 				},
+				Order: []string{"json", "toml", "struct", "struct_snake"},
+			},
+		},
+		{
+			name:  "protobuf tags",
+			input: `protobuf:"bytes,1,opt,name=test_field,json=testField,proto3" protobuf_key:"bytes,1,opt,name=key,proto3"`,
+			expected: TagMap{
+				FieldName: "TestField",
+				Tags: map[string]Tag{
+					"protobuf": {
+						Name:  "protobuf",
+						Value: "test_field",
+						Options: map[string]string{
+							"1":      "",
+							"opt":    "",
+							"name":   "test_field",
+							"json":   "testField",
+							"proto3": "",
+						},
+					},
+					"protobuf_key": {
+						Name:  "protobuf_key",
+						Value: "",
+						Options: map[string]string{
+							"1":      "",
+							"opt":    "",
+							"name":   "key",
+							"proto3": "",
+						},
+					},
+					"struct": {
+						Name:    "struct",
+						Value:   "TestField",
+						Options: map[string]string{},
+					},
+					"struct_snake": {
+						Name:    "struct_snake",
+						Value:   "test_field",
+						Options: map[string]string{},
+					},
+				},
+				Order: []string{"protobuf", "protobuf_key", "struct", "struct_snake"},
 			},
 		},
 	}
@@ -0,0 +1,67 @@
+package envcfg
+
+import (
+	"context"
+	"strings"
+)
+
+// Tree returns the loaded environment variables under prefix as a nested map,
+// splitting keys on underscores. For example, with prefix "APP", the variable
+// APP_DB_HOST=localhost becomes {"DB": {"HOST": "localhost"}}.
+// An empty prefix returns every loaded variable. When a key holds a value and
+// nested keys at the same time, the value is stored under the empty key.
+func Tree(prefix string, opts ...Option) (map[string]any, error) {
+	return TreeContext(context.Background(), prefix, opts...)
+}
+
+// TreeContext is like Tree, but passes ctx to sources implementing
+// sources.ContextSource.
+func TreeContext(ctx context.Context, prefix string, opts ...Option) (map[string]any, error) {
+	o, err := build(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = strings.TrimSuffix(strings.ToUpper(prefix), "_")
+
+	tree := make(map[string]any)
+
+	for key, value := range o.Matcher.EnvVars {
+		rest := key
+		if prefix != "" {
+			var ok bool
+			if rest, ok = strings.CutPrefix(key, prefix+"_"); !ok {
+				continue
+			}
+		}
+
+		insert(tree, strings.Split(rest, "_"), value)
+	}
+
+	return tree, nil
+}
+
+func insert(node map[string]any, parts []string, value string) {
+	head := parts[0]
+
+	if len(parts) == 1 {
+		if child, ok := node[head].(map[string]any); ok {
+			child[""] = value
+			return
+		}
+
+		node[head] = value
+		return
+	}
+
+	child, ok := node[head].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		if existing, ok := node[head].(string); ok {
+			child[""] = existing
+		}
+		node[head] = child
+	}
+
+	insert(child, parts[1:], value)
+}
@@ -0,0 +1,73 @@
+package envcfg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/sethpollack/envcfg/sources/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTree(t *testing.T) {
+	env := map[string]string{
+		"APP_NAME":         "app",
+		"APP_DB":           "postgres",
+		"APP_DB_HOST":      "localhost",
+		"APP_DB_PORT":      "5432",
+		"APP_SERVERS_0":    "host1",
+		"APP_CACHE_TTL":    "60s",
+		"APP_CACHE":        "redis",
+		"OTHER_VALUE":      "other",
+		"APPLICATION_NAME": "application",
+	}
+
+	opts := []envcfg.Option{envcfg.WithLoader(envcfg.WithMapEnvSource(env))}
+
+	t.Run("prefix", func(t *testing.T) {
+		tree, err := envcfg.Tree("app_", opts...)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"NAME": "app",
+			"DB": map[string]any{
+				"":     "postgres",
+				"HOST": "localhost",
+				"PORT": "5432",
+			},
+			"SERVERS": map[string]any{"0": "host1"},
+			"CACHE": map[string]any{
+				"":    "redis",
+				"TTL": "60s",
+			},
+		}, tree)
+	})
+
+	t.Run("no prefix", func(t *testing.T) {
+		tree, err := envcfg.Tree("", envcfg.WithLoader(envcfg.WithMapEnvSource(map[string]string{
+			"OTHER_VALUE": "other",
+		})))
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"OTHER": map[string]any{"VALUE": "other"}}, tree)
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		_, err := envcfg.Tree("", envcfg.WithLoader(envcfg.WithSource(&customSource{})))
+
+		assert.ErrorIs(t, err, errs.ErrLoadEnv)
+	})
+}
+
+func TestTreeContext(t *testing.T) {
+	src := mock.New(mock.Response{Values: map[string]string{"APP_NAME": "app"}, Delay: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := envcfg.TreeContext(ctx, "app", envcfg.WithLoader(envcfg.WithSource(src)))
+	assert.ErrorIs(t, err, context.Canceled)
+}
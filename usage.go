@@ -0,0 +1,208 @@
+package envcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Usage returns a table describing the environment variables the provided
+// struct can be configured with, including the description and example
+// values from the "desc" and "example" tags. No sources are loaded.
+func Usage(cfg any, opts ...Option) (string, error) {
+	specs, err := fieldSpecs(cfg, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tTYPE\tDEFAULT\tREQUIRED\tDESCRIPTION\tEXAMPLE")
+
+	for _, spec := range specs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\n",
+			spec.Key, spec.Type, spec.Default, spec.Required, spec.Description, spec.Example)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// Markdown returns a Markdown table documenting the environment variables the
+// provided struct can be configured with, e.g. for a README. No sources are
+// loaded.
+func Markdown(cfg any, opts ...Option) (string, error) {
+	specs, err := fieldSpecs(cfg, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("| Variable | Type | Default | Required | Description | Example |\n")
+	sb.WriteString("|----------|------|---------|----------|-------------|---------|\n")
+
+	for _, spec := range specs {
+		fmt.Fprintf(&sb, "| `%s` | `%s` | %s | %t | %s | %s |\n",
+			spec.Key, spec.Type, markdownCode(spec.Default), spec.Required,
+			markdownText(spec.Description), markdownCode(spec.Example))
+	}
+
+	return sb.String(), nil
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return "`" + markdownText(s) + "`"
+}
+
+func markdownText(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+type jsonSchema struct {
+	Schema     string                    `json:"$schema"`
+	Type       string                    `json:"type"`
+	Properties map[string]schemaProperty `json:"properties"`
+	Required   []string                  `json:"required,omitempty"`
+}
+
+type schemaProperty struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Default     any    `json:"default,omitempty"`
+	Examples    []any  `json:"examples,omitempty"`
+	WriteOnly   bool   `json:"writeOnly,omitempty"`
+}
+
+// JSONSchema returns a JSON Schema of the environment variables the provided
+// struct can be configured with, with their descriptions, defaults and
+// examples. Sensitive variables are write-only and their defaults are left
+// out. No sources are loaded.
+func JSONSchema(cfg any, opts ...Option) ([]byte, error) {
+	specs, err := fieldSpecs(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := jsonSchema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Type:       "object",
+		Properties: make(map[string]schemaProperty, len(specs)),
+	}
+
+	for _, spec := range specs {
+		typ := schemaType(spec.Type)
+
+		prop := schemaProperty{
+			Type:        typ,
+			Description: spec.Description,
+			WriteOnly:   spec.Sensitive,
+		}
+
+		if spec.HasDefault && !spec.Sensitive {
+			prop.Default = schemaValue(typ, spec.Default)
+		}
+
+		if spec.Example != "" {
+			prop.Examples = []any{schemaValue(typ, spec.Example)}
+		}
+
+		schema.Properties[spec.Key] = prop
+
+		if spec.Required {
+			schema.Required = append(schema.Required, spec.Key)
+		}
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaType returns the JSON Schema type of the values of t, types parsed
+// from text, such as durations and slices, are strings.
+func schemaType(t reflect.Type) string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.PkgPath() != "" {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// schemaValue converts a default or example value to the JSON Schema type,
+// values that don't parse are kept as strings.
+func schemaValue(typ, value string) any {
+	switch typ {
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(value, 0, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
+
+// DotEnvExample returns a .env.example style template for the provided struct.
+// Each variable is preceded by its description and set to its example value,
+// falling back to its default value, except for sensitive variables, which
+// are left empty without an example. No sources are loaded.
+func DotEnvExample(cfg any, opts ...Option) (string, error) {
+	specs, err := fieldSpecs(cfg, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for _, spec := range specs {
+		if spec.Description != "" {
+			fmt.Fprintf(&sb, "# %s\n", spec.Description)
+		}
+
+		fmt.Fprintf(&sb, "%s=%s\n", spec.Key, exampleValue(spec))
+	}
+
+	return sb.String(), nil
+}
+
+// exampleValue returns the example value of spec, falling back to its default
+// value, which is left out for sensitive variables.
+func exampleValue(spec VarSpec) string {
+	if spec.Example != "" || spec.Sensitive {
+		return spec.Example
+	}
+
+	return spec.Default
+}
@@ -0,0 +1,104 @@
+package envcfg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type usageConfig struct {
+	Port    int           `desc:"Port to listen on" example:"8080" required:"true"`
+	Timeout time.Duration `env:"TIMEOUT,default=30s" desc:"Request timeout"`
+	Redis   struct {
+		Host string `info:"Redis host" sample:"localhost"`
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t.Run("usage", func(t *testing.T) {
+		t.Setenv("PORT", "9090")
+
+		usage, err := envcfg.Usage(&usageConfig{})
+
+		require.NoError(t, err)
+		assert.Equal(t, `KEY         TYPE           DEFAULT  REQUIRED  DESCRIPTION        EXAMPLE
+PORT        int                     true      Port to listen on  8080
+TIMEOUT     time.Duration  30s      false     Request timeout    
+REDIS_HOST  string                  false                        
+`, usage)
+	})
+
+	t.Run("custom tags", func(t *testing.T) {
+		example, err := envcfg.DotEnvExample(usageConfig{},
+			envcfg.WithDescriptionTag("info"),
+			envcfg.WithExampleTag("sample"),
+		)
+
+		require.NoError(t, err)
+		assert.Equal(t, "PORT=\nTIMEOUT=30s\n# Redis host\nREDIS_HOST=localhost\n", example)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := envcfg.Usage("not a struct")
+
+		assert.ErrorIs(t, err, errs.ErrNotAPointer)
+	})
+}
+
+func TestDotEnvExample(t *testing.T) {
+	example, err := envcfg.DotEnvExample(&usageConfig{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "# Port to listen on\nPORT=8080\n# Request timeout\nTIMEOUT=30s\nREDIS_HOST=\n", example)
+}
+
+func TestDotEnvExampleSensitive(t *testing.T) {
+	type Config struct {
+		Token    string `default:"secret" sensitive:"true"`
+		Password string `default:"secret" example:"changeme" sensitive:"true"`
+	}
+
+	example, err := envcfg.DotEnvExample(&Config{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN=\nPASSWORD=changeme\n", example)
+}
+
+func TestMarkdown(t *testing.T) {
+	doc, err := envcfg.Markdown(&usageConfig{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "| Variable | Type | Default | Required | Description | Example |\n"+
+		"|----------|------|---------|----------|-------------|---------|\n"+
+		"| `PORT` | `int` | - | true | Port to listen on | `8080` |\n"+
+		"| `TIMEOUT` | `time.Duration` | `30s` | false | Request timeout | - |\n"+
+		"| `REDIS_HOST` | `string` | - | false |  | - |\n", doc)
+}
+
+func TestJSONSchema(t *testing.T) {
+	type Config struct {
+		Port    int           `desc:"Port to listen on" example:"8080" required:"true"`
+		Debug   bool          `default:"true"`
+		Timeout time.Duration `default:"30s"`
+		Token   string        `default:"secret" sensitive:"true"`
+	}
+
+	schema, err := envcfg.JSONSchema(&Config{})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"PORT": {"type": "integer", "description": "Port to listen on", "examples": [8080]},
+			"DEBUG": {"type": "boolean", "default": true},
+			"TIMEOUT": {"type": "string", "default": "30s"},
+			"TOKEN": {"type": "string", "writeOnly": true}
+		},
+		"required": ["PORT"]
+	}`, string(schema))
+}
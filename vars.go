@@ -0,0 +1,77 @@
+package envcfg
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/sethpollack/envcfg/tag"
+)
+
+// VarSpec describes an environment variable a struct can be configured with.
+type VarSpec struct {
+	// Key is the primary environment variable name.
+	Key string
+	// Field is the dotted path of the struct field, e.g. "Redis.Host".
+	Field string
+	// Type is the type of the struct field.
+	Type reflect.Type
+
+	Default     string
+	HasDefault  bool
+	Required    bool
+	NotEmpty    bool
+	Expand      bool
+	File        bool
+	Sensitive   bool
+	Description string
+	Example     string
+}
+
+// Vars statically analyzes the struct type T and returns every environment
+// variable it can be configured with. No sources are loaded.
+func Vars[T any](opts ...Option) ([]VarSpec, error) {
+	var t T
+	return fieldSpecs(t, opts...)
+}
+
+// fieldSpecs statically describes every variable the struct can consume.
+func fieldSpecs(cfg any, opts ...Option) ([]VarSpec, error) {
+	o := newOptions(opts...)
+
+	fields, err := o.Walker.Fields(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]VarSpec, 0, len(fields))
+	for _, path := range fields {
+		spec := o.Matcher.Spec(path)
+
+		specs = append(specs, VarSpec{
+			Key:         spec.Key,
+			Field:       fieldName(path),
+			Type:        path[len(path)-1].Type,
+			Default:     spec.Default,
+			HasDefault:  spec.HasDefault,
+			Required:    spec.Required,
+			NotEmpty:    spec.NotEmpty,
+			Expand:      spec.Expand,
+			File:        spec.File,
+			Sensitive:   spec.Sensitive,
+			Description: spec.Description,
+			Example:     spec.Example,
+		})
+	}
+
+	return specs, nil
+}
+
+// fieldName returns the dotted field path, e.g. "Redis.Host".
+func fieldName(path []tag.TagMap) string {
+	names := make([]string, 0, len(path))
+	for _, tm := range path {
+		names = append(names, tm.FieldName)
+	}
+
+	return strings.Join(names, ".")
+}
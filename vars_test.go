@@ -0,0 +1,69 @@
+package envcfg_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg"
+	errs "github.com/sethpollack/envcfg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVars(t *testing.T) {
+	t.Run("struct", func(t *testing.T) {
+		t.Setenv("PORT", "9090")
+
+		vars, err := envcfg.Vars[usageConfig]()
+
+		require.NoError(t, err)
+		assert.Equal(t, []envcfg.VarSpec{
+			{
+				Key:         "PORT",
+				Field:       "Port",
+				Type:        reflect.TypeOf(0),
+				Required:    true,
+				Description: "Port to listen on",
+				Example:     "8080",
+			},
+			{
+				Key:         "TIMEOUT",
+				Field:       "Timeout",
+				Type:        reflect.TypeOf(time.Duration(0)),
+				Default:     "30s",
+				HasDefault:  true,
+				Description: "Request timeout",
+			},
+			{
+				Key:   "REDIS_HOST",
+				Field: "Redis.Host",
+				Type:  reflect.TypeOf(""),
+			},
+		}, vars)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		vars, err := envcfg.Vars[struct {
+			Password string `secret:"true"`
+		}](envcfg.WithSensitiveTag("secret"), envcfg.WithRequired())
+
+		require.NoError(t, err)
+		require.Len(t, vars, 1)
+		assert.True(t, vars[0].Sensitive)
+		assert.True(t, vars[0].Required)
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		vars, err := envcfg.Vars[*usageConfig]()
+
+		require.NoError(t, err)
+		assert.Len(t, vars, 3)
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		_, err := envcfg.Vars[string]()
+
+		assert.ErrorIs(t, err, errs.ErrNotAPointer)
+	})
+}
@@ -0,0 +1,18 @@
+module github.com/sethpollack/envcfg/watch
+
+go 1.22
+
+replace github.com/sethpollack/envcfg => ../
+
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/sethpollack/envcfg v0.0.0-20241201181600-b026eb186a76
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.4.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
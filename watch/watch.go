@@ -0,0 +1,257 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sethpollack/envcfg"
+)
+
+// DefaultDebounce is how long changes have to settle before they are sent.
+const DefaultDebounce = 100 * time.Millisecond
+
+type Option func(*watcher)
+
+// WithDebounce sets how long changes have to settle before they are sent,
+// so that a burst of writes triggers a single reload.
+func WithDebounce(d time.Duration) Option {
+	return func(w *watcher) {
+		w.debounce = d
+	}
+}
+
+// WithErrorHandler sets the function called with the errors of the
+// underlying watcher, which are ignored by default.
+func WithErrorHandler(handler func(error)) Option {
+	return func(w *watcher) {
+		w.onError = handler
+	}
+}
+
+type watcher struct {
+	debounce time.Duration
+	onError  func(error)
+
+	fw *fsnotify.Watcher
+
+	mu      sync.Mutex
+	watched map[string]bool
+	names   map[string]bool
+	dirs    map[string]bool
+}
+
+// Files watches the files and directories at paths and sends on the returned
+// channel once changes have settled. The parent directory of each file is
+// watched, so that files that are created later or replaced, such as mounted
+// Kubernetes secrets updated by swapping a symlink, are noticed, but only
+// changes of the files themselves, of their symlink targets and of files in
+// watched directories are sent. The channel is closed when ctx is done.
+func Files(ctx context.Context, paths []string, opts ...Option) (<-chan struct{}, error) {
+	w, err := newWatcher(paths, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+
+	go w.run(ctx, changes)
+
+	return changes, nil
+}
+
+// Reload reloads r whenever the files it was read from change, until ctx is
+// done. The watched files are refreshed from r.Files after every reload, so
+// that files added to the configuration are watched as well. Reload errors
+// are passed to onError, which may be nil.
+func Reload[T any](ctx context.Context, r *envcfg.Reloader[T], onError func(error), opts ...Option) error {
+	w, err := newWatcher(r.Files(), opts...)
+	if err != nil {
+		return err
+	}
+
+	changes := make(chan struct{}, 1)
+
+	go w.run(ctx, changes)
+
+	go func() {
+		for range changes {
+			if err := r.ReloadContext(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+
+			if err := w.watch(r.Files()); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func newWatcher(paths []string, opts ...Option) (*watcher, error) {
+	w := &watcher{
+		debounce: DefaultDebounce,
+		watched:  map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	w.fw = fw
+
+	if err := w.watch(paths); err != nil {
+		fw.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// watch replaces the watched paths, adding the directories that are not
+// watched yet and removing those that are no longer needed.
+func (w *watcher) watch(paths []string) error {
+	names := make(map[string]bool, len(paths))
+	dirs := map[string]bool{}
+
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		names[path] = true
+
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dirs[path] = true
+		} else if target := linkTarget(path); target != "" {
+			names[target] = true
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	needed := map[string]bool{}
+	for _, dir := range watchDirs(paths) {
+		needed[dir] = true
+
+		if w.watched[dir] {
+			continue
+		}
+
+		if err := w.fw.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+
+		w.watched[dir] = true
+	}
+
+	for dir := range w.watched {
+		if !needed[dir] {
+			// the directory may be gone already, in which case it is no
+			// longer watched either.
+			_ = w.fw.Remove(dir)
+			delete(w.watched, dir)
+		}
+	}
+
+	w.names = names
+	w.dirs = dirs
+
+	return nil
+}
+
+// matches reports whether an event for name concerns a watched path.
+func (w *watcher) matches(name string) bool {
+	name = filepath.Clean(name)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.names[name] || w.dirs[filepath.Dir(name)]
+}
+
+func (w *watcher) run(ctx context.Context, changes chan<- struct{}) {
+	defer close(changes)
+	defer w.fw.Close()
+
+	fw := w.fw
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+
+			if w.matches(ev.Name) {
+				timer.Reset(w.debounce)
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+
+			if w.onError != nil {
+				w.onError(err)
+			}
+		case <-timer.C:
+			// a pending change is already queued when the channel is full.
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// linkTarget returns the entry next to path that the symlink at path points
+// through, such as the ..data symlink of mounted Kubernetes secrets, or ""
+// when path is not a relative symlink.
+func linkTarget(path string) string {
+	target, err := os.Readlink(path)
+	if err != nil || filepath.IsAbs(target) {
+		return ""
+	}
+
+	first, _, _ := strings.Cut(filepath.ToSlash(filepath.Clean(target)), "/")
+	if first == "." || first == ".." {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(path), first)
+}
+
+// watchDirs returns the directories to watch for paths, directories
+// themselves and the parent directory of files.
+func watchDirs(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+
+	var dirs []string
+	for _, path := range paths {
+		dir := path
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
@@ -0,0 +1,175 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sethpollack/envcfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := Files(ctx, []string{path}, WithDebounce(10*time.Millisecond))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("PORT=8080"), 0o600))
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change received")
+	}
+
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		_, ok := <-changes
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestFilesIgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := Files(ctx, []string{path}, WithDebounce(10*time.Millisecond))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte("x"), 0o600))
+
+	select {
+	case <-changes:
+		t.Fatal("change received for an unwatched file")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("PORT=8080"), 0o600))
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change received")
+	}
+}
+
+func TestFilesSymlink(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..v1"), 0o700))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..v2"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..v1", "password"), []byte("old"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..v2", "password"), []byte("new"), 0o600))
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "password"), filepath.Join(dir, "password")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := Files(ctx, []string{filepath.Join(dir, "password")}, WithDebounce(10*time.Millisecond))
+	require.NoError(t, err)
+
+	// swap the ..data symlink the way Kubernetes updates mounted secrets.
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change received")
+	}
+}
+
+func TestReload(t *testing.T) {
+	type Config struct {
+		Port int
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(path, []byte("PORT=8080"), 0o600))
+
+	r, err := envcfg.NewReloader[Config](
+		envcfg.WithLoader(envcfg.WithDotEnvSource(path)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, r.Files())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, Reload(ctx, r, nil, WithDebounce(10*time.Millisecond)))
+
+	require.NoError(t, os.WriteFile(path, []byte("PORT=9090"), 0o600))
+
+	assert.Eventually(t, func() bool {
+		return r.Get().Port == 9090
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestReloadWatchesNewFiles(t *testing.T) {
+	type Config struct {
+		Password string `file:"true"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	first := filepath.Join(t.TempDir(), "password")
+	second := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(first, []byte("first"), 0o600))
+	require.NoError(t, os.WriteFile(second, []byte("second"), 0o600))
+	require.NoError(t, os.WriteFile(path, []byte("PASSWORD="+first), 0o600))
+
+	r, err := envcfg.NewReloader[Config](
+		envcfg.WithLoader(envcfg.WithDotEnvSource(path)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "first", r.Get().Password)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, Reload(ctx, r, nil, WithDebounce(10*time.Millisecond)))
+
+	require.NoError(t, os.WriteFile(path, []byte("PASSWORD="+second), 0o600))
+
+	assert.Eventually(t, func() bool {
+		return r.Get().Password == "second"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return slices.Contains(r.Files(), second)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// give the watcher time to pick up the new file.
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(second, []byte("updated"), 0o600))
+
+	assert.Eventually(t, func() bool {
+		return r.Get().Password == "updated"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatchDirs(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.Equal(t, []string{dir, "."}, watchDirs([]string{
+		dir,
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, ".env.local"),
+		".env",
+	}))
+}